@@ -0,0 +1,187 @@
+package gocamel
+
+import (
+	"fmt"
+)
+
+// AdviceWith modifie une route déjà construite, typiquement dans un test unitaire, afin de
+// remplacer ses endpoints réels (FTP, SMTP, MongoDB...) par des endpoints direct: ou mock:.
+// La route doit être arrêtée ; les modifications sont appliquées dans l'ordre des appels
+// et la première erreur rencontrée est retournée.
+//
+//	err := ctx.AdviceWith("orders", func(a *AdviceWithRouteBuilder) {
+//		a.ReplaceFromWith("direct:start")
+//		a.InterceptSendToEndpoint("smtp:*").SkipSendToOriginalEndpoint().To("mock:mail")
+//		a.WeaveByID("enrich").Replace().SetBody("enriched")
+//	})
+func (c *CamelContext) AdviceWith(routeID string, advice func(a *AdviceWithRouteBuilder)) error {
+	route := c.GetRoute(routeID)
+	if route == nil {
+		return fmt.Errorf("route non trouvée: %s", routeID)
+	}
+	if route.IsStarted() {
+		return fmt.Errorf("la route %s doit être arrêtée pour être modifiée", routeID)
+	}
+
+	builder := &AdviceWithRouteBuilder{context: c, route: route}
+	advice(builder)
+	if builder.err != nil {
+		return fmt.Errorf("AdviceWith sur la route %s: %w", routeID, builder.err)
+	}
+	return nil
+}
+
+// AdviceWithRouteBuilder décrit les modifications à appliquer à une route
+type AdviceWithRouteBuilder struct {
+	context *CamelContext
+	route   *Route
+	err     error
+}
+
+// fail conserve la première erreur rencontrée
+func (a *AdviceWithRouteBuilder) fail(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
+
+// ReplaceFromWith remplace l'endpoint source de la route
+func (a *AdviceWithRouteBuilder) ReplaceFromWith(uri string) *AdviceWithRouteBuilder {
+	endpoint, err := a.context.CreateEndpoint(uri)
+	if err != nil {
+		a.fail(fmt.Errorf("erreur lors de la création de l'endpoint %s: %w", uri, err))
+		return a
+	}
+	a.route.from = endpoint
+	return a
+}
+
+// InterceptSendToEndpoint intercepte les envois de la route vers les endpoints dont l'URI
+// correspond au motif (URI exacte, jokers "*" ou expression régulière).
+// Les processeurs ajoutés à la définition sont exécutés avant l'envoi.
+func (a *AdviceWithRouteBuilder) InterceptSendToEndpoint(pattern string) *InterceptSendToEndpointDefinition {
+	interceptor := newSendInterceptor(pattern)
+	a.route.sendInterceptors = append(a.route.sendInterceptors, interceptor)
+
+	return newInterceptSendToEndpointDefinition(a.context, a.route, nil, interceptor)
+}
+
+// WeaveByID sélectionne le nœud de premier niveau portant cet identifiant
+func (a *AdviceWithRouteBuilder) WeaveByID(id string) *WeaveDefinition {
+	return &WeaveDefinition{advice: a, id: id}
+}
+
+// WeaveAddFirst ajoute des processeurs au début de la route
+func (a *AdviceWithRouteBuilder) WeaveAddFirst() *RouteBuilder {
+	return a.weaveBuilder(&weaveContainer{route: a.route, mode: weaveFirst})
+}
+
+// WeaveAddLast ajoute des processeurs à la fin de la route
+func (a *AdviceWithRouteBuilder) WeaveAddLast() *RouteBuilder {
+	return a.weaveBuilder(&weaveContainer{route: a.route, mode: weaveLast})
+}
+
+func (a *AdviceWithRouteBuilder) weaveBuilder(container ProcessorContainer) *RouteBuilder {
+	return &RouteBuilder{
+		context:   a.context,
+		route:     a.route,
+		container: container,
+	}
+}
+
+// WeaveDefinition cible un nœud de la route par son identifiant
+type WeaveDefinition struct {
+	advice *AdviceWithRouteBuilder
+	id     string
+}
+
+// Before insère des processeurs avant le nœud
+func (w *WeaveDefinition) Before() *RouteBuilder {
+	return w.builder(weaveBefore)
+}
+
+// After insère des processeurs après le nœud
+func (w *WeaveDefinition) After() *RouteBuilder {
+	return w.builder(weaveAfter)
+}
+
+// Replace remplace le nœud par les processeurs ajoutés ensuite
+func (w *WeaveDefinition) Replace() *RouteBuilder {
+	return w.builder(weaveReplace)
+}
+
+// Remove supprime le nœud de la route
+func (w *WeaveDefinition) Remove() *AdviceWithRouteBuilder {
+	index := w.advice.route.nodeIndex(w.id)
+	if index < 0 {
+		w.advice.fail(fmt.Errorf("nœud non trouvé: %s", w.id))
+		return w.advice
+	}
+	w.advice.route.removeNode(index)
+	return w.advice
+}
+
+func (w *WeaveDefinition) builder(mode weaveMode) *RouteBuilder {
+	if w.advice.route.nodeIndex(w.id) < 0 {
+		w.advice.fail(fmt.Errorf("nœud non trouvé: %s", w.id))
+		// Les processeurs ajoutés sont ignorés, l'erreur est retournée par AdviceWith
+		return w.advice.weaveBuilder(NewPipeline())
+	}
+	return w.advice.weaveBuilder(&weaveContainer{route: w.advice.route, anchor: w.id, mode: mode})
+}
+
+// weaveMode indique où un weaveContainer insère les processeurs
+type weaveMode int
+
+const (
+	weaveBefore weaveMode = iota
+	weaveAfter
+	weaveReplace
+	weaveFirst
+	weaveLast
+)
+
+// weaveContainer insère les processeurs qu'on lui ajoute dans la route, relativement
+// au nœud anchor. Les processeurs successifs sont insérés les uns après les autres.
+type weaveContainer struct {
+	route  *Route
+	anchor string
+	mode   weaveMode
+	// last est l'identifiant du dernier nœud inséré par ce conteneur
+	last string
+}
+
+// AddProcessor implémente ProcessorContainer
+func (w *weaveContainer) AddProcessor(processor Processor) {
+	var index int
+	switch {
+	case w.last != "":
+		index = w.indexAfter(w.last)
+	case w.mode == weaveFirst:
+		index = 0
+	case w.mode == weaveLast:
+		index = len(w.route.processors)
+	case w.mode == weaveBefore:
+		index = w.route.nodeIndex(w.anchor)
+	case w.mode == weaveAfter:
+		index = w.indexAfter(w.anchor)
+	case w.mode == weaveReplace:
+		index = w.route.nodeIndex(w.anchor)
+		if index >= 0 {
+			w.route.removeNode(index)
+		}
+	}
+	if index < 0 {
+		// Le nœud de référence a été supprimé entre-temps : on ajoute en fin de route
+		index = len(w.route.processors)
+	}
+	w.last = w.route.insertNode(index, processor)
+}
+
+// indexAfter retourne la position suivant le nœud, ou -1 s'il n'existe plus
+func (w *weaveContainer) indexAfter(id string) int {
+	if index := w.route.nodeIndex(id); index >= 0 {
+		return index + 1
+	}
+	return -1
+}
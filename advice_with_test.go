@@ -0,0 +1,123 @@
+package gocamel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdvisedOrderRoute construit une route qui dépend de serveurs FTP et SMTP réels
+func newAdvisedOrderRoute(t *testing.T) *CamelContext {
+	t.Helper()
+	camel := newTestContext()
+	camel.AddComponent("ftp", NewFTPComponent())
+	camel.AddComponent("smtp", NewMailComponent())
+
+	builder := camel.CreateRouteBuilder().
+		From("ftp://ftp.example.com/orders").
+		SetHeader("stage", "received").NodeID("receive").
+		ProcessFunc(func(e *Exchange) error {
+			body, _ := e.GetIn().GetBodyAsString()
+			e.GetOut().SetBody("order:" + body)
+			return nil
+		}).NodeID("transform").
+		To("smtp://mail.example.com?to=ops@example.com").
+		To("mock:archive").NodeID("archive")
+	builder.Build().SetID("orders")
+	return camel
+}
+
+func TestAdviceWith_ReplaceFromAndInterceptSend(t *testing.T) {
+	camel := newAdvisedOrderRoute(t)
+
+	err := camel.AdviceWith("orders", func(a *AdviceWithRouteBuilder) {
+		a.ReplaceFromWith("direct:start")
+		a.InterceptSendToEndpoint("smtp:*").SkipSendToOriginalEndpoint().To("mock:mail")
+	})
+	require.NoError(t, err)
+
+	mail, _ := camel.GetMockEndpoint("mock:mail")
+	mail.ExpectedBodiesReceived("order:42")
+	mail.MessageN(0).Predicate(func(e *Exchange) bool {
+		uri, _ := e.GetPropertyAsString(CamelInterceptedEndpoint)
+		return uri == "smtp://mail.example.com?to=ops@example.com"
+	})
+	archive, _ := camel.GetMockEndpoint("mock:archive")
+	archive.ExpectedBodiesReceived("order:42")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "42"))
+
+	mail.AssertIsSatisfied(t, time.Second)
+	archive.AssertIsSatisfied(t, time.Second)
+}
+
+func TestAdviceWith_Weave(t *testing.T) {
+	camel := newAdvisedOrderRoute(t)
+
+	err := camel.AdviceWith("orders", func(a *AdviceWithRouteBuilder) {
+		a.ReplaceFromWith("direct:start")
+		a.InterceptSendToEndpoint("smtp://.*").SkipSendToOriginalEndpoint()
+		a.WeaveByID("receive").Remove()
+		a.WeaveByID("transform").Before().To("mock:before").SetHeader("woven", true)
+		a.WeaveByID("transform").Replace().SetBody("replaced")
+		a.WeaveByID("archive").After().To("mock:after")
+		a.WeaveAddFirst().To("mock:first")
+	})
+	require.NoError(t, err)
+
+	route := camel.GetRoute("orders")
+	nodes := route.NodeIDs()
+	assert.Len(t, nodes, 7)
+	assert.NotContains(t, nodes, "receive")
+	assert.NotContains(t, nodes, "transform")
+	assert.Equal(t, "archive", nodes[5])
+
+	before, _ := camel.GetMockEndpoint("mock:before")
+	before.ExpectedBodiesReceived("42")
+	after, _ := camel.GetMockEndpoint("mock:after")
+	after.ExpectedBodiesReceived("replaced")
+	after.ExpectedHeaderReceived("woven", true)
+	after.MessageN(0).Predicate(func(e *Exchange) bool {
+		_, exists := e.GetIn().GetHeader("stage")
+		return !exists
+	})
+	first, _ := camel.GetMockEndpoint("mock:first")
+	first.ExpectedMessageCount(1)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "42"))
+
+	before.AssertIsSatisfied(t, time.Second)
+	after.AssertIsSatisfied(t, time.Second)
+	first.AssertIsSatisfied(t, time.Second)
+}
+
+func TestAdviceWith_Errors(t *testing.T) {
+	camel := newAdvisedOrderRoute(t)
+
+	assert.Error(t, camel.AdviceWith("unknown", func(a *AdviceWithRouteBuilder) {}))
+
+	err := camel.AdviceWith("orders", func(a *AdviceWithRouteBuilder) {
+		a.WeaveByID("missing").Replace().SetBody("x")
+	})
+	assert.ErrorContains(t, err, "missing")
+
+	err = camel.AdviceWith("orders", func(a *AdviceWithRouteBuilder) {
+		a.ReplaceFromWith("unknown:start")
+	})
+	assert.Error(t, err)
+
+	require.NoError(t, camel.AdviceWith("orders", func(a *AdviceWithRouteBuilder) {
+		a.ReplaceFromWith("direct:start")
+	}))
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+	assert.ErrorContains(t, camel.AdviceWith("orders", func(a *AdviceWithRouteBuilder) {}), "arrêtée")
+}
@@ -1,6 +1,10 @@
 package gocamel
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // AggregationRepository defines the interface for storing exchanges being aggregated.
 type AggregationRepository interface {
@@ -13,3 +17,63 @@ type AggregationRepository interface {
 	// Remove removes an exchange from the repository.
 	Remove(ctx context.Context, key string) error
 }
+
+// ErrOptimisticLockingFailure is returned by an OptimisticLockingAggregationRepository when the
+// aggregated exchange was changed or removed by another aggregator since it was read.
+var ErrOptimisticLockingFailure = errors.New("optimistic locking failure")
+
+// CamelAggregationVersion is the exchange property holding the version of an aggregated exchange
+// read from an OptimisticLockingAggregationRepository.
+const CamelAggregationVersion = "CamelAggregationVersion"
+
+// OptimisticLockingAggregationRepository is an AggregationRepository detecting concurrent updates
+// of the same correlation key, for aggregators sharing the repository across several nodes.
+// Get sets the CamelAggregationVersion property on the exchanges it returns.
+type OptimisticLockingAggregationRepository interface {
+	AggregationRepository
+
+	// AddIfUnchanged stores newExchange if the stored exchange still has the version of
+	// oldExchange, or if it doesn't exist when oldExchange is nil.
+	// Returns ErrOptimisticLockingFailure otherwise.
+	AddIfUnchanged(ctx context.Context, key string, oldExchange, newExchange *Exchange) error
+
+	// RemoveIfUnchanged removes the stored exchange if it still has the version of exchange.
+	// Returns ErrOptimisticLockingFailure otherwise.
+	RemoveIfUnchanged(ctx context.Context, key string, exchange *Exchange) error
+}
+
+// RecoverableAggregationRepository is an AggregationRepository keeping the completed aggregated
+// exchanges until their downstream processing is confirmed, so that an Aggregator can redeliver
+// them after a failure or a crash.
+type RecoverableAggregationRepository interface {
+	AggregationRepository
+
+	// Complete removes the group of the correlation key and stores the aggregated exchange among
+	// the completed exchanges, under its ID. With optimistic locking, the group is only removed if
+	// it still has the version held by the CamelAggregationVersion property of the exchange, and
+	// ErrOptimisticLockingFailure is returned otherwise.
+	Complete(ctx context.Context, key string, exchange *Exchange) error
+
+	// Confirm removes a completed exchange once it has been processed.
+	Confirm(ctx context.Context, exchangeID string) error
+
+	// Scan returns the IDs of the completed exchanges not confirmed yet that can be recovered
+	// with the given options.
+	Scan(ctx context.Context, options RecoveryOptions) ([]string, error)
+
+	// Recover claims a completed exchange not confirmed yet: it increments its stored number of
+	// redeliveries, returned in the CamelRedeliveryCounter header of the exchange, and restarts
+	// its lease. Returns (nil, nil) if it doesn't exist or can't be recovered with the given
+	// options, for example because another aggregator sharing the repository claimed it.
+	Recover(ctx context.Context, exchangeID string, options RecoveryOptions) (*Exchange, error)
+}
+
+// RecoveryOptions selects the completed exchanges that can be recovered.
+type RecoveryOptions struct {
+	// Lease is the time left to the aggregator processing a completed exchange, from its
+	// completion or its last redelivery, before another recovery can claim it
+	Lease time.Duration
+	// MaximumRedeliveries excludes the exchanges already redelivered this number of times
+	// (0 = no limit)
+	MaximumRedeliveries int
+}
@@ -7,3 +7,11 @@ type AggregationStrategy interface {
 	// Returns the merged exchange (often oldExchange after modification, or newExchange on first call).
 	Aggregate(oldExchange *Exchange, newExchange *Exchange) *Exchange
 }
+
+// AggregationStrategyFunc is a function type that implements AggregationStrategy
+type AggregationStrategyFunc func(oldExchange *Exchange, newExchange *Exchange) *Exchange
+
+// Aggregate implements the AggregationStrategy interface
+func (f AggregationStrategyFunc) Aggregate(oldExchange *Exchange, newExchange *Exchange) *Exchange {
+	return f(oldExchange, newExchange)
+}
@@ -2,21 +2,108 @@ package gocamel
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand/v2"
 	"sync"
+	"time"
+)
+
+// Exchange properties set by the Aggregator on the aggregated exchange
+const (
+	// CamelAggregatedCompletedBy is the completion condition that completed the aggregation:
+	// "size", "predicate", "timeout", "interval", "consumer" or "force".
+	CamelAggregatedCompletedBy = "CamelAggregatedCompletedBy"
+	// CamelAggregatedCorrelationKey is the correlation key of the aggregated exchange
+	CamelAggregatedCorrelationKey = "CamelAggregatedCorrelationKey"
 )
 
 // Aggregator is a Processor that implements the Aggregator EIP.
 // It collects and stores messages, then aggregates them until a completion condition is met.
+//
+// When the aggregation is completed by an incoming exchange (size, predicate or batch consumer),
+// that exchange becomes the aggregated exchange. Timeout, interval and stop completions are
+// emitted from a background goroutine, with a new exchange. Every aggregated exchange takes
+// the same downstream path: the processors of the block, then the steps that follow the block
+// in the parent route (added after End()). The caller of Process therefore always receives
+// ErrStopRouting, unless the downstream processing fails.
+//
+// With UseRecovery, the completed exchanges are kept in the repository until the downstream
+// processors succeed, and redelivered otherwise (at least once).
 type Aggregator struct {
 	CorrelationExpression func(*Exchange) string
 	AggregationStrategy   AggregationStrategy
 	AggregationRepository AggregationRepository
 	CompletionSize        int
+	// CompletionTimeout completes a group when no exchange was added to it for this duration
+	CompletionTimeout time.Duration
+	// CompletionInterval periodically completes all the groups
+	CompletionInterval time.Duration
+	// CompletionPredicate is evaluated on the aggregated exchange after each aggregation
+	CompletionPredicate func(*Exchange) (bool, error)
+	// CompletionFromBatchConsumer completes all the groups when the last exchange of a batch
+	// (CamelBatchComplete property) has been aggregated
+	CompletionFromBatchConsumer bool
+	// ForceCompletionOnStop completes all the groups when the CamelContext stops
+	ForceCompletionOnStop bool
+	// OptimisticLocking detects the concurrent updates of aggregators sharing the repository
+	// (on several nodes), which must implement OptimisticLockingAggregationRepository. The
+	// exchange is aggregated again with the new state of its group on conflict, so the
+	// strategy may be called several times for the same exchange.
+	OptimisticLocking bool
+	// OptimisticLockRetries is the number of retries after a conflict before failing
+	OptimisticLockRetries int
+	// UseRecovery keeps the completed exchanges in the repository, which must implement
+	// RecoverableAggregationRepository, until their downstream processing is confirmed
+	UseRecovery bool
+	// RecoveryInterval is the interval between the scans of the unconfirmed completed exchanges
+	RecoveryInterval time.Duration
+	// RecoveryLease is the time left to the processing of a completed exchange, from its
+	// completion or its last redelivery, before it is redelivered. It must exceed the downstream
+	// processing time, so that the aggregators sharing a repository on several nodes don't
+	// redeliver an exchange still being processed.
+	RecoveryLease time.Duration
+	// MaximumRedeliveries is the number of redeliveries of a completed exchange before it is
+	// sent to DeadLetterURI (0 = unlimited)
+	MaximumRedeliveries int
+	// DeadLetterURI receives the completed exchanges still failing after MaximumRedeliveries
+	DeadLetterURI string
+
+	context    *CamelContext
+	processors []Processor
+	// trailing holds the steps that follow the block in the parent route
+	trailing trailingSteps
+	// deadLetters sends the exhausted completed exchanges to DeadLetterURI
+	deadLetters *ProducerTemplate
+
+	// locks serializes the processing of the correlation keys sharing a stripe, so that
+	// different keys are mostly aggregated in parallel
+	locks [aggregatorLockStripes]sync.Mutex
+	// mu protects groups, which tracks the correlation keys being aggregated with their inactivity
+	// timer, and inflight, the IDs of the completed exchanges being processed
+	mu            sync.Mutex
+	groups        map[string]*aggregationGroup
+	inflight      map[string]struct{}
+	intervalStart sync.Once
+}
+
+// aggregatorLockStripes is the number of locks shared by the correlation keys of an Aggregator
+const aggregatorLockStripes = 64
+
+// DefaultOptimisticLockRetries is the default number of retries after an optimistic locking conflict
+const DefaultOptimisticLockRetries = 10
+
+// DefaultRecoveryInterval is the default interval between the recoveries of the completed exchanges
+const DefaultRecoveryInterval = 5 * time.Second
 
-	// mu protects concurrent access to the processing of the same correlation key
-	// For a distributed system, a distributed lock would be needed.
-	mu sync.Mutex
+// DefaultRecoveryLease is the default time left to the processing of a completed exchange before it is redelivered
+const DefaultRecoveryLease = 30 * time.Second
+
+// aggregationGroup is a correlation key being aggregated
+type aggregationGroup struct {
+	timer *time.Timer
 }
 
 // NewAggregator creates a new Aggregator processor.
@@ -29,6 +116,12 @@ func NewAggregator(
 		CorrelationExpression: correlationExpr,
 		AggregationStrategy:   strategy,
 		AggregationRepository: repo,
+		OptimisticLockRetries: DefaultOptimisticLockRetries,
+		RecoveryInterval:      DefaultRecoveryInterval,
+		RecoveryLease:         DefaultRecoveryLease,
+		processors:            make([]Processor, 0),
+		groups:                make(map[string]*aggregationGroup),
+		inflight:              make(map[string]struct{}),
 	}
 }
 
@@ -38,32 +131,171 @@ func (a *Aggregator) SetCompletionSize(size int) *Aggregator {
 	return a
 }
 
+// SetCompletionTimeout completes a group when no message was added to it for the given duration.
+func (a *Aggregator) SetCompletionTimeout(timeout time.Duration) *Aggregator {
+	a.CompletionTimeout = timeout
+	return a
+}
+
+// SetCompletionInterval completes all the groups periodically.
+func (a *Aggregator) SetCompletionInterval(interval time.Duration) *Aggregator {
+	a.CompletionInterval = interval
+	return a
+}
+
+// SetCompletionPredicate completes a group when the predicate matches the aggregated exchange.
+func (a *Aggregator) SetCompletionPredicate(predicate func(*Exchange) (bool, error)) *Aggregator {
+	a.CompletionPredicate = predicate
+	return a
+}
+
+// SetCompletionFromBatchConsumer completes all the groups at the end of each batch of a batch consumer.
+func (a *Aggregator) SetCompletionFromBatchConsumer(enabled bool) *Aggregator {
+	a.CompletionFromBatchConsumer = enabled
+	return a
+}
+
+// SetForceCompletionOnStop completes all the groups when the CamelContext stops.
+func (a *Aggregator) SetForceCompletionOnStop(enabled bool) *Aggregator {
+	a.ForceCompletionOnStop = enabled
+	return a
+}
+
+// SetOptimisticLocking enables the optimistic locking of the repository.
+func (a *Aggregator) SetOptimisticLocking(enabled bool) *Aggregator {
+	a.OptimisticLocking = enabled
+	return a
+}
+
+// SetOptimisticLockRetries sets the number of retries after an optimistic locking conflict.
+func (a *Aggregator) SetOptimisticLockRetries(retries int) *Aggregator {
+	a.OptimisticLockRetries = retries
+	return a
+}
+
+// SetUseRecovery enables the recovery of the completed exchanges.
+func (a *Aggregator) SetUseRecovery(enabled bool) *Aggregator {
+	a.UseRecovery = enabled
+	return a
+}
+
+// SetRecoveryInterval sets the interval between the recoveries of the completed exchanges.
+func (a *Aggregator) SetRecoveryInterval(interval time.Duration) *Aggregator {
+	a.RecoveryInterval = interval
+	return a
+}
+
+// SetRecoveryLease sets the time left to the processing of a completed exchange before it is redelivered.
+func (a *Aggregator) SetRecoveryLease(lease time.Duration) *Aggregator {
+	a.RecoveryLease = lease
+	return a
+}
+
+// SetMaximumRedeliveries sets the number of redeliveries of a completed exchange before it is
+// sent to the dead letter URI.
+func (a *Aggregator) SetMaximumRedeliveries(redeliveries int) *Aggregator {
+	a.MaximumRedeliveries = redeliveries
+	return a
+}
+
+// SetDeadLetterURI sets the endpoint receiving the exhausted completed exchanges.
+func (a *Aggregator) SetDeadLetterURI(uri string) *Aggregator {
+	a.DeadLetterURI = uri
+	return a
+}
+
+// AddProcessor adds a downstream processor receiving the aggregated exchanges.
+func (a *Aggregator) AddProcessor(processor Processor) {
+	a.processors = append(a.processors, processor)
+}
+
 // Process handles the arrival of a new exchange.
 func (a *Aggregator) Process(exchange *Exchange) error {
-	ctx := exchange.Context
-	if ctx == nil {
-		ctx = context.Background()
-	}
+	ctx := exchangeContext(exchange)
 
 	key := a.CorrelationExpression(exchange)
 	if key == "" {
 		return fmt.Errorf("correlation key evaluated to empty string")
 	}
+	var optimistic OptimisticLockingAggregationRepository
+	if a.OptimisticLocking {
+		var ok bool
+		if optimistic, ok = a.AggregationRepository.(OptimisticLockingAggregationRepository); !ok {
+			return fmt.Errorf("aggregation repository %T doesn't support optimistic locking", a.AggregationRepository)
+		}
+	}
+	if _, ok := a.recoverable(); a.UseRecovery && !ok {
+		return fmt.Errorf("aggregation repository %T doesn't support recovery", a.AggregationRepository)
+	}
+	if a.CompletionInterval > 0 {
+		a.intervalStart.Do(a.startInterval)
+	}
+	batchComplete := false
+	if a.CompletionFromBatchConsumer {
+		batchComplete, _ = exchange.GetPropertyAsBool(CamelBatchComplete)
+	}
 
-	// Simplification: a global lock for the aggregator to avoid race conditions
-	// when accessing the repository concurrently. In a real implementation, one could
-	// have a lock per correlation key or rely on DB transactions.
-	a.mu.Lock()
-	defer a.mu.Unlock()
+	// The exchanges of the same correlation key are aggregated one at a time in this process;
+	// with optimistic locking, a conflict with another process is retried with the new state.
+	// The lock is released during the backoff so that it doesn't hold up the other keys of
+	// the stripe.
+	lock := a.lockFor(key)
+	lock.Lock()
+	aggregatedExchange, completedBy, err := a.aggregate(ctx, key, exchange, optimistic, batchComplete)
+	for attempt := 0; errors.Is(err, ErrOptimisticLockingFailure) && attempt < a.OptimisticLockRetries; attempt++ {
+		lock.Unlock()
+		time.Sleep(optimisticLockBackoff(attempt))
+		lock.Lock()
+		aggregatedExchange, completedBy, err = a.aggregate(ctx, key, exchange, optimistic, batchComplete)
+	}
+	lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// The end of a batch also completes the groups of the other correlation keys
+	if batchComplete {
+		a.completeAll(ctx, "consumer")
+	}
+	if completedBy == "" {
+		return ErrStopRouting
+	}
+
+	// The current exchange that continues in the route becomes the aggregated exchange
+	exchange.In = aggregatedExchange.In
+	exchange.Out = aggregatedExchange.Out
+	exchange.Properties = aggregatedExchange.Properties
+	exchange.RemoveProperty(CamelAggregationVersion)
+	exchange.SetProperty(CamelAggregatedCompletedBy, completedBy)
+	exchange.SetProperty(CamelAggregatedCorrelationKey, key)
 
+	// Like the background completions, the aggregated exchange has already run the steps that
+	// follow the block: the parent route must not run them again
+	if err := a.deliver(ctx, exchange); err != nil {
+		return err
+	}
+	return ErrStopRouting
+}
+
+// aggregate merges the exchange into its group and stores the result, or removes the group when
+// a completion condition is met. The lock of the correlation key must be held.
+func (a *Aggregator) aggregate(ctx context.Context, key string, exchange *Exchange, optimistic OptimisticLockingAggregationRepository, batchComplete bool) (*Exchange, string, error) {
 	// Retrieve the old exchange
 	oldExchange, err := a.AggregationRepository.Get(ctx, key)
 	if err != nil {
-		return fmt.Errorf("failed to get exchange from repository: %w", err)
+		return nil, "", fmt.Errorf("failed to get exchange from repository: %w", err)
+	}
+	var version any
+	if oldExchange != nil {
+		version, _ = oldExchange.GetProperty(CamelAggregationVersion)
 	}
 
 	// Apply the aggregation strategy
 	aggregatedExchange := a.AggregationStrategy.Aggregate(oldExchange, exchange)
+	if oldExchange != nil && version != nil {
+		// The strategy may have modified the old exchange: its version identifies the stored state
+		oldExchange.SetProperty(CamelAggregationVersion, version)
+	}
 
 	// Manage the count for completion
 	count := 1
@@ -76,25 +308,610 @@ func (a *Aggregator) Process(exchange *Exchange) error {
 	}
 	aggregatedExchange.SetProperty("CamelAggregatorSize", count)
 
+	completedBy, err := a.completedBy(aggregatedExchange, count)
+	if err != nil {
+		return nil, "", err
+	}
+	if batchComplete && completedBy == "" {
+		completedBy = "consumer"
+	}
+
+	if completedBy == "" {
+		// Not completed: save to repository and stop routing for THIS exchange
+		if optimistic != nil {
+			err = optimistic.AddIfUnchanged(ctx, key, oldExchange, aggregatedExchange)
+		} else {
+			err = a.AggregationRepository.Add(ctx, key, aggregatedExchange)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to add exchange to repository: %w", err)
+		}
+		a.track(key)
+		return aggregatedExchange, "", nil
+	}
+
+	// Completed: remove from repository
+	if recoverable, ok := a.recoverable(); ok {
+		// The exchange that continues in the route is kept until its processing is confirmed
+		record := aggregatedExchange.Copy()
+		record.ID = exchange.ID
+		record.RemoveProperty(CamelAggregationVersion)
+		if oldExchange != nil && version != nil {
+			record.SetProperty(CamelAggregationVersion, version)
+		}
+		record.SetProperty(CamelAggregatedCompletedBy, completedBy)
+		record.SetProperty(CamelAggregatedCorrelationKey, key)
+		a.markInflight(record.ID)
+		if err = recoverable.Complete(ctx, key, record); err != nil {
+			a.unmarkInflight(record.ID)
+		}
+	} else if optimistic != nil {
+		if oldExchange != nil {
+			err = optimistic.RemoveIfUnchanged(ctx, key, oldExchange)
+		}
+	} else {
+		err = a.AggregationRepository.Remove(ctx, key)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to remove exchange from repository: %w", err)
+	}
+	a.forget(key)
+	return aggregatedExchange, completedBy, nil
+}
+
+// completedBy returns the completion condition met by the aggregated exchange, or "".
+func (a *Aggregator) completedBy(aggregatedExchange *Exchange, count int) (string, error) {
 	if a.CompletionSize > 0 && count >= a.CompletionSize {
-		// Completed: remove from repository
-		if err := a.AggregationRepository.Remove(ctx, key); err != nil {
-			return fmt.Errorf("failed to remove exchange from repository: %w", err)
+		return "size", nil
+	}
+	if a.CompletionPredicate != nil {
+		matched, err := a.CompletionPredicate(aggregatedExchange)
+		if err != nil {
+			return "", fmt.Errorf("completion predicate error: %w", err)
+		}
+		if matched {
+			return "predicate", nil
 		}
+	}
+	return "", nil
+}
+
+// lockFor returns the lock of the stripe of the correlation key.
+func (a *Aggregator) lockFor(key string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &a.locks[h.Sum32()%aggregatorLockStripes]
+}
 
-		// The current exchange that continues in the route becomes the aggregated exchange
-		exchange.In = aggregatedExchange.In
-		exchange.Out = aggregatedExchange.Out
-		exchange.Properties = aggregatedExchange.Properties
+// optimisticLockBackoff returns a random delay before retrying after a conflict, growing with
+// the attempts so that the competing aggregators don't keep colliding.
+func optimisticLockBackoff(attempt int) time.Duration {
+	maximum := time.Duration(attempt+1) * 5 * time.Millisecond
+	return rand.N(maximum) + time.Millisecond
+}
 
-		// Let the exchange continue normally in the route
-		return nil
+// track records a group being aggregated and restarts its inactivity timer.
+func (a *Aggregator) track(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.groups == nil {
+		a.groups = make(map[string]*aggregationGroup)
+	}
+	group, exists := a.groups[key]
+	if !exists {
+		group = &aggregationGroup{}
+		a.groups[key] = group
 	}
+	if a.CompletionTimeout > 0 {
+		if group.timer != nil {
+			group.timer.Stop()
+		}
+		group.timer = time.AfterFunc(a.CompletionTimeout, func() { a.timeout(key, group) })
+	}
+}
 
-	// Not completed: save to repository and stop routing for THIS exchange
-	if err := a.AggregationRepository.Add(ctx, key, aggregatedExchange); err != nil {
-		return fmt.Errorf("failed to add exchange to repository: %w", err)
+// forget removes a completed group.
+func (a *Aggregator) forget(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if group, exists := a.groups[key]; exists {
+		if group.timer != nil {
+			group.timer.Stop()
+		}
+		delete(a.groups, key)
 	}
+}
 
-	return ErrStopRouting
+// complete removes a group from the repository and returns its aggregated exchange, or nil if it
+// no longer exists. When expected is not nil, the group is only completed if it is still the
+// expected one, i.e. it has not been completed or restarted in the meantime.
+func (a *Aggregator) complete(ctx context.Context, key, completedBy string, expected *aggregationGroup) (*Exchange, error) {
+	lock := a.lockFor(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	a.mu.Lock()
+	group, exists := a.groups[key]
+	a.mu.Unlock()
+	if !exists || (expected != nil && group != expected) {
+		return nil, nil
+	}
+
+	aggregatedExchange, err := a.AggregationRepository.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange from repository: %w", err)
+	}
+	if aggregatedExchange == nil {
+		// Completed by another aggregator sharing the repository
+		a.forget(key)
+		return nil, nil
+	}
+	// The stored exchange may be one of the aggregated exchanges: the completion is a new exchange
+	completed := aggregatedExchange.Copy()
+	completed.Context = a.baseContext()
+	completed.SetProperty(CamelAggregatedCompletedBy, completedBy)
+	completed.SetProperty(CamelAggregatedCorrelationKey, key)
+
+	optimistic, isOptimistic := a.AggregationRepository.(OptimisticLockingAggregationRepository)
+	if recoverable, ok := a.recoverable(); ok {
+		// The completed exchange holds the version read, checked with optimistic locking
+		a.markInflight(completed.ID)
+		if err = recoverable.Complete(ctx, key, completed); err != nil {
+			a.unmarkInflight(completed.ID)
+		}
+	} else if isOptimistic && a.OptimisticLocking {
+		err = optimistic.RemoveIfUnchanged(ctx, key, aggregatedExchange)
+	} else {
+		err = a.AggregationRepository.Remove(ctx, key)
+	}
+	if errors.Is(err, ErrOptimisticLockingFailure) {
+		// Updated by another aggregator: the group is completed later with its new content
+		a.track(key)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove exchange from repository: %w", err)
+	}
+	a.forget(key)
+
+	completed.RemoveProperty(CamelAggregationVersion)
+	return completed, nil
+}
+
+// completeAll completes and emits all the groups being aggregated.
+func (a *Aggregator) completeAll(ctx context.Context, completedBy string) {
+	a.mu.Lock()
+	keys := make([]string, 0, len(a.groups))
+	for key := range a.groups {
+		keys = append(keys, key)
+	}
+	a.mu.Unlock()
+
+	for _, key := range keys {
+		exchange, err := a.complete(ctx, key, completedBy, nil)
+		if err != nil {
+			log.Printf("Aggregator: échec de la complétion du groupe %s: %v", key, err)
+		} else if exchange != nil {
+			a.emit(exchange)
+		}
+	}
+}
+
+// emit sends an aggregated exchange completed in the background to the downstream processors.
+func (a *Aggregator) emit(exchange *Exchange) {
+	if err := a.deliver(exchange.Context, exchange); err != nil && !errors.Is(err, ErrStopRouting) {
+		log.Printf("Aggregator: échec du traitement de l'échange agrégé %s: %v", exchange.ID, err)
+	}
+}
+
+// deliver runs the processors of the block, then the steps that follow it, with an aggregated
+// exchange. With recovery, the exchange is confirmed when they succeed, and redelivered later
+// otherwise.
+func (a *Aggregator) deliver(ctx context.Context, exchange *Exchange) error {
+	var err error
+	for _, p := range a.processors {
+		if err = p.Process(exchange); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = a.trailing.process(exchange)
+	}
+
+	if recoverable, ok := a.recoverable(); ok {
+		if err == nil || errors.Is(err, ErrStopRouting) {
+			if cerr := recoverable.Confirm(ctx, exchange.ID); cerr != nil {
+				log.Printf("Aggregator: échec de la confirmation de l'échange agrégé %s: %v", exchange.ID, cerr)
+			}
+		}
+		a.unmarkInflight(exchange.ID)
+	}
+	return err
+}
+
+// recoverable returns the repository of the completed exchanges when UseRecovery is enabled.
+func (a *Aggregator) recoverable() (RecoverableAggregationRepository, bool) {
+	if !a.UseRecovery {
+		return nil, false
+	}
+	repository, ok := a.AggregationRepository.(RecoverableAggregationRepository)
+	return repository, ok
+}
+
+// markInflight records a completed exchange being processed, so that it isn't redelivered at
+// the same time. Returns false if it is already being processed.
+func (a *Aggregator) markInflight(exchangeID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.inflight == nil {
+		a.inflight = make(map[string]struct{})
+	}
+	if _, exists := a.inflight[exchangeID]; exists {
+		return false
+	}
+	a.inflight[exchangeID] = struct{}{}
+	return true
+}
+
+// unmarkInflight records the end of the processing of a completed exchange.
+func (a *Aggregator) unmarkInflight(exchangeID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.inflight, exchangeID)
+}
+
+// recoveryOptions returns the options selecting the completed exchanges to recover. Without
+// dead letter URI, the exhausted exchanges are left out, so they are neither read nor updated.
+func (a *Aggregator) recoveryOptions() RecoveryOptions {
+	options := RecoveryOptions{Lease: a.RecoveryLease}
+	if a.DeadLetterURI == "" || a.deadLetters == nil {
+		options.MaximumRedeliveries = a.MaximumRedeliveries
+	}
+	return options
+}
+
+// Recover redelivers the completed exchanges whose processing was not confirmed within
+// RecoveryLease, after a downstream failure or a crash, and returns their number. An exchange
+// still failing after MaximumRedeliveries is sent to DeadLetterURI, or left in the repository
+// without dead letter URI; the number of redeliveries is kept by the repository, so it survives
+// a restart. Once the CamelContext is started, Recover is called every RecoveryInterval.
+func (a *Aggregator) Recover(ctx context.Context) (int, error) {
+	recoverable, ok := a.recoverable()
+	if !ok {
+		return 0, fmt.Errorf("recovery is not enabled on the aggregator")
+	}
+	options := a.recoveryOptions()
+	ids, err := recoverable.Scan(ctx, options)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan completed exchanges: %w", err)
+	}
+
+	count := 0
+	var errs []error
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		if !a.markInflight(id) {
+			continue
+		}
+		exchange, err := recoverable.Recover(ctx, id, options)
+		if err != nil || exchange == nil {
+			// Confirmed or claimed in the meantime, by this aggregator or another one sharing the repository
+			a.unmarkInflight(id)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to recover exchange %s: %w", id, err))
+			}
+			continue
+		}
+
+		attempt, _ := exchange.GetIn().GetHeaderAsInt(CamelRedeliveryCounter)
+		exchange.Context = ctx
+		exchange.GetIn().SetHeader(CamelRedelivered, true)
+		if a.MaximumRedeliveries > 0 && attempt > a.MaximumRedeliveries {
+			exchange.GetIn().SetHeader(CamelRedeliveryCounter, a.MaximumRedeliveries)
+			if a.deadLetter(ctx, recoverable, exchange) {
+				count++
+			}
+			continue
+		}
+
+		if err := a.deliver(ctx, exchange); err != nil && !errors.Is(err, ErrStopRouting) {
+			log.Printf("Aggregator: échec de la redélivrance %d de l'échange agrégé %s: %v", attempt, id, err)
+			if attempt == options.MaximumRedeliveries {
+				log.Printf("Aggregator: l'échange agrégé %s a épuisé ses %d redélivrances", id, a.MaximumRedeliveries)
+			}
+		}
+		count++
+	}
+	return count, errors.Join(errs...)
+}
+
+// deadLetter sends an exhausted completed exchange to DeadLetterURI and confirms it. Without
+// DeadLetterURI, the exhausted exchanges are not recovered (see recoveryOptions).
+func (a *Aggregator) deadLetter(ctx context.Context, recoverable RecoverableAggregationRepository, exchange *Exchange) bool {
+	defer a.unmarkInflight(exchange.ID)
+
+	err := a.deadLetters.Send(a.DeadLetterURI, exchange)
+	if err != nil && !errors.Is(err, ErrStopRouting) {
+		log.Printf("Aggregator: échec de l'envoi de l'échange agrégé %s vers %s: %v", exchange.ID, a.DeadLetterURI, err)
+		return false
+	}
+	if err := recoverable.Confirm(ctx, exchange.ID); err != nil {
+		log.Printf("Aggregator: échec de la confirmation de l'échange agrégé %s: %v", exchange.ID, err)
+		return false
+	}
+	return true
+}
+
+// timeout completes a group that stayed inactive for CompletionTimeout.
+func (a *Aggregator) timeout(key string, group *aggregationGroup) {
+	ctx := a.baseContext()
+	if ctx.Err() != nil {
+		return
+	}
+
+	exchange, err := a.complete(ctx, key, "timeout", group)
+	if err != nil {
+		log.Printf("Aggregator: échec de la complétion du groupe %s: %v", key, err)
+	} else if exchange != nil {
+		a.emit(exchange)
+	}
+}
+
+// startInterval completes all the groups every CompletionInterval until the CamelContext stops.
+func (a *Aggregator) startInterval() {
+	ctx := a.baseContext()
+	go func() {
+		ticker := time.NewTicker(a.CompletionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.completeAll(ctx, "interval")
+			}
+		}
+	}()
+}
+
+// start starts the recovery of the completed exchanges once the CamelContext is started.
+func (a *Aggregator) start() {
+	if _, ok := a.recoverable(); !ok || a.RecoveryInterval <= 0 {
+		return
+	}
+	ctx := a.baseContext()
+	go func() {
+		ticker := time.NewTicker(a.RecoveryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := a.Recover(ctx); err != nil {
+					log.Printf("Aggregator: échec de la récupération des échanges agrégés: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// stop is called when the CamelContext stops, before the pending exchanges are cancelled.
+func (a *Aggregator) stop() {
+	if a.ForceCompletionOnStop {
+		a.completeAll(a.baseContext(), "force")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, group := range a.groups {
+		if group.timer != nil {
+			group.timer.Stop()
+		}
+	}
+}
+
+// cleanup is called once the routes are stopped, when no recovery can use the dead letter
+// producers anymore.
+func (a *Aggregator) cleanup() {
+	if err := a.deadLetters.Stop(); err != nil {
+		log.Printf("Aggregator: échec de l'arrêt du producteur %s: %v", a.DeadLetterURI, err)
+	}
+}
+
+// baseContext returns the context of the exchanges completed in the background.
+func (a *Aggregator) baseContext() context.Context {
+	if a.context == nil {
+		return context.Background()
+	}
+	return a.context.GetContext()
+}
+
+// Aggregate starts an Aggregator EIP block. The aggregated exchanges run the processors of the
+// block, then the steps added after End() at the same level; the incoming exchanges stop at the
+// aggregator (ErrStopRouting), including the one completing a group. Only the steps of the
+// parent builder are continued: when the aggregator is nested (Split, Filter...), the steps
+// that follow the enclosing block are not run by the aggregated exchanges.
+//
+// Aggregate used to return the *RouteBuilder itself, the rest of the route receiving the
+// aggregated exchanges of the size and predicate completions only; it now returns an
+// *AggregateDefinition, which changes the meaning of the existing chains: written without
+// End() (as in the aggregator-files example), all the following steps now belong to the block,
+// where every completion reaches them.
+//
+// The CamelContext of the route is used for the background completions and ForceCompletionOnStop.
+func (b *RouteBuilder) Aggregate(aggregator *Aggregator) *AggregateDefinition {
+	b.container.AddProcessor(aggregator)
+	if aggregator.context == nil {
+		aggregator.trailing.capture(b)
+		aggregator.context = b.context
+		aggregator.deadLetters = NewProducerTemplate(b.context)
+		b.context.addStartHook(aggregator.start)
+		b.context.addStopHook(aggregator.stop)
+		b.context.addCleanupHook(aggregator.cleanup)
+	}
+
+	return &AggregateDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: aggregator,
+		},
+		parent:     b,
+		aggregator: aggregator,
+	}
+}
+
+// AggregateDefinition configures the Aggregator and its downstream processors
+type AggregateDefinition struct {
+	*RouteBuilder
+	parent     *RouteBuilder
+	aggregator *Aggregator
+}
+
+// CompletionSize completes a group after the given number of messages
+func (d *AggregateDefinition) CompletionSize(size int) *AggregateDefinition {
+	d.aggregator.SetCompletionSize(size)
+	return d
+}
+
+// CompletionTimeout completes a group when no message was added to it for the given duration
+func (d *AggregateDefinition) CompletionTimeout(timeout time.Duration) *AggregateDefinition {
+	d.aggregator.SetCompletionTimeout(timeout)
+	return d
+}
+
+// CompletionInterval completes all the groups periodically
+func (d *AggregateDefinition) CompletionInterval(interval time.Duration) *AggregateDefinition {
+	d.aggregator.SetCompletionInterval(interval)
+	return d
+}
+
+// CompletionPredicate completes a group when the Simple predicate (ex: "${body.length} > 100")
+// matches the aggregated exchange
+func (d *AggregateDefinition) CompletionPredicate(predicate string) *AggregateDefinition {
+	template, err := ParseSimpleTemplate(predicate)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for completion predicate: %v", err))
+	}
+	d.aggregator.SetCompletionPredicate(template.EvaluateAsBool)
+	return d
+}
+
+// CompletionPredicateFunc completes a group when the Go predicate matches the aggregated exchange
+func (d *AggregateDefinition) CompletionPredicateFunc(predicate func(*Exchange) bool) *AggregateDefinition {
+	d.aggregator.SetCompletionPredicate(func(exchange *Exchange) (bool, error) {
+		return predicate(exchange), nil
+	})
+	return d
+}
+
+// CompletionFromBatchConsumer completes all the groups at the end of each batch of a batch consumer
+func (d *AggregateDefinition) CompletionFromBatchConsumer() *AggregateDefinition {
+	d.aggregator.SetCompletionFromBatchConsumer(true)
+	return d
+}
+
+// ForceCompletionOnStop completes all the groups when the CamelContext stops
+func (d *AggregateDefinition) ForceCompletionOnStop() *AggregateDefinition {
+	d.aggregator.SetForceCompletionOnStop(true)
+	return d
+}
+
+// OptimisticLocking enables the optimistic locking of the repository, for aggregators sharing it on several nodes
+func (d *AggregateDefinition) OptimisticLocking() *AggregateDefinition {
+	d.aggregator.SetOptimisticLocking(true)
+	return d
+}
+
+// OptimisticLockRetries sets the number of retries after an optimistic locking conflict
+func (d *AggregateDefinition) OptimisticLockRetries(retries int) *AggregateDefinition {
+	d.aggregator.SetOptimisticLockRetries(retries)
+	return d
+}
+
+// UseRecovery keeps the completed exchanges in the repository until their downstream processing
+// succeeds, and redelivers them otherwise
+func (d *AggregateDefinition) UseRecovery() *AggregateDefinition {
+	d.aggregator.SetUseRecovery(true)
+	return d
+}
+
+// RecoveryInterval sets the interval between the recoveries of the completed exchanges
+func (d *AggregateDefinition) RecoveryInterval(interval time.Duration) *AggregateDefinition {
+	d.aggregator.SetRecoveryInterval(interval)
+	return d
+}
+
+// RecoveryLease sets the time left to the processing of a completed exchange before it is redelivered
+func (d *AggregateDefinition) RecoveryLease(lease time.Duration) *AggregateDefinition {
+	d.aggregator.SetRecoveryLease(lease)
+	return d
+}
+
+// MaximumRedeliveries sets the number of redeliveries of a completed exchange before it is sent to the dead letter URI
+func (d *AggregateDefinition) MaximumRedeliveries(redeliveries int) *AggregateDefinition {
+	d.aggregator.SetMaximumRedeliveries(redeliveries)
+	return d
+}
+
+// DeadLetterURI sets the endpoint receiving the completed exchanges exhausted after MaximumRedeliveries
+func (d *AggregateDefinition) DeadLetterURI(uri string) *AggregateDefinition {
+	d.aggregator.SetDeadLetterURI(uri)
+	return d
+}
+
+// Process adds a processor and stays in the aggregator context
+func (d *AggregateDefinition) Process(processor Processor) *AggregateDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc adds a processing function and stays in the aggregator context
+func (d *AggregateDefinition) ProcessFunc(f func(*Exchange) error) *AggregateDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To adds one or more destination endpoints and stays in the aggregator context
+func (d *AggregateDefinition) To(uris ...string) *AggregateDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD adds one or more dynamic endpoints and stays in the aggregator context
+func (d *AggregateDefinition) ToD(uriTemplates ...string) *AggregateDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody sets the body of the output message and stays in the aggregator context
+func (d *AggregateDefinition) SetBody(body interface{}) *AggregateDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader sets a header of the output message and stays in the aggregator context
+func (d *AggregateDefinition) SetHeader(key string, value interface{}) *AggregateDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetProperty sets an exchange property and stays in the aggregator context
+func (d *AggregateDefinition) SetProperty(key string, value any) *AggregateDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// Log adds a log and stays in the aggregator context
+func (d *AggregateDefinition) Log(message string) *AggregateDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End ends the Aggregate block and returns to the parent builder
+func (d *AggregateDefinition) End() *RouteBuilder {
+	return d.parent
 }
@@ -2,10 +2,18 @@ package gocamel
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // StringConcatStrategy est une stratégie d'agrégation simple qui concatène les corps de messages.
@@ -77,8 +85,9 @@ func TestAggregator_CompletionSize(t *testing.T) {
 	ex3.GetIn().SetHeader("group", "A")
 	ex3.GetIn().SetBody("msg3")
 
+	// L'échange agrégé a déjà parcouru les étapes en aval : l'appelant ne poursuit pas la route
 	err = aggregator.Process(ex3)
-	assert.NoError(t, err, "Third message should complete aggregation and continue routing")
+	assert.ErrorIs(t, err, ErrStopRouting, "Third message should complete aggregation")
 
 	// Verify that ex3 now contains the aggregated result
 	assert.Equal(t, "msg1,msg2,msg3", ex3.GetIn().GetBody())
@@ -88,3 +97,554 @@ func TestAggregator_CompletionSize(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, savedEx, "Repository should be cleared after completion")
 }
+
+// newGroupAggregator crée un agrégateur concaténant les corps par en-tête "group"
+func newGroupAggregator() *Aggregator {
+	return NewAggregator(func(exchange *Exchange) string {
+		group, _ := exchange.GetHeader("group")
+		return fmt.Sprint(group)
+	}, &StringConcatStrategy{}, NewMemoryAggregationRepository())
+}
+
+// completedBy vérifie la condition de complétion d'un échange agrégé
+func completedBy(expected string) func(*Exchange) bool {
+	return func(e *Exchange) bool {
+		value, _ := e.GetPropertyAsString(CamelAggregatedCompletedBy)
+		return value == expected
+	}
+}
+
+func TestAggregator_CompletionSizeAndPredicate(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(newGroupAggregator()).
+		CompletionSize(3).
+		CompletionPredicate("${body endsWith 'END'}").
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ExpectedBodiesReceived("a1,END", "b1,b2,b3")
+	out.MessageN(0).Predicate(completedBy("predicate"))
+	out.MessageN(1).Predicate(completedBy("size"))
+	out.MessageN(1).Predicate(func(e *Exchange) bool {
+		key, _ := e.GetPropertyAsString(CamelAggregatedCorrelationKey)
+		return key == "B"
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	for _, message := range [][2]string{{"A", "a1"}, {"B", "b1"}, {"B", "b2"}, {"A", "END"}, {"B", "b3"}} {
+		err := template.SendBodyAndHeaders("direct:start", message[1], map[string]any{"group": message[0]})
+		if err != nil {
+			assert.ErrorIs(t, err, ErrStopRouting)
+		}
+	}
+	out.AssertIsSatisfied(t, time.Second)
+}
+
+func TestAggregator_CompletionTimeout(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(newGroupAggregator()).
+		CompletionTimeout(150 * time.Millisecond).
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ExpectedBodiesReceivedInAnyOrder("a1,a2", "b1")
+	out.MessageN(0).Predicate(completedBy("timeout"))
+	out.MessageN(1).Predicate(completedBy("timeout"))
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// Chaque message reçu par un groupe repousse sa complétion
+	template := camel.CreateProducerTemplate()
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", "a1", map[string]any{"group": "A"}), ErrStopRouting)
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", "b1", map[string]any{"group": "B"}), ErrStopRouting)
+	time.Sleep(80 * time.Millisecond)
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", "a2", map[string]any{"group": "A"}), ErrStopRouting)
+
+	out.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, "b1", out.ReceivedBodies()[0])
+}
+
+func TestAggregator_CompletionInterval(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(newGroupAggregator()).
+		CompletionInterval(100 * time.Millisecond).
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ExpectedBodiesReceivedInAnyOrder("a1,a2", "b1")
+	out.MessageN(0).Predicate(completedBy("interval"))
+	out.MessageN(1).Predicate(completedBy("interval"))
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	for _, message := range [][2]string{{"A", "a1"}, {"B", "b1"}, {"A", "a2"}} {
+		assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", message[1], map[string]any{"group": message[0]}), ErrStopRouting)
+	}
+	out.AssertIsSatisfied(t, time.Second)
+}
+
+func TestAggregator_AllCompletionsContinueAfterEnd(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(newGroupAggregator()).
+		CompletionSize(2).
+		CompletionTimeout(100 * time.Millisecond).
+		To("mock:block").
+		End().
+		To("mock:after").
+		Build()
+
+	block, _ := camel.GetMockEndpoint("mock:block")
+	block.ExpectedBodiesReceivedInAnyOrder("a1,a2", "b1")
+	after, _ := camel.GetMockEndpoint("mock:after")
+	after.ExpectedBodiesReceivedInAnyOrder("a1,a2", "b1")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// Complétion par taille pour A, par délai d'inactivité pour B : les deux échanges agrégés
+	// suivent le même chemin, et l'appelant ne poursuit jamais la route lui-même
+	template := camel.CreateProducerTemplate()
+	for _, message := range [][2]string{{"A", "a1"}, {"B", "b1"}, {"A", "a2"}} {
+		assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", message[1], map[string]any{"group": message[0]}), ErrStopRouting)
+	}
+	block.AssertIsSatisfied(t, time.Second)
+	after.AssertIsSatisfied(t, time.Second)
+}
+
+func TestAggregator_CompletionFromBatchConsumer(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(newGroupAggregator()).
+		CompletionFromBatchConsumer().
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ExpectedBodiesReceivedInAnyOrder("a1,a2", "b1")
+	out.MessageN(0).Predicate(completedBy("consumer"))
+	out.MessageN(1).Predicate(completedBy("consumer"))
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// Lot de trois échanges, tel que produit par un consommateur FTP, SFTP ou SMB
+	template := camel.CreateProducerTemplate()
+	messages := [][2]string{{"A", "a1"}, {"B", "b1"}, {"A", "a2"}}
+	for i, message := range messages {
+		exchange := NewExchange(context.Background())
+		exchange.GetIn().SetBody(message[1])
+		exchange.GetIn().SetHeader("group", message[0])
+		setBatchProperties(exchange, i, len(messages), false)
+		assert.ErrorIs(t, template.Send("direct:start", exchange), ErrStopRouting)
+	}
+	out.AssertIsSatisfied(t, time.Second)
+}
+
+func TestAggregator_ForceCompletionOnStop(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(newGroupAggregator()).
+		CompletionSize(10).
+		ForceCompletionOnStop().
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ExpectedBodiesReceivedInAnyOrder("a1,a2", "b1")
+	out.MessageN(0).Predicate(completedBy("force"))
+	out.MessageN(1).Predicate(completedBy("force"))
+
+	require.NoError(t, camel.Start())
+	template := camel.CreateProducerTemplate()
+	for _, message := range [][2]string{{"A", "a1"}, {"B", "b1"}, {"A", "a2"}} {
+		assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", message[1], map[string]any{"group": message[0]}), ErrStopRouting)
+	}
+	assert.Equal(t, 0, out.ReceivedCounter())
+
+	require.NoError(t, camel.Stop())
+	out.AssertIsSatisfied(t, time.Second)
+}
+
+// aggregateConcurrently envoie les parties "p0".."pN" du groupe A en parallèle, réparties sur les
+// agrégateurs, et retourne les échanges complétés
+func aggregateConcurrently(t *testing.T, aggregators []*Aggregator, parts int) []*Exchange {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		completed []*Exchange
+	)
+	// Les échanges agrégés sont relevés par le bloc de chaque agrégateur
+	for _, aggregator := range aggregators {
+		aggregator.AddProcessor(ProcessorFunc(func(exchange *Exchange) error {
+			mu.Lock()
+			completed = append(completed, exchange)
+			mu.Unlock()
+			return nil
+		}))
+	}
+	for i := 0; i < parts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			exchange := NewExchange(context.Background())
+			exchange.GetIn().SetHeader("group", "A")
+			exchange.GetIn().SetBody(fmt.Sprintf("p%d", i))
+			assert.ErrorIs(t, aggregators[i%len(aggregators)].Process(exchange), ErrStopRouting)
+		}(i)
+	}
+	wg.Wait()
+	return completed
+}
+
+// assertAllParts vérifie qu'aucune partie n'a été perdue ni dupliquée
+func assertAllParts(t *testing.T, exchange *Exchange, parts int) {
+	body, _ := exchange.GetIn().GetBody().(string)
+	received := strings.Split(body, ",")
+	sort.Strings(received)
+	expected := make([]string, parts)
+	for i := range expected {
+		expected[i] = fmt.Sprintf("p%d", i)
+	}
+	sort.Strings(expected)
+	assert.Equal(t, expected, received)
+}
+
+func TestAggregator_ConcurrentSameKey(t *testing.T) {
+	const parts = 50
+	aggregator := newGroupAggregator().SetCompletionSize(parts)
+
+	completed := aggregateConcurrently(t, []*Aggregator{aggregator}, parts)
+	require.Len(t, completed, 1)
+	assertAllParts(t, completed[0], parts)
+}
+
+func TestAggregator_OptimisticLocking(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// Deux agrégateurs simulent deux nœuds partageant la même base
+	const parts = 40
+	aggregators := make([]*Aggregator, 2)
+	for i := range aggregators {
+		repo := NewSQLAggregationRepository(db, SQLAggregationOptions{TableName: "test_cluster", OptimisticLocking: true})
+		require.NoError(t, repo.InitDB(context.Background()))
+		aggregators[i] = NewAggregator(func(exchange *Exchange) string {
+			group, _ := exchange.GetHeader("group")
+			return fmt.Sprint(group)
+		}, &StringConcatStrategy{}, repo).
+			SetCompletionSize(parts).
+			SetOptimisticLocking(true).
+			SetOptimisticLockRetries(100)
+	}
+
+	completed := aggregateConcurrently(t, aggregators, parts)
+	require.Len(t, completed, 1)
+	assertAllParts(t, completed[0], parts)
+	assert.False(t, completed[0].HasProperty(CamelAggregationVersion))
+}
+
+func TestAggregator_OptimisticLockingUnsupported(t *testing.T) {
+	aggregator := newGroupAggregator().SetOptimisticLocking(true)
+
+	exchange := NewExchange(context.Background())
+	exchange.GetIn().SetHeader("group", "A")
+	assert.ErrorContains(t, aggregator.Process(exchange), "doesn't support optimistic locking")
+}
+
+func TestAggregator_Recovery(t *testing.T) {
+	camel := newTestContext()
+	repo := NewMemoryAggregationRepository()
+	aggregator := NewAggregator(func(exchange *Exchange) string {
+		group, _ := exchange.GetHeader("group")
+		return fmt.Sprint(group)
+	}, &StringConcatStrategy{}, repo)
+
+	// Le traitement en aval échoue deux fois avant de réussir
+	var mu sync.Mutex
+	attempts := 0
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(aggregator).
+		CompletionSize(2).
+		UseRecovery().
+		RecoveryInterval(50 * time.Millisecond).
+		RecoveryLease(20 * time.Millisecond).
+		ProcessFunc(func(e *Exchange) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			if attempts <= 2 {
+				return errors.New("invoice service unavailable")
+			}
+			return nil
+		}).
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ExpectedBodiesReceived("a1,a2")
+	out.ExpectedHeaderReceived(CamelRedelivered, true)
+	out.ExpectedHeaderReceived(CamelRedeliveryCounter, 2)
+	out.MessageN(0).Predicate(completedBy("size"))
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", "a1", map[string]any{"group": "A"}), ErrStopRouting)
+	assert.EqualError(t, template.SendBodyAndHeaders("direct:start", "a2", map[string]any{"group": "A"}), "invoice service unavailable")
+
+	out.AssertIsSatisfied(t, time.Second)
+	ids, err := repo.Scan(context.Background(), RecoveryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, ids, "completed exchange should be confirmed")
+}
+
+func TestAggregator_RecoveryDeadLetter(t *testing.T) {
+	camel := newTestContext()
+	repo := NewMemoryAggregationRepository()
+	aggregator := NewAggregator(func(exchange *Exchange) string {
+		group, _ := exchange.GetHeader("group")
+		return fmt.Sprint(group)
+	}, &StringConcatStrategy{}, repo)
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(aggregator).
+		CompletionSize(2).
+		UseRecovery().
+		RecoveryInterval(time.Hour).
+		RecoveryLease(0).
+		MaximumRedeliveries(2).
+		DeadLetterURI("mock:dead").
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ReturnError(errors.New("invoice service unavailable"))
+	dead, _ := camel.GetMockEndpoint("mock:dead")
+	dead.ExpectedBodiesReceived("a1,a2")
+	dead.ExpectedHeaderReceived(CamelRedeliveryCounter, 2)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", "a1", map[string]any{"group": "A"}), ErrStopRouting)
+	assert.Error(t, template.SendBodyAndHeaders("direct:start", "a2", map[string]any{"group": "A"}))
+
+	// Deux redélivrances échouent, puis l'échange part vers le dead letter
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		count, err := aggregator.Recover(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	}
+	dead.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, 3, out.ReceivedCounter())
+
+	count, err := aggregator.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestAggregator_RecoveryExhaustedWithoutDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryAggregationRepository()
+	aggregator := NewAggregator(func(*Exchange) string { return "A" }, &StringConcatStrategy{}, repo).
+		SetCompletionSize(1).
+		SetUseRecovery(true).
+		SetRecoveryLease(0).
+		SetMaximumRedeliveries(1)
+	aggregator.AddProcessor(ProcessorFunc(func(*Exchange) error {
+		return errors.New("invoice service unavailable")
+	}))
+
+	exchange := NewExchange(ctx)
+	exchange.GetIn().SetBody("a1")
+	assert.Error(t, aggregator.Process(exchange))
+
+	count, err := aggregator.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// L'échange épuisé n'est plus relu ni mis à jour : son compteur reste à une redélivrance
+	for i := 0; i < 2; i++ {
+		count, err = aggregator.Recover(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	}
+	recovered, err := repo.Recover(ctx, exchange.ID, RecoveryOptions{})
+	require.NoError(t, err)
+	counter, _ := recovered.GetHeaderAsInt(CamelRedeliveryCounter)
+	assert.Equal(t, 2, counter)
+}
+
+func TestAggregator_RecoveryLeaseSharedRepository(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	repo := NewSQLAggregationRepository(db, SQLAggregationOptions{})
+	require.NoError(t, repo.InitDB(ctx))
+	completed := NewExchange(ctx)
+	completed.GetIn().SetBody("a1,a2")
+	require.NoError(t, repo.Complete(ctx, "A", completed))
+
+	// Deux agrégateurs simulent deux nœuds partageant la même base
+	var delivered atomic.Int32
+	nodes := make([]*Aggregator, 2)
+	for i := range nodes {
+		nodes[i] = NewAggregator(func(*Exchange) string { return "A" }, &StringConcatStrategy{}, repo).
+			SetUseRecovery(true).
+			SetRecoveryLease(100 * time.Millisecond)
+		nodes[i].AddProcessor(ProcessorFunc(func(*Exchange) error {
+			delivered.Add(1)
+			return errors.New("invoice service unavailable")
+		}))
+	}
+
+	// L'échange vient d'être complété : il est laissé au traitement en cours pendant le bail
+	count, err := nodes[0].Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	time.Sleep(150 * time.Millisecond)
+	count, err = nodes[0].Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	// L'autre nœud ne le redélivre pas tant que le bail de la redélivrance n'a pas expiré
+	count, err = nodes[1].Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, int32(1), delivered.Load())
+
+	time.Sleep(150 * time.Millisecond)
+	count, err = nodes[1].Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int32(2), delivered.Load())
+}
+
+func TestAggregator_RecoveryAfterRestart(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	// Première instance : l'agrégation est complétée et redélivrée une fois, mais l'application
+	// s'arrête avant sa confirmation
+	first := NewSQLAggregationRepository(db, SQLAggregationOptions{})
+	require.NoError(t, first.InitDB(ctx))
+	completed := NewExchange(ctx)
+	completed.GetIn().SetBody("a1,a2")
+	require.NoError(t, first.Complete(ctx, "A", completed))
+	_, err = first.Recover(ctx, completed.ID, RecoveryOptions{})
+	require.NoError(t, err)
+
+	// Seconde instance : l'échange complété est redélivré au démarrage, le compteur de
+	// redélivrances reprenant là où il s'était arrêté
+	camel := newTestContext()
+	repo := NewSQLAggregationRepository(db, SQLAggregationOptions{})
+	require.NoError(t, repo.InitDB(ctx))
+	aggregator := NewAggregator(func(exchange *Exchange) string {
+		group, _ := exchange.GetHeader("group")
+		return fmt.Sprint(group)
+	}, &StringConcatStrategy{}, repo)
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(aggregator).
+		CompletionSize(2).
+		UseRecovery().
+		RecoveryInterval(50 * time.Millisecond).
+		RecoveryLease(0).
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ExpectedBodiesReceived("a1,a2")
+	out.ExpectedHeaderReceived(CamelRedeliveryCounter, 2)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	out.AssertIsSatisfied(t, time.Second)
+	assert.Eventually(t, func() bool {
+		ids, err := repo.Scan(ctx, RecoveryOptions{})
+		return err == nil && len(ids) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAggregator_DeadLetterProducerStoppedWithContext(t *testing.T) {
+	camel := newTestContext()
+	tracking := &trackingComponent{}
+	camel.AddComponent("tracking", tracking)
+	aggregator := NewAggregator(func(*Exchange) string { return "A" }, &StringConcatStrategy{}, NewMemoryAggregationRepository())
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Aggregate(aggregator).
+		CompletionSize(1).
+		UseRecovery().
+		RecoveryInterval(time.Hour).
+		RecoveryLease(0).
+		MaximumRedeliveries(1).
+		DeadLetterURI("tracking:dead").
+		To("mock:out").
+		End().
+		Build()
+
+	out, _ := camel.GetMockEndpoint("mock:out")
+	out.ReturnError(errors.New("invoice service unavailable"))
+	require.NoError(t, camel.Start())
+
+	template := camel.CreateProducerTemplate()
+	assert.Error(t, template.SendBody("direct:start", "a1"))
+	for i := 0; i < 2; i++ {
+		_, err := aggregator.Recover(context.Background())
+		require.NoError(t, err)
+	}
+	// Le producteur du dead letter est partagé par les redélivrances épuisées, puis arrêté avec le contexte
+	assert.Equal(t, int32(1), tracking.started.Load())
+
+	require.NoError(t, camel.Stop())
+	assert.Equal(t, int32(0), tracking.started.Load())
+}
+
+func TestAggregator_RecoveryUnsupported(t *testing.T) {
+	aggregator := NewAggregator(func(*Exchange) string { return "A" }, &StringConcatStrategy{}, &struct{ AggregationRepository }{NewMemoryAggregationRepository()}).
+		SetUseRecovery(true)
+
+	assert.ErrorContains(t, aggregator.Process(NewExchange(context.Background())), "doesn't support recovery")
+}
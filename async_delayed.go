@@ -0,0 +1,74 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// stopSignal réveille les attentes d'un Delayer ou d'un Throttler dès l'arrêt du CamelContext,
+// avant l'arrêt des routes dont les consommateurs attendent la fin de leurs échanges en cours
+type stopSignal struct {
+	once    sync.Once
+	stopped chan struct{}
+}
+
+// newStopSignal crée un signal déclenché par un hook d'arrêt du contexte, s'il y en a un
+func newStopSignal(context *CamelContext) *stopSignal {
+	s := &stopSignal{stopped: make(chan struct{})}
+	if context != nil {
+		context.addStopHook(s.stop)
+	}
+	return s
+}
+
+func (s *stopSignal) stop() {
+	s.once.Do(func() { close(s.stopped) })
+}
+
+// wait attend la durée donnée, l'annulation de ctx ou l'arrêt du CamelContext ; ce dernier
+// est signalé par context.Canceled
+func (s *stopSignal) wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.stopped:
+		return context.Canceled
+	case <-timer.C:
+		return nil
+	}
+}
+
+// asyncDelayed réinjecte en arrière-plan les échanges retardés par un bloc Delay ou Throttle en
+// mode asynchrone. L'appelant reçoit ErrStopRouting et n'exécute donc pas les étapes qui suivent
+// le bloc : à l'échéance, l'échange retardé exécute le bloc puis ces étapes, capturées dans le
+// conteneur parent lors de la construction de la route.
+type asyncDelayed struct {
+	trailingSteps
+	// name identifie l'EIP dans les logs
+	name string
+}
+
+// processLater transfère l'échange, avec ses synchronisations, à une goroutine qui attend avec
+// wait, puis exécute block et les étapes qui suivent le bloc. Les synchronisations ne sont
+// déclenchées qu'à la fin du traitement.
+func (a *asyncDelayed) processLater(ctx context.Context, exchange *Exchange, wait func(*Exchange) error, block func(*Exchange) error) {
+	delayed := exchange.handover(ctx)
+	go func() {
+		err := wait(delayed)
+		if err == nil {
+			err = block(delayed)
+		}
+		if err == nil {
+			err = a.process(delayed)
+		}
+		if err != nil && !errors.Is(err, ErrStopRouting) {
+			log.Printf("%s: échec du traitement différé de l'échange %s: %v", a.name, delayed.ID, err)
+		}
+		delayed.Done(err)
+	}()
+}
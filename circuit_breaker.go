@@ -0,0 +1,496 @@
+package gocamel
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Propriétés positionnées par le Circuit Breaker sur l'échange
+const (
+	CamelCircuitBreakerState         = "CamelCircuitBreakerState"         // État du circuit lors du passage de l'échange
+	CamelResponseSuccessfulExecution = "CamelResponseSuccessfulExecution" // true si le bloc protégé a réussi
+	CamelResponseFromFallback        = "CamelResponseFromFallback"        // true si la réponse provient du fallback
+	CamelResponseShortCircuited      = "CamelResponseShortCircuited"      // true si le circuit ouvert a court-circuité le bloc
+)
+
+// CircuitBreakerState est l'état d'un circuit breaker
+type CircuitBreakerState string
+
+const (
+	// CircuitClosed laisse passer les échanges et mesure leurs résultats
+	CircuitClosed CircuitBreakerState = "CLOSED"
+	// CircuitOpen court-circuite les échanges jusqu'à la fin du délai d'attente
+	CircuitOpen CircuitBreakerState = "OPEN"
+	// CircuitHalfOpen laisse passer quelques échanges d'essai pour décider de la réouverture
+	CircuitHalfOpen CircuitBreakerState = "HALF_OPEN"
+)
+
+// ErrCircuitBreakerOpen est retournée lorsque le circuit est ouvert et qu'aucun fallback n'est défini
+var ErrCircuitBreakerOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig contient les seuils d'un circuit breaker
+type CircuitBreakerConfig struct {
+	// SlidingWindowSize est le nombre de derniers appels pris en compte pour calculer les taux
+	SlidingWindowSize int
+	// MinimumNumberOfCalls est le nombre d'appels nécessaires avant de calculer les taux
+	MinimumNumberOfCalls int
+	// FailureRateThreshold est le pourcentage d'échecs à partir duquel le circuit s'ouvre (0 désactive)
+	FailureRateThreshold float64
+	// SlowCallRateThreshold est le pourcentage d'appels lents à partir duquel le circuit s'ouvre (0 désactive)
+	SlowCallRateThreshold float64
+	// SlowCallDurationThreshold est la durée à partir de laquelle un appel est lent (0 désactive)
+	SlowCallDurationThreshold time.Duration
+	// WaitDurationInOpenState est la durée pendant laquelle le circuit reste ouvert
+	WaitDurationInOpenState time.Duration
+	// PermittedNumberOfCallsInHalfOpenState est le nombre d'appels d'essai en état semi-ouvert
+	PermittedNumberOfCallsInHalfOpenState int
+}
+
+// DefaultCircuitBreakerConfig retourne la configuration par défaut : ouverture à 50 % d'échecs
+// ou 100 % d'appels lents (plus de 60s) sur les 100 derniers appels, 60s d'attente, 10 essais
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		SlidingWindowSize:                     100,
+		MinimumNumberOfCalls:                  10,
+		FailureRateThreshold:                  50,
+		SlowCallRateThreshold:                 100,
+		SlowCallDurationThreshold:             60 * time.Second,
+		WaitDurationInOpenState:               60 * time.Second,
+		PermittedNumberOfCallsInHalfOpenState: 10,
+	}
+}
+
+// CircuitBreakerStats contient l'état et les compteurs d'un circuit breaker
+type CircuitBreakerStats struct {
+	State CircuitBreakerState `json:"state"`
+	// FailureRate et SlowCallRate sont les taux (en pourcentage) de la fenêtre courante,
+	// ou -1 tant que le nombre minimal d'appels n'est pas atteint
+	FailureRate  float64 `json:"failureRate"`
+	SlowCallRate float64 `json:"slowCallRate"`
+	// BufferedCalls, FailedCalls et SlowCalls décrivent la fenêtre courante
+	BufferedCalls int `json:"bufferedCalls"`
+	FailedCalls   int `json:"failedCalls"`
+	SlowCalls     int `json:"slowCalls"`
+	// NotPermittedCalls est le nombre total d'échanges court-circuités
+	NotPermittedCalls int64 `json:"notPermittedCalls"`
+}
+
+// callOutcome est le résultat d'un appel dans la fenêtre glissante
+type callOutcome struct {
+	failed bool
+	slow   bool
+}
+
+// CircuitBreaker implémente le Circuit Breaker EIP autour d'un bloc de processeurs.
+// En état fermé, les résultats des appels sont mesurés sur une fenêtre glissante ; lorsque le taux
+// d'échecs ou d'appels lents dépasse son seuil, le circuit s'ouvre et les échanges sont
+// court-circuités (vers le fallback s'il est défini). Après le délai d'attente, le circuit passe
+// en semi-ouvert et laisse passer quelques appels d'essai qui décident de sa fermeture.
+type CircuitBreaker struct {
+	CircuitBreakerConfig
+	processors []Processor
+	fallback   []Processor
+
+	mu              sync.Mutex
+	state           CircuitBreakerState
+	openedAt        time.Time
+	window          []callOutcome
+	next            int
+	halfOpenPermits int
+	halfOpenResults []callOutcome
+	notPermitted    int64
+}
+
+// NewCircuitBreaker crée un circuit breaker fermé avec la configuration par défaut
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		CircuitBreakerConfig: DefaultCircuitBreakerConfig(),
+		processors:           make([]Processor, 0),
+		fallback:             make([]Processor, 0),
+		state:                CircuitClosed,
+	}
+}
+
+// AddProcessor ajoute un processeur au bloc protégé
+func (c *CircuitBreaker) AddProcessor(processor Processor) {
+	c.processors = append(c.processors, processor)
+}
+
+// AddFallbackProcessor ajoute un processeur au fallback, exécuté lorsque le circuit est ouvert
+// ou que le bloc protégé échoue
+func (c *CircuitBreaker) AddFallbackProcessor(processor Processor) {
+	c.fallback = append(c.fallback, processor)
+}
+
+// State retourne l'état courant du circuit
+func (c *CircuitBreaker) State() CircuitBreakerState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkOpenTimeout(time.Now())
+	return c.state
+}
+
+// Stats retourne l'état et les compteurs du circuit
+func (c *CircuitBreaker) Stats() CircuitBreakerStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkOpenTimeout(time.Now())
+
+	outcomes := c.window
+	if c.state == CircuitHalfOpen {
+		outcomes = c.halfOpenResults
+	}
+	failed, slow := countOutcomes(outcomes)
+	stats := CircuitBreakerStats{
+		State:             c.state,
+		FailureRate:       -1,
+		SlowCallRate:      -1,
+		BufferedCalls:     len(outcomes),
+		FailedCalls:       failed,
+		SlowCalls:         slow,
+		NotPermittedCalls: c.notPermitted,
+	}
+	if len(outcomes) > 0 && len(outcomes) >= c.minimumCalls() {
+		stats.FailureRate = rate(failed, len(outcomes))
+		stats.SlowCallRate = rate(slow, len(outcomes))
+	}
+	return stats
+}
+
+// Reset referme le circuit et vide la fenêtre glissante
+func (c *CircuitBreaker) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transition(CircuitClosed, time.Now())
+}
+
+// Process implémente l'interface Processor
+func (c *CircuitBreaker) Process(exchange *Exchange) error {
+	state, permitted := c.acquirePermission()
+	exchange.SetProperty(CamelCircuitBreakerState, string(state))
+	if !permitted {
+		exchange.SetProperty(CamelResponseSuccessfulExecution, false)
+		exchange.SetProperty(CamelResponseShortCircuited, true)
+		return c.processFallback(exchange, ErrCircuitBreakerOpen)
+	}
+
+	started := time.Now()
+	err := c.processBlock(c.processors, exchange)
+	failed := err != nil && !errors.Is(err, ErrStopRouting)
+	slow := c.SlowCallDurationThreshold > 0 && time.Since(started) >= c.SlowCallDurationThreshold
+	c.record(state, callOutcome{failed: failed, slow: slow})
+
+	exchange.SetProperty(CamelResponseSuccessfulExecution, !failed)
+	exchange.SetProperty(CamelResponseShortCircuited, false)
+	if failed {
+		return c.processFallback(exchange, err)
+	}
+	return err
+}
+
+func (c *CircuitBreaker) processBlock(processors []Processor, exchange *Exchange) error {
+	for _, p := range processors {
+		if err := p.Process(exchange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processFallback exécute le fallback s'il est défini, sinon retourne l'erreur d'origine
+func (c *CircuitBreaker) processFallback(exchange *Exchange, cause error) error {
+	if len(c.fallback) == 0 {
+		exchange.SetProperty(CamelResponseFromFallback, false)
+		return cause
+	}
+	exchange.SetProperty(CamelResponseFromFallback, true)
+	exchange.Error = cause
+	return c.processBlock(c.fallback, exchange)
+}
+
+// acquirePermission indique si l'échange peut traverser le bloc protégé
+func (c *CircuitBreaker) acquirePermission() (CircuitBreakerState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkOpenTimeout(time.Now())
+	switch c.state {
+	case CircuitOpen:
+		c.notPermitted++
+		return c.state, false
+	case CircuitHalfOpen:
+		if c.halfOpenPermits >= c.halfOpenCalls() {
+			c.notPermitted++
+			return c.state, false
+		}
+		c.halfOpenPermits++
+	}
+	return c.state, true
+}
+
+// record enregistre le résultat d'un appel autorisé dans l'état donné
+func (c *CircuitBreaker) record(state CircuitBreakerState, outcome callOutcome) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// le circuit a changé d'état pendant l'appel : le résultat n'est plus pertinent
+	if state != c.state {
+		return
+	}
+
+	switch c.state {
+	case CircuitClosed:
+		size := c.SlidingWindowSize
+		if size < 1 {
+			size = 1
+		}
+		if len(c.window) < size {
+			c.window = append(c.window, outcome)
+		} else {
+			c.window[c.next] = outcome
+		}
+		c.next = (c.next + 1) % size
+		if len(c.window) >= c.minimumCalls() && c.exceedsThresholds(c.window) {
+			c.transition(CircuitOpen, now)
+		}
+	case CircuitHalfOpen:
+		c.halfOpenResults = append(c.halfOpenResults, outcome)
+		if c.exceedsThresholds(c.halfOpenResults) {
+			c.transition(CircuitOpen, now)
+		} else if len(c.halfOpenResults) >= c.halfOpenCalls() {
+			c.transition(CircuitClosed, now)
+		}
+	}
+}
+
+// exceedsThresholds indique si le taux d'échecs ou d'appels lents atteint son seuil.
+// En état semi-ouvert, le circuit se rouvre dès que le seuil est atteint avec tous les essais,
+// même s'ils ne sont pas tous terminés.
+func (c *CircuitBreaker) exceedsThresholds(outcomes []callOutcome) bool {
+	total := len(outcomes)
+	if c.state == CircuitHalfOpen {
+		total = c.halfOpenCalls()
+	}
+	failed, slow := countOutcomes(outcomes)
+	return (c.FailureRateThreshold > 0 && rate(failed, total) >= c.FailureRateThreshold) ||
+		(c.SlowCallRateThreshold > 0 && rate(slow, total) >= c.SlowCallRateThreshold)
+}
+
+// checkOpenTimeout passe le circuit en semi-ouvert une fois le délai d'attente écoulé ;
+// appelé avec le verrou
+func (c *CircuitBreaker) checkOpenTimeout(now time.Time) {
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= c.WaitDurationInOpenState {
+		c.transition(CircuitHalfOpen, now)
+	}
+}
+
+// transition change l'état du circuit ; appelé avec le verrou
+func (c *CircuitBreaker) transition(state CircuitBreakerState, now time.Time) {
+	if state != c.state {
+		log.Printf("Circuit breaker: %s -> %s", c.state, state)
+	}
+	c.state = state
+	c.window = nil
+	c.next = 0
+	c.halfOpenPermits = 0
+	c.halfOpenResults = nil
+	if state == CircuitOpen {
+		c.openedAt = now
+	}
+}
+
+func (c *CircuitBreaker) minimumCalls() int {
+	if c.MinimumNumberOfCalls < 1 {
+		return 1
+	}
+	return c.MinimumNumberOfCalls
+}
+
+func (c *CircuitBreaker) halfOpenCalls() int {
+	if c.PermittedNumberOfCallsInHalfOpenState < 1 {
+		return 1
+	}
+	return c.PermittedNumberOfCallsInHalfOpenState
+}
+
+func countOutcomes(outcomes []callOutcome) (failed, slow int) {
+	for _, outcome := range outcomes {
+		if outcome.failed {
+			failed++
+		}
+		if outcome.slow {
+			slow++
+		}
+	}
+	return failed, slow
+}
+
+func rate(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(total)
+}
+
+// circuitBreakers retourne les circuit breakers de premier niveau de la route, indexés par
+// identifiant de nœud
+func (r *Route) circuitBreakers() map[string]*CircuitBreaker {
+	return topLevelNodes[*CircuitBreaker](r)
+}
+
+// CircuitBreaker commence un bloc Circuit Breaker EIP protégeant les processeurs qui suivent,
+// jusqu'à OnFallback() ou End()
+func (b *RouteBuilder) CircuitBreaker() *CircuitBreakerDefinition {
+	c := NewCircuitBreaker()
+	b.container.AddProcessor(c)
+
+	return &CircuitBreakerDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: c,
+		},
+		parent:  b,
+		breaker: c,
+	}
+}
+
+// CircuitBreakerDefinition permet de configurer le circuit breaker, le bloc protégé et le fallback
+type CircuitBreakerDefinition struct {
+	*RouteBuilder
+	parent  *RouteBuilder
+	breaker *CircuitBreaker
+}
+
+// circuitBreakerFallback est le conteneur des processeurs du fallback
+type circuitBreakerFallback struct {
+	breaker *CircuitBreaker
+}
+
+func (f *circuitBreakerFallback) AddProcessor(processor Processor) {
+	f.breaker.AddFallbackProcessor(processor)
+}
+
+// Config remplace la configuration du circuit breaker
+func (d *CircuitBreakerDefinition) Config(config CircuitBreakerConfig) *CircuitBreakerDefinition {
+	d.breaker.CircuitBreakerConfig = config
+	return d
+}
+
+// SlidingWindowSize définit le nombre de derniers appels pris en compte
+func (d *CircuitBreakerDefinition) SlidingWindowSize(size int) *CircuitBreakerDefinition {
+	d.breaker.SlidingWindowSize = size
+	return d
+}
+
+// MinimumNumberOfCalls définit le nombre d'appels nécessaires avant de calculer les taux
+func (d *CircuitBreakerDefinition) MinimumNumberOfCalls(calls int) *CircuitBreakerDefinition {
+	d.breaker.MinimumNumberOfCalls = calls
+	return d
+}
+
+// FailureRateThreshold définit le pourcentage d'échecs qui ouvre le circuit
+func (d *CircuitBreakerDefinition) FailureRateThreshold(percent float64) *CircuitBreakerDefinition {
+	d.breaker.FailureRateThreshold = percent
+	return d
+}
+
+// SlowCallRateThreshold définit le pourcentage d'appels lents qui ouvre le circuit
+func (d *CircuitBreakerDefinition) SlowCallRateThreshold(percent float64) *CircuitBreakerDefinition {
+	d.breaker.SlowCallRateThreshold = percent
+	return d
+}
+
+// SlowCallDurationThreshold définit la durée à partir de laquelle un appel est lent
+func (d *CircuitBreakerDefinition) SlowCallDurationThreshold(duration time.Duration) *CircuitBreakerDefinition {
+	d.breaker.SlowCallDurationThreshold = duration
+	return d
+}
+
+// WaitDurationInOpenState définit la durée pendant laquelle le circuit reste ouvert
+func (d *CircuitBreakerDefinition) WaitDurationInOpenState(duration time.Duration) *CircuitBreakerDefinition {
+	d.breaker.WaitDurationInOpenState = duration
+	return d
+}
+
+// PermittedNumberOfCallsInHalfOpenState définit le nombre d'appels d'essai en état semi-ouvert
+func (d *CircuitBreakerDefinition) PermittedNumberOfCallsInHalfOpenState(calls int) *CircuitBreakerDefinition {
+	d.breaker.PermittedNumberOfCallsInHalfOpenState = calls
+	return d
+}
+
+// OnFallback commence le fallback : les processeurs qui suivent sont exécutés à la place du bloc
+// protégé lorsque le circuit est ouvert, ou après son échec. L'erreur d'origine est disponible
+// dans Exchange.Error.
+func (d *CircuitBreakerDefinition) OnFallback() *CircuitBreakerDefinition {
+	return &CircuitBreakerDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   d.context,
+			route:     d.route,
+			container: &circuitBreakerFallback{breaker: d.breaker},
+		},
+		parent:  d.parent,
+		breaker: d.breaker,
+	}
+}
+
+// CircuitBreaker retourne le circuit breaker configuré
+func (d *CircuitBreakerDefinition) CircuitBreaker() *CircuitBreaker {
+	return d.breaker
+}
+
+// Process ajoute un processeur et reste dans le contexte du circuit breaker
+func (d *CircuitBreakerDefinition) Process(processor Processor) *CircuitBreakerDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte du circuit breaker
+func (d *CircuitBreakerDefinition) ProcessFunc(f func(*Exchange) error) *CircuitBreakerDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte du circuit breaker
+func (d *CircuitBreakerDefinition) To(uris ...string) *CircuitBreakerDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD ajoute un ou plusieurs endpoints dynamiques et reste dans le contexte du circuit breaker
+func (d *CircuitBreakerDefinition) ToD(uriTemplates ...string) *CircuitBreakerDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody définit le corps du message de sortie et reste dans le contexte du circuit breaker
+func (d *CircuitBreakerDefinition) SetBody(body interface{}) *CircuitBreakerDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte du circuit breaker
+func (d *CircuitBreakerDefinition) SetHeader(key string, value interface{}) *CircuitBreakerDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetProperty définit une propriété de l'échange et reste dans le contexte du circuit breaker
+func (d *CircuitBreakerDefinition) SetProperty(key string, value any) *CircuitBreakerDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte du circuit breaker
+func (d *CircuitBreakerDefinition) Log(message string) *CircuitBreakerDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End termine le bloc Circuit Breaker et revient au builder parent
+func (d *CircuitBreakerDefinition) End() *RouteBuilder {
+	return d.parent
+}
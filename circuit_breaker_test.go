@@ -0,0 +1,180 @@
+package gocamel
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errPartnerDown = errors.New("partner down")
+
+// failWhenHeader échoue lorsque l'en-tête fail vaut true
+func failWhenHeader(calls *int) func(*Exchange) error {
+	return func(e *Exchange) error {
+		*calls++
+		if fail, _ := e.GetIn().GetHeaderAsBool("fail"); fail {
+			return errPartnerDown
+		}
+		return nil
+	}
+}
+
+func TestCircuitBreaker_OpensOnFailureRate(t *testing.T) {
+	camel := newTestContext()
+	calls := 0
+	cb := camel.CreateRouteBuilder().
+		From("direct:start").
+		CircuitBreaker().
+		SlidingWindowSize(4).
+		MinimumNumberOfCalls(4).
+		FailureRateThreshold(50).
+		WaitDurationInOpenState(time.Minute).
+		ProcessFunc(failWhenHeader(&calls))
+	cb.End().Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "ok"))
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", "ko", map[string]any{"fail": true}), errPartnerDown)
+	require.NoError(t, template.SendBody("direct:start", "ok"))
+	assert.Equal(t, CircuitClosed, cb.CircuitBreaker().State())
+	assert.Equal(t, float64(-1), cb.CircuitBreaker().Stats().FailureRate)
+
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", "ko", map[string]any{"fail": true}), errPartnerDown)
+	assert.Equal(t, CircuitOpen, cb.CircuitBreaker().State())
+
+	// Le circuit ouvert court-circuite le bloc protégé
+	exchange, err := template.Request("direct:start", "ok", nil)
+	assert.ErrorIs(t, err, ErrCircuitBreakerOpen)
+	assert.Equal(t, 4, calls)
+	shortCircuited, _ := exchange.GetProperty(CamelResponseShortCircuited)
+	assert.Equal(t, true, shortCircuited)
+	assert.Equal(t, int64(1), cb.CircuitBreaker().Stats().NotPermittedCalls)
+
+	cb.CircuitBreaker().Reset()
+	assert.Equal(t, CircuitClosed, cb.CircuitBreaker().State())
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	camel := newTestContext()
+	calls := 0
+	cb := camel.CreateRouteBuilder().
+		From("direct:start").
+		CircuitBreaker().
+		SlidingWindowSize(2).
+		MinimumNumberOfCalls(2).
+		WaitDurationInOpenState(50 * time.Millisecond).
+		PermittedNumberOfCallsInHalfOpenState(2).
+		ProcessFunc(failWhenHeader(&calls))
+	cb.End().Build()
+	breaker := cb.CircuitBreaker()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	fail := map[string]any{"fail": true}
+	open := func() {
+		template.SendBodyAndHeaders("direct:start", "ko", fail)
+		template.SendBodyAndHeaders("direct:start", "ko", fail)
+		require.Equal(t, CircuitOpen, breaker.State())
+	}
+
+	// Essais réussis : le circuit se referme
+	open()
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	require.NoError(t, template.SendBody("direct:start", "ok"))
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	require.NoError(t, template.SendBody("direct:start", "ok"))
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	// Essai en échec : le circuit se rouvre
+	open()
+	time.Sleep(60 * time.Millisecond)
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:start", "ko", fail), errPartnerDown)
+	assert.Equal(t, CircuitOpen, breaker.State())
+}
+
+func TestCircuitBreaker_SlowCalls(t *testing.T) {
+	camel := newTestContext()
+	cb := camel.CreateRouteBuilder().
+		From("direct:start").
+		CircuitBreaker().
+		SlidingWindowSize(2).
+		MinimumNumberOfCalls(2).
+		SlowCallDurationThreshold(20 * time.Millisecond).
+		SlowCallRateThreshold(50).
+		ProcessFunc(func(e *Exchange) error {
+			if slow, _ := e.GetIn().GetHeaderAsBool("slow"); slow {
+				time.Sleep(30 * time.Millisecond)
+			}
+			return nil
+		})
+	cb.End().Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "fast"))
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "slow", map[string]any{"slow": true}))
+	assert.Equal(t, CircuitOpen, cb.CircuitBreaker().State())
+}
+
+func TestCircuitBreaker_Fallback(t *testing.T) {
+	camel := newTestContext()
+	calls := 0
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		CircuitBreaker().
+		SlidingWindowSize(1).
+		MinimumNumberOfCalls(1).
+		ProcessFunc(failWhenHeader(&calls)).
+		To("mock:partner").
+		OnFallback().
+		SetBody("fallback").
+		To("mock:fallback").
+		End().
+		To("mock:result").
+		Build()
+
+	partner, _ := camel.GetMockEndpoint("mock:partner")
+	partner.ExpectedMessageCount(0)
+	fallback, _ := camel.GetMockEndpoint("mock:fallback")
+	fallback.ExpectedMessageCount(2)
+	fallback.MessageN(0).Predicate(func(e *Exchange) bool {
+		shortCircuited, _ := e.GetProperty(CamelResponseShortCircuited)
+		return errors.Is(e.Error, errPartnerDown) && shortCircuited == false
+	})
+	fallback.MessageN(1).Predicate(func(e *Exchange) bool {
+		state, _ := e.GetProperty(CamelCircuitBreakerState)
+		return errors.Is(e.Error, ErrCircuitBreakerOpen) && state == string(CircuitOpen)
+	})
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedMessageCount(2)
+	for i := 0; i < 2; i++ {
+		result.MessageN(i).Predicate(func(e *Exchange) bool {
+			fromFallback, _ := e.GetProperty(CamelResponseFromFallback)
+			successful, _ := e.GetProperty(CamelResponseSuccessfulExecution)
+			return fromFallback == true && successful == false
+		}).Body("fallback")
+	}
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "ko", map[string]any{"fail": true}))
+	require.NoError(t, template.SendBody("direct:start", "ok"))
+	assert.Equal(t, 1, calls)
+
+	partner.AssertIsSatisfied(t, 0)
+	fallback.AssertIsSatisfied(t, time.Second)
+	result.AssertIsSatisfied(t, time.Second)
+}
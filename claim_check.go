@@ -0,0 +1,284 @@
+package gocamel
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// ClaimCheckOperation est l'opération réalisée par un Claim Check
+type ClaimCheckOperation string
+
+const (
+	// ClaimCheckSet stocke le message sous la clé, en remplaçant un message existant
+	ClaimCheckSet ClaimCheckOperation = "Set"
+	// ClaimCheckGet restaure le message stocké sous la clé, qui reste dans le repository
+	ClaimCheckGet ClaimCheckOperation = "Get"
+	// ClaimCheckGetAndRemove restaure le message stocké sous la clé et le retire du repository
+	ClaimCheckGetAndRemove ClaimCheckOperation = "GetAndRemove"
+	// ClaimCheckPush empile le message sur la pile de l'échange
+	ClaimCheckPush ClaimCheckOperation = "Push"
+	// ClaimCheckPop restaure le dernier message empilé par l'échange
+	ClaimCheckPop ClaimCheckOperation = "Pop"
+)
+
+// ClaimCheck implémente le Claim Check EIP : le corps et les en-têtes du message courant sont
+// mis de côté dans un repository (Set, Push) puis restaurés plus tard dans la route (Get,
+// GetAndRemove, Pop), afin que des données volumineuses ne traversent pas chaque étape.
+//
+// Set, Get et GetAndRemove utilisent une clé calculée pour chaque échange, ce qui permet de
+// restaurer le message depuis un autre échange. Push et Pop utilisent une pile propre à
+// l'échange ; elle est supprimée à la fin de l'échange si elle n'a pas été entièrement dépilée.
+//
+// À la restauration, le corps est remplacé et les en-têtes stockés sont fusionnés dans le
+// message courant ; un filtre permet de choisir les données restaurées.
+type ClaimCheck struct {
+	context   *CamelContext
+	Operation ClaimCheckOperation
+	// Key calcule la clé du message (Set, Get, GetAndRemove)
+	Key func(*Exchange) (string, error)
+	// Repository stocke les messages ; nil utilise celui du CamelContext
+	Repository ClaimCheckRepository
+	filter     *claimCheckFilter
+}
+
+// NewClaimCheck crée un Claim Check réalisant l'opération donnée ; la clé est ignorée par Push et Pop
+func NewClaimCheck(context *CamelContext, operation ClaimCheckOperation, key func(*Exchange) (string, error)) *ClaimCheck {
+	return &ClaimCheck{
+		context:   context,
+		Operation: operation,
+		Key:       key,
+	}
+}
+
+// SetFilter définit les données restaurées par Get, GetAndRemove et Pop. Le filtre est une liste
+// séparée par des virgules de "body", "headers" et "header:<pattern>" (joker '*' accepté) ;
+// un élément préfixé par '-' est exclu. Sans élément inclus, tout ce qui n'est pas exclu est restauré.
+func (c *ClaimCheck) SetFilter(filter string) error {
+	f, err := parseClaimCheckFilter(filter)
+	if err != nil {
+		return err
+	}
+	c.filter = f
+	return nil
+}
+
+// Process implémente l'interface Processor
+func (c *ClaimCheck) Process(exchange *Exchange) error {
+	repository := c.Repository
+	if repository == nil {
+		repository = c.context.GetClaimCheckRepository()
+	}
+	ctx := exchangeContext(exchange)
+
+	// Le message courant est celui que le processeur précédent a produit
+	promoteOut(exchange)
+
+	switch c.Operation {
+	case ClaimCheckPush:
+		if err := repository.Push(ctx, exchange.ID, exchange.GetIn()); err != nil {
+			return fmt.Errorf("claim check push failed: %w", err)
+		}
+		c.removeStackOnDone(exchange, repository)
+		return nil
+	case ClaimCheckPop:
+		message, err := repository.Pop(ctx, exchange.ID)
+		if err != nil {
+			return fmt.Errorf("claim check pop failed: %w", err)
+		}
+		c.restore(exchange, message)
+		return nil
+	}
+
+	key, err := c.Key(exchange)
+	if err != nil {
+		return fmt.Errorf("claim check key expression error: %w", err)
+	}
+	if key == "" {
+		return fmt.Errorf("claim check key is empty for exchange %s", exchange.ID)
+	}
+
+	switch c.Operation {
+	case ClaimCheckSet:
+		if err := repository.Add(ctx, key, exchange.GetIn()); err != nil {
+			return fmt.Errorf("claim check set failed: %w", err)
+		}
+	case ClaimCheckGet:
+		message, err := repository.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("claim check get failed: %w", err)
+		}
+		c.restore(exchange, message)
+	case ClaimCheckGetAndRemove:
+		message, err := repository.GetAndRemove(ctx, key)
+		if err != nil {
+			return fmt.Errorf("claim check get and remove failed: %w", err)
+		}
+		c.restore(exchange, message)
+	default:
+		return fmt.Errorf("unsupported claim check operation: %s", c.Operation)
+	}
+	return nil
+}
+
+// restore fusionne le message restauré dans le message courant selon le filtre
+func (c *ClaimCheck) restore(exchange *Exchange, message *Message) {
+	if message == nil {
+		return
+	}
+	in := exchange.GetIn()
+	if c.filter.includesBody() {
+		in.SetBody(message.GetBody())
+	}
+	for name, value := range message.GetHeaders() {
+		if c.filter.includesHeader(name) {
+			in.SetHeader(name, value)
+		}
+	}
+}
+
+// removeStackOnDone supprime la pile de l'échange à sa fin, une seule fois par échange
+func (c *ClaimCheck) removeStackOnDone(exchange *Exchange, repository ClaimCheckRepository) {
+	const registered = "CamelClaimCheckStackCleanup"
+	// La propriété est copiée avec l'échange : elle n'est valable que pour l'identifiant qui l'a posée
+	if id, _ := exchange.GetProperty(registered); id == exchange.ID {
+		return
+	}
+	exchange.SetProperty(registered, exchange.ID)
+	exchange.AddSynchronization(&claimCheckStackCleanup{repository: repository})
+}
+
+// claimCheckStackCleanup supprime la pile d'un échange terminé
+type claimCheckStackCleanup struct {
+	repository ClaimCheckRepository
+}
+
+func (s *claimCheckStackCleanup) OnComplete(exchange *Exchange) { s.remove(exchange) }
+
+func (s *claimCheckStackCleanup) OnFailure(exchange *Exchange) { s.remove(exchange) }
+
+func (s *claimCheckStackCleanup) remove(exchange *Exchange) {
+	if err := s.repository.RemoveStack(context.Background(), exchange.ID); err != nil {
+		log.Printf("ClaimCheck: échec de la suppression de la pile de l'échange %s: %v", exchange.ID, err)
+	}
+}
+
+// claimCheckFilter décrit les données restaurées ; un filtre nil restaure tout
+type claimCheckFilter struct {
+	includeBody, excludeBody       bool
+	includeHeaders, excludeHeaders bool
+	includes, excludes             []*regexp.Regexp
+}
+
+func parseClaimCheckFilter(filter string) (*claimCheckFilter, error) {
+	f := &claimCheckFilter{}
+	for _, item := range strings.Split(filter, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		exclude := strings.HasPrefix(item, "-")
+		item = strings.TrimLeft(item, "+-")
+
+		switch {
+		case item == "body":
+			f.includeBody, f.excludeBody = f.includeBody || !exclude, f.excludeBody || exclude
+		case item == "headers":
+			f.includeHeaders, f.excludeHeaders = f.includeHeaders || !exclude, f.excludeHeaders || exclude
+		case strings.HasPrefix(item, "header:") && len(item) > len("header:"):
+			pattern := patternToRegex(strings.TrimPrefix(item, "header:"))
+			if exclude {
+				f.excludes = append(f.excludes, pattern)
+			} else {
+				f.includes = append(f.includes, pattern)
+			}
+		default:
+			return nil, fmt.Errorf("invalid claim check filter element: %q", item)
+		}
+	}
+	return f, nil
+}
+
+func (f *claimCheckFilter) hasIncludes() bool {
+	return f.includeBody || f.includeHeaders || len(f.includes) > 0
+}
+
+func (f *claimCheckFilter) includesBody() bool {
+	if f == nil {
+		return true
+	}
+	return !f.excludeBody && (f.includeBody || !f.hasIncludes())
+}
+
+func (f *claimCheckFilter) includesHeader(name string) bool {
+	if f == nil {
+		return true
+	}
+	if f.excludeHeaders || matchesAny(f.excludes, name) {
+		return false
+	}
+	return f.includeHeaders || matchesAny(f.includes, name) || !f.hasIncludes()
+}
+
+func matchesAny(patterns []*regexp.Regexp, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimCheck ajoute un Claim Check EIP réalisant l'opération donnée. La clé est une constante
+// ou une expression Simple (ex: "${header.Message-ID}") utilisée par Set, Get et GetAndRemove ;
+// elle est ignorée par Push et Pop, qui utilisent une pile propre à l'échange.
+func (b *RouteBuilder) ClaimCheck(operation ClaimCheckOperation, key string) *ClaimCheckDefinition {
+	var keyExpression func(*Exchange) (string, error)
+	switch operation {
+	case ClaimCheckPush, ClaimCheckPop:
+	case ClaimCheckSet, ClaimCheckGet, ClaimCheckGetAndRemove:
+		if key == "" {
+			panic(fmt.Sprintf("claim check operation %s requires a key", operation))
+		}
+		template, err := ParseSimpleTemplate(key)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse simple expression for claim check key: %v", err))
+		}
+		expression := simpleValueExpression(template)
+		keyExpression = func(exchange *Exchange) (string, error) {
+			value, err := expression(exchange)
+			if err != nil || value == nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", value), nil
+		}
+	default:
+		panic(fmt.Sprintf("unsupported claim check operation: %s", operation))
+	}
+
+	c := NewClaimCheck(b.context, operation, keyExpression)
+	b.container.AddProcessor(c)
+	return &ClaimCheckDefinition{RouteBuilder: b, claimCheck: c}
+}
+
+// ClaimCheckDefinition permet de configurer un Claim Check
+type ClaimCheckDefinition struct {
+	*RouteBuilder
+	claimCheck *ClaimCheck
+}
+
+// Repository définit le repository du Claim Check (celui du CamelContext par défaut)
+func (d *ClaimCheckDefinition) Repository(repository ClaimCheckRepository) *ClaimCheckDefinition {
+	d.claimCheck.Repository = repository
+	return d
+}
+
+// RestoreFilter définit les données restaurées par Get, GetAndRemove et Pop (ex: "header:CamelMailAttachment_*", "body,-header:Authorization")
+func (d *ClaimCheckDefinition) RestoreFilter(filter string) *ClaimCheckDefinition {
+	if err := d.claimCheck.SetFilter(filter); err != nil {
+		panic(fmt.Sprintf("failed to parse claim check filter: %v", err))
+	}
+	return d
+}
@@ -0,0 +1,119 @@
+package gocamel
+
+import (
+	"context"
+	"sync"
+)
+
+// ClaimCheckRepository defines the interface for storing the messages parked by the Claim Check EIP.
+// Messages are stored under a key (Set/Get), or on a stack (Push/Pop) identified by a key.
+type ClaimCheckRepository interface {
+	// Add adds or replaces the message stored under the key.
+	Add(ctx context.Context, key string, message *Message) error
+
+	// Get retrieves the message stored under the key. Returns (nil, nil) if it doesn't exist.
+	Get(ctx context.Context, key string) (*Message, error)
+
+	// GetAndRemove atomically retrieves and removes the message stored under the key, so that
+	// concurrent callers never restore the same message twice. Returns (nil, nil) if it doesn't exist.
+	GetAndRemove(ctx context.Context, key string) (*Message, error)
+
+	// Remove removes the message stored under the key. The stack with the same key is kept.
+	Remove(ctx context.Context, key string) error
+
+	// Push pushes the message on the stack identified by the key.
+	Push(ctx context.Context, key string, message *Message) error
+
+	// Pop removes and returns the last message pushed on the stack. Returns (nil, nil) if the stack is empty.
+	Pop(ctx context.Context, key string) (*Message, error)
+
+	// RemoveStack removes the stack identified by the key. The message stored under the same key is kept.
+	RemoveStack(ctx context.Context, key string) error
+}
+
+// MemoryClaimCheckRepository is an in-memory implementation of ClaimCheckRepository.
+type MemoryClaimCheckRepository struct {
+	mu     sync.Mutex
+	store  map[string]*Message
+	stacks map[string][]*Message
+}
+
+// NewMemoryClaimCheckRepository creates a new MemoryClaimCheckRepository instance.
+func NewMemoryClaimCheckRepository() *MemoryClaimCheckRepository {
+	return &MemoryClaimCheckRepository{
+		store:  make(map[string]*Message),
+		stacks: make(map[string][]*Message),
+	}
+}
+
+// Add adds or replaces the message stored under the key.
+func (r *MemoryClaimCheckRepository) Add(ctx context.Context, key string, message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store[key] = message.Copy()
+	return nil
+}
+
+// Get retrieves the message stored under the key. Returns nil if it doesn't exist.
+func (r *MemoryClaimCheckRepository) Get(ctx context.Context, key string) (*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, exists := r.store[key]
+	if !exists {
+		return nil, nil
+	}
+	return message.Copy(), nil
+}
+
+// GetAndRemove retrieves and removes the message stored under the key. Returns nil if it doesn't exist.
+func (r *MemoryClaimCheckRepository) GetAndRemove(ctx context.Context, key string) (*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, exists := r.store[key]
+	if !exists {
+		return nil, nil
+	}
+	delete(r.store, key)
+	return message, nil
+}
+
+// Remove removes the message stored under the key.
+func (r *MemoryClaimCheckRepository) Remove(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.store, key)
+	return nil
+}
+
+// Push pushes the message on the stack identified by the key.
+func (r *MemoryClaimCheckRepository) Push(ctx context.Context, key string, message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stacks[key] = append(r.stacks[key], message.Copy())
+	return nil
+}
+
+// Pop removes and returns the last message pushed on the stack. Returns nil if the stack is empty.
+func (r *MemoryClaimCheckRepository) Pop(ctx context.Context, key string) (*Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stack := r.stacks[key]
+	if len(stack) == 0 {
+		return nil, nil
+	}
+	message := stack[len(stack)-1]
+	if len(stack) == 1 {
+		delete(r.stacks, key)
+	} else {
+		r.stacks[key] = stack[:len(stack)-1]
+	}
+	return message, nil
+}
+
+// RemoveStack removes the stack identified by the key.
+func (r *MemoryClaimCheckRepository) RemoveStack(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.stacks, key)
+	return nil
+}
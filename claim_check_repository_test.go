@@ -0,0 +1,76 @@
+package gocamel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClaimCheckRepository vérifie le contrat commun des ClaimCheckRepository
+func testClaimCheckRepository(t *testing.T, repo ClaimCheckRepository) {
+	ctx := context.Background()
+	newMessage := func(body string) *Message {
+		message := NewMessage()
+		message.SetBody(body)
+		message.SetHeader("name", body)
+		return message
+	}
+
+	message, err := repo.Get(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Nil(t, message)
+
+	require.NoError(t, repo.Add(ctx, "order-1", newMessage("a")))
+	require.NoError(t, repo.Add(ctx, "order-1", newMessage("b")))
+	message, err = repo.Get(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, "b", message.GetBody())
+	assert.Equal(t, map[string]any{"name": "b"}, message.GetHeaders())
+
+	// Les piles sont indépendantes des messages stockés sous la même clé
+	require.NoError(t, repo.Push(ctx, "order-1", newMessage("first")))
+	require.NoError(t, repo.Push(ctx, "order-1", newMessage("second")))
+	require.NoError(t, repo.Push(ctx, "order-2", newMessage("other")))
+	message, err = repo.Pop(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, "second", message.GetBody())
+	message, _ = repo.Get(ctx, "order-1")
+	assert.Equal(t, "b", message.GetBody())
+
+	// Remove et RemoveStack ne suppriment chacun que leur partie de la clé
+	require.NoError(t, repo.Remove(ctx, "order-1"))
+	message, _ = repo.Get(ctx, "order-1")
+	assert.Nil(t, message)
+	require.NoError(t, repo.Push(ctx, "order-1", newMessage("third")))
+	require.NoError(t, repo.Add(ctx, "order-1", newMessage("c")))
+	require.NoError(t, repo.RemoveStack(ctx, "order-1"))
+	message, err = repo.Pop(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Nil(t, message)
+
+	message, err = repo.GetAndRemove(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, "c", message.GetBody())
+	message, err = repo.GetAndRemove(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Nil(t, message)
+
+	message, _ = repo.Pop(ctx, "order-2")
+	assert.Equal(t, "other", message.GetBody())
+}
+
+func TestMemoryClaimCheckRepository(t *testing.T) {
+	repo := NewMemoryClaimCheckRepository()
+	testClaimCheckRepository(t, repo)
+
+	// Le message stocké est une copie : le modifier ensuite ne change pas le repository
+	ctx := context.Background()
+	message := NewMessage()
+	message.SetHeader("status", "parked")
+	require.NoError(t, repo.Add(ctx, "key", message))
+	message.SetHeader("status", "changed")
+	stored, _ := repo.Get(ctx, "key")
+	assert.Equal(t, "parked", stored.GetHeaders()["status"])
+}
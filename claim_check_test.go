@@ -0,0 +1,130 @@
+package gocamel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimCheck_PushPopAttachments(t *testing.T) {
+	camel := newTestContext()
+	attachment := MailAttachmentPrefix + "_invoice.pdf"
+	camel.CreateRouteBuilder().
+		From("direct:mail").
+		ClaimCheck(ClaimCheckPush, "").
+		RemoveHeaders(MailAttachmentPrefix+"_*").
+		SetBody("summary").
+		To("mock:enrich").
+		ClaimCheck(ClaimCheckPop, "").
+		RestoreFilter("header:" + MailAttachmentPrefix + "_*").
+		To("mock:result").
+		Build()
+
+	enrich, _ := camel.GetMockEndpoint("mock:enrich")
+	enrich.ExpectedBodiesReceived("summary")
+	enrich.MessageN(0).Predicate(func(e *Exchange) bool {
+		return !e.GetIn().HasHeader(attachment)
+	})
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("summary")
+	result.MessageN(0).Header(attachment, []byte("%PDF-1.7"))
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	exchange := NewExchange(context.Background())
+	exchange.GetIn().SetBody("Please find the invoice attached")
+	exchange.GetIn().SetHeader(attachment, []byte("%PDF-1.7"))
+	require.NoError(t, camel.CreateProducerTemplate().Send("direct:mail", exchange))
+
+	enrich.AssertIsSatisfied(t, time.Second)
+	result.AssertIsSatisfied(t, time.Second)
+
+	message, err := camel.GetClaimCheckRepository().Pop(context.Background(), exchange.ID)
+	require.NoError(t, err)
+	assert.Nil(t, message)
+}
+
+func TestClaimCheck_SetAndGetAndRemove(t *testing.T) {
+	camel := newTestContext()
+	repo := NewMemoryClaimCheckRepository()
+	camel.CreateRouteBuilder().
+		From("direct:park").
+		ClaimCheck(ClaimCheckSet, "${header.orderId}").
+		Repository(repo).
+		SetBody("parked").
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:claim").
+		ClaimCheck(ClaimCheckGetAndRemove, "${header.orderId}").
+		Repository(repo).
+		RestoreFilter("body, headers, -header:secret").
+		To("mock:claimed").
+		Build()
+
+	claimed, _ := camel.GetMockEndpoint("mock:claimed")
+	claimed.ExpectedBodiesReceived("large payload", "not found")
+	claimed.MessageN(0).Header("status", "new")
+	claimed.MessageN(0).Predicate(func(e *Exchange) bool {
+		return !e.GetIn().HasHeader("secret")
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:park", "large payload", map[string]any{
+		"orderId": "42", "status": "new", "secret": "s3cr3t",
+	}))
+	require.NoError(t, template.SendBodyAndHeaders("direct:claim", "ticket", map[string]any{"orderId": "42"}))
+	// Le message a été retiré : un second retrait ne restaure rien
+	require.NoError(t, template.SendBodyAndHeaders("direct:claim", "not found", map[string]any{"orderId": "42"}))
+
+	claimed.AssertIsSatisfied(t, time.Second)
+	assert.ErrorContains(t, template.SendBody("direct:claim", "x"), "claim check key is empty")
+}
+
+func TestClaimCheck_StackRemovedWhenExchangeDone(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		ProcessFunc(func(exchange *Exchange) error {
+			exchange.GetIn().SetHeader("key", exchange.ID)
+			return nil
+		}).
+		ClaimCheck(ClaimCheckPush, "").
+		ClaimCheck(ClaimCheckPush, "").
+		ClaimCheck(ClaimCheckSet, "${header.key}").
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	exchange := NewExchange(context.Background())
+	exchange.GetIn().SetBody("a")
+	require.NoError(t, camel.CreateProducerTemplate().Send("direct:start", exchange))
+	exchange.Done(nil)
+
+	message, err := camel.GetClaimCheckRepository().Pop(context.Background(), exchange.ID)
+	require.NoError(t, err)
+	assert.Nil(t, message)
+
+	// Le message stocké sous la même clé que la pile n'est pas supprimé avec elle
+	message, err = camel.GetClaimCheckRepository().Get(context.Background(), exchange.ID)
+	require.NoError(t, err)
+	require.NotNil(t, message)
+	assert.Equal(t, "a", message.GetBody())
+}
+
+func TestClaimCheck_InvalidDefinitions(t *testing.T) {
+	camel := newTestContext()
+	assert.PanicsWithValue(t, "claim check operation Get requires a key", func() {
+		camel.CreateRouteBuilder().From("direct:a").ClaimCheck(ClaimCheckGet, "")
+	})
+	assert.PanicsWithValue(t, `failed to parse claim check filter: invalid claim check filter element: "attachments"`, func() {
+		camel.CreateRouteBuilder().From("direct:b").ClaimCheck(ClaimCheckPop, "").RestoreFilter("body,attachments")
+	})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tranchida/gocamel"
+)
+
+// managementClient appelle l'API REST de management d'une instance en cours d'exécution
+type managementClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// addClientFlags ajoute les options de connexion communes aux commandes distantes
+func addClientFlags(fs *flag.FlagSet) (url, token *string) {
+	url = fs.String("url", "http://localhost:8081", "base URL of the management API")
+	token = fs.String("token", "", "bearer token for the management API")
+	return url, token
+}
+
+func newManagementClient(url, token string) *managementClient {
+	return &managementClient{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do exécute une requête et décode la réponse JSON dans result
+func (c *managementClient) do(method, path string, payload any, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// routesCommand liste les routes d'une instance via l'API de management
+func routesCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("routes", "[options]", stderr)
+	url, token := addClientFlags(fs)
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return 2
+	}
+
+	var routes []gocamel.RouteInfo
+	if err := newManagementClient(*url, *token).do(http.MethodGet, "/api/routes", nil, &routes); err != nil {
+		fmt.Fprintf(stderr, "gocamel: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tGROUP\tDESCRIPTION")
+	for _, route := range routes {
+		status := "stopped"
+		if route.Started {
+			status = "started"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", route.ID, status, route.Group, route.Description)
+	}
+	w.Flush()
+	return 0
+}
+
+// sendCommand envoie un message vers un endpoint d'une instance via l'API de management
+func sendCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("send", "<uri> [options]", stderr)
+	url, token := addClientFlags(fs)
+	body := fs.String("body", "", "body of the message")
+	headers := keyValueFlag{}
+	fs.Var(headers, "header", "message header as key=value (repeatable)")
+	reply := fs.Bool("reply", false, "print the reply of the endpoint")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	request := gocamel.SendRequest{
+		URI:     positional[0],
+		Body:    *body,
+		Headers: headers.toMap(),
+		Reply:   *reply,
+	}
+	var response gocamel.SendResponse
+	if err := newManagementClient(*url, *token).do(http.MethodPost, "/api/endpoints/send", request, &response); err != nil {
+		fmt.Fprintf(stderr, "gocamel: %v\n", err)
+		return 1
+	}
+
+	if !*reply {
+		fmt.Fprintf(stdout, "Sent exchange %s\n", response.ExchangeID)
+		return 0
+	}
+	keys := make([]string, 0, len(response.Headers))
+	for key := range response.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(stdout, "%s: %v\n", key, response.Headers[key])
+	}
+	if response.Body != nil {
+		if len(response.Headers) > 0 {
+			fmt.Fprintln(stdout)
+		}
+		fmt.Fprintln(stdout, response.Body)
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/tranchida/gocamel"
+)
+
+// newContext crée un CamelContext dans lequel tous les composants intégrés sont enregistrés
+func newContext() *gocamel.CamelContext {
+	ctx := gocamel.NewCamelContext()
+
+	mail := gocamel.NewMailComponent()
+	for _, scheme := range []string{"smtp", "smtps", "imap", "imaps", "pop3", "pop3s"} {
+		ctx.AddComponent(scheme, mail)
+	}
+
+	ctx.AddComponent("direct", gocamel.NewDirectComponent())
+	ctx.AddComponent("timer", gocamel.NewTimerComponent())
+	ctx.AddComponent("cron", gocamel.NewCronComponent())
+	ctx.AddComponent("http", gocamel.NewHTTPComponent())
+	ctx.AddComponent("file", gocamel.NewFileComponent())
+	ctx.AddComponent("ftp", gocamel.NewFTPComponent())
+	ctx.AddComponent("sftp", gocamel.NewSFTPComponent())
+	ctx.AddComponent("smb", gocamel.NewSMBComponent())
+	ctx.AddComponent("sql", gocamel.NewSQLComponent())
+	ctx.AddComponent("sql-stored", gocamel.NewSQLStoredComponent())
+	ctx.AddComponent("mongodb", gocamel.NewMongoDBComponent())
+	ctx.AddComponent("telegram", gocamel.NewTelegramComponent())
+	ctx.AddComponent("openai", gocamel.NewOpenAIComponent())
+	ctx.AddComponent("template", gocamel.NewTemplateComponent())
+	ctx.AddComponent("xslt", gocamel.NewXsltComponent())
+	ctx.AddComponent("xsd", gocamel.NewXsdComponent())
+	ctx.AddComponent("exec", gocamel.NewExecComponent())
+
+	return ctx
+}
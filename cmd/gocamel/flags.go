@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// keyValueFlag collecte des options répétables de la forme --header k=v
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[key] = val
+	return nil
+}
+
+// toMap convertit les paires collectées en map[string]any
+func (f keyValueFlag) toMap() map[string]any {
+	m := make(map[string]any, len(f))
+	for k, v := range f {
+		m[k] = v
+	}
+	return m
+}
+
+// newFlagSet crée un FlagSet dont les erreurs et l'aide sont écrites sur stderr
+func newFlagSet(name, arguments string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: gocamel %s %s\n\nOptions:\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseInterspersed analyse les options en autorisant les arguments positionnels
+// avant, entre ou après les options (ex: gocamel send direct:a --body x)
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,67 @@
+// Command gocamel exécute et pilote des routes GoCamel depuis la ligne de commande.
+//
+// Usage :
+//
+//	gocamel run routes.yaml [--management :8081]
+//	gocamel routes [--url http://localhost:8081] [--token TOKEN]
+//	gocamel send <uri> [--body BODY] [--header k=v]... [--reply]
+//	gocamel simple '<expr>' [--body BODY] [--header k=v]... [--property k=v]...
+//	gocamel validate routes.yaml...
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command représente une sous-commande du CLI
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{"run", "boot a context from YAML route files", runCommand},
+	{"routes", "list the routes of a running instance", routesCommand},
+	{"send", "send a message to an endpoint of a running instance", sendCommand},
+	{"simple", "evaluate a Simple expression offline", simpleCommand},
+	{"validate", "check YAML route files without starting them", validateCommand},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatche les arguments vers la sous-commande demandée et retourne le code de sortie
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "gocamel: unknown command %q\n\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gocamel <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'gocamel <command> -h' for the options of a command.")
+}
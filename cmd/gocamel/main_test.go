@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tranchida/gocamel"
+)
+
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestUnknownCommand(t *testing.T) {
+	code, _, stderr := runCLI("bogus")
+	if code != 2 || !strings.Contains(stderr, "unknown command") {
+		t.Errorf("Expected usage error, got %d: %s", code, stderr)
+	}
+}
+
+func TestSimpleCommand(t *testing.T) {
+	code, stdout, stderr := runCLI("simple", "Hello ${body} from ${header.city}", "--body", "World", "--header", "city=Paris")
+	if code != 0 {
+		t.Fatalf("Expected success, got %d: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != "Hello World from Paris" {
+		t.Errorf("Unexpected result: %q", stdout)
+	}
+
+	code, stdout, _ = runCLI("simple", "--predicate", "${header.count} > 5", "--header", "count=10")
+	if code != 0 || strings.TrimSpace(stdout) != "true" {
+		t.Errorf("Expected predicate to be true, got %d: %q", code, stdout)
+	}
+
+	if code, _, _ := runCLI("simple", "--header", "invalid"); code != 2 {
+		t.Errorf("Expected usage error for invalid header, got %d", code)
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	os.WriteFile(valid, []byte(`
+- route:
+    id: hello
+    from:
+      uri: direct:hello
+      steps:
+        - setBody:
+            simple: "Hello ${body}"
+        - to: direct:out
+`), 0644)
+	invalid := filepath.Join(dir, "invalid.yaml")
+	os.WriteFile(invalid, []byte(`
+- route:
+    id: broken
+    from:
+      uri: unknown:endpoint
+`), 0644)
+
+	code, stdout, stderr := runCLI("validate", valid)
+	if code != 0 || !strings.Contains(stdout, "OK") {
+		t.Errorf("Expected valid file, got %d: %s %s", code, stdout, stderr)
+	}
+
+	code, _, stderr = runCLI("validate", valid, invalid)
+	if code != 1 || !strings.Contains(stderr, "route broken") {
+		t.Errorf("Expected validation failure for broken route, got %d: %s", code, stderr)
+	}
+}
+
+func TestRoutesAndSendCommands(t *testing.T) {
+	ctx := gocamel.NewCamelContext()
+	ctx.AddComponent("direct", gocamel.NewDirectComponent())
+	ctx.CreateRouteBuilder().
+		From("direct:upper").
+		SetID("upper").
+		SetDescription("Upper-cases the body").
+		ProcessFunc(func(e *gocamel.Exchange) error {
+			body, _ := e.GetIn().GetBodyAsString()
+			e.GetOut().SetBody(strings.ToUpper(body))
+			return nil
+		}).
+		Build()
+	if err := ctx.Start(); err != nil {
+		t.Fatalf("Failed to start context: %v", err)
+	}
+	defer ctx.Stop()
+
+	mgmt := gocamel.NewManagementServer(ctx).
+		AddAuthenticator(gocamel.NewBearerTokenAuthenticator().AddToken("secret", "cli", gocamel.RoleOperator))
+	server := httptest.NewServer(mgmt.Handler())
+	defer server.Close()
+
+	code, stdout, stderr := runCLI("routes", "--url", server.URL, "--token", "secret")
+	if code != 0 {
+		t.Fatalf("Expected success, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "upper") || !strings.Contains(stdout, "started") || !strings.Contains(stdout, "Upper-cases the body") {
+		t.Errorf("Unexpected routes output: %q", stdout)
+	}
+
+	code, stdout, stderr = runCLI("send", "direct:upper", "--url", server.URL, "--token", "secret", "--body", "ping", "--reply")
+	if code != 0 {
+		t.Fatalf("Expected success, got %d: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != "PING" {
+		t.Errorf("Unexpected reply: %q", stdout)
+	}
+
+	code, _, stderr = runCLI("routes", "--url", server.URL)
+	if code != 1 || !strings.Contains(stderr, "401") {
+		t.Errorf("Expected unauthorized error without token, got %d: %s", code, stderr)
+	}
+}
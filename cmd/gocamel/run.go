@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tranchida/gocamel"
+)
+
+// runCommand démarre un contexte à partir de fichiers de routes YAML et attend un signal d'arrêt
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("run", "<routes.yaml>... [options]", stderr)
+	management := fs.String("management", "", "address of the management REST API (e.g. :8081)")
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx := newContext()
+	for _, file := range files {
+		routes, err := gocamel.LoadRoutesYAMLFile(ctx, file)
+		if err != nil {
+			fmt.Fprintf(stderr, "gocamel: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Loaded %d route(s) from %s\n", len(routes), file)
+	}
+
+	if err := ctx.Start(); err != nil {
+		fmt.Fprintf(stderr, "gocamel: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Started %d route(s)\n", ctx.GetStartedRouteCount())
+
+	var mgmt *gocamel.ManagementServer
+	if *management != "" {
+		mgmt = gocamel.NewManagementServer(ctx)
+		if err := mgmt.Start(*management); err != nil {
+			fmt.Fprintf(stderr, "gocamel: %v\n", err)
+			ctx.Stop()
+			return 1
+		}
+		fmt.Fprintf(stdout, "Management API listening on %s\n", *management)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	fmt.Fprintln(stdout, "Shutting down")
+	if mgmt != nil {
+		mgmt.Stop()
+	}
+	if err := ctx.Stop(); err != nil {
+		fmt.Fprintf(stderr, "gocamel: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/tranchida/gocamel"
+)
+
+// simpleCommand évalue une expression Simple sur un échange construit à partir des options
+func simpleCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("simple", "'<expression>' [options]", stderr)
+	body := fs.String("body", "", "body of the message")
+	headers := keyValueFlag{}
+	properties := keyValueFlag{}
+	fs.Var(headers, "header", "message header as key=value (repeatable)")
+	fs.Var(properties, "property", "exchange property as key=value (repeatable)")
+	predicate := fs.Bool("predicate", false, "evaluate the expression as a boolean predicate")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	template, err := gocamel.ParseSimpleTemplate(positional[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "gocamel: %v\n", err)
+		return 1
+	}
+
+	exchange := gocamel.NewExchange(context.Background())
+	exchange.GetIn().SetBody(*body)
+	exchange.GetIn().SetHeaders(headers.toMap())
+	exchange.SetProperties(properties.toMap())
+
+	if *predicate {
+		result, err := template.EvaluateAsBool(exchange)
+		if err != nil {
+			fmt.Fprintf(stderr, "gocamel: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, result)
+		return 0
+	}
+
+	result, err := template.EvaluateAsString(exchange)
+	if err != nil {
+		fmt.Fprintf(stderr, "gocamel: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, result)
+	return 0
+}
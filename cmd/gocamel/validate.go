@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tranchida/gocamel"
+)
+
+// validateCommand charge les fichiers de routes dans un contexte jetable sans démarrer les routes
+func validateCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("validate", "<routes.yaml>...", stderr)
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	status := 0
+	for _, file := range files {
+		routes, err := gocamel.LoadRoutesYAMLFile(newContext(), file)
+		if err != nil {
+			fmt.Fprintf(stderr, "FAIL %v\n", err)
+			status = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "OK   %s (%d route(s))\n", file, len(routes))
+	}
+	return status
+}
@@ -12,20 +12,43 @@ type CamelContext struct {
 	cancel       context.CancelFunc
 	routes       []*Route
 	registry     *ComponentRegistry
+	inflight     *InflightRepository
+	claimCheck   ClaimCheckRepository
+	saga         SagaCoordinator
 	started      bool
 	startLock    sync.Mutex
 	routeCounter int
+
+	notifiers     []EventNotifier
+	notifiersLock sync.RWMutex
+
+	// fonctions appelées après le démarrage des routes (ex: tâche de récupération des agrégations)
+	startHooks []func()
+	// fonctions appelées à l'arrêt, avant l'annulation des échanges en attente
+	stopHooks []func()
+	// fonctions appelées après l'arrêt des routes (ex: arrêt des producteurs en cache des EIPs)
+	cleanupHooks []func()
+	hooksLock    sync.Mutex
+
+	// intercepteurs appliqués à toutes les routes, configurés avant le démarrage
+	interceptors     []*interceptor
+	fromInterceptors []*interceptor
+	sendInterceptors []*sendInterceptor
 }
 
 // NewCamelContext crée une nouvelle instance de CamelContext
 func NewCamelContext() *CamelContext {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &CamelContext{
-		ctx:      ctx,
-		cancel:   cancel,
-		routes:   make([]*Route, 0),
-		registry: NewComponentRegistry(),
+	c := &CamelContext{
+		ctx:        ctx,
+		cancel:     cancel,
+		routes:     make([]*Route, 0),
+		registry:   NewComponentRegistry(),
+		inflight:   NewInflightRepository(),
+		claimCheck: NewMemoryClaimCheckRepository(),
 	}
+	c.saga = NewMemorySagaCoordinator(c)
+	return c
 }
 
 // AddRoute ajoute une route au contexte
@@ -99,6 +122,13 @@ func (c *CamelContext) Start() error {
 	}
 
 	c.started = true
+
+	c.hooksLock.Lock()
+	hooks := c.startHooks
+	c.hooksLock.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
 	return nil
 }
 
@@ -111,18 +141,61 @@ func (c *CamelContext) Stop() error {
 		return nil
 	}
 
-	// Arrêt de toutes les routes
+	c.hooksLock.Lock()
+	hooks := c.stopHooks
+	c.hooksLock.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+
+	// Arrêt de toutes les routes ; les hooks d'arrêt ont déjà réveillé les échanges en attente
+	// (Delay, Throttle...), afin que l'arrêt des consommateurs qui attendent leurs échanges en
+	// cours ne bloque pas
+	var err error
 	for _, route := range c.routes {
-		if err := route.Stop(); err != nil {
-			return fmt.Errorf("erreur lors de l'arrêt de la route %s: %v", route.ID, err)
+		if err = route.Stop(); err != nil {
+			err = fmt.Errorf("erreur lors de l'arrêt de la route %s: %v", route.ID, err)
+			break
 		}
 	}
 
+	// Les producteurs en cache sont arrêtés même si une route n'a pas pu l'être
+	c.hooksLock.Lock()
+	hooks = c.cleanupHooks
+	c.hooksLock.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	if err != nil {
+		return err
+	}
+
 	c.cancel()
 	c.started = false
 	return nil
 }
 
+// addStartHook enregistre une fonction appelée après le démarrage des routes
+func (c *CamelContext) addStartHook(hook func()) {
+	c.hooksLock.Lock()
+	defer c.hooksLock.Unlock()
+	c.startHooks = append(c.startHooks, hook)
+}
+
+// addStopHook enregistre une fonction appelée à l'arrêt du contexte (ex: complétion forcée des agrégations)
+func (c *CamelContext) addStopHook(hook func()) {
+	c.hooksLock.Lock()
+	defer c.hooksLock.Unlock()
+	c.stopHooks = append(c.stopHooks, hook)
+}
+
+// addCleanupHook enregistre une fonction appelée après l'arrêt des routes
+func (c *CamelContext) addCleanupHook(hook func()) {
+	c.hooksLock.Lock()
+	defer c.hooksLock.Unlock()
+	c.cleanupHooks = append(c.cleanupHooks, hook)
+}
+
 // IsStarted vérifie si le contexte est démarré
 func (c *CamelContext) IsStarted() bool {
 	c.startLock.Lock()
@@ -140,6 +213,31 @@ func (c *CamelContext) GetComponentRegistry() *ComponentRegistry {
 	return c.registry
 }
 
+// GetInflightRepository récupère le registre des échanges en cours
+func (c *CamelContext) GetInflightRepository() *InflightRepository {
+	return c.inflight
+}
+
+// GetClaimCheckRepository récupère le repository utilisé par défaut par le Claim Check EIP
+func (c *CamelContext) GetClaimCheckRepository() ClaimCheckRepository {
+	return c.claimCheck
+}
+
+// SetClaimCheckRepository définit le repository utilisé par défaut par le Claim Check EIP
+func (c *CamelContext) SetClaimCheckRepository(repository ClaimCheckRepository) {
+	c.claimCheck = repository
+}
+
+// GetSagaCoordinator récupère le coordinateur utilisé par défaut par le Saga EIP
+func (c *CamelContext) GetSagaCoordinator() SagaCoordinator {
+	return c.saga
+}
+
+// SetSagaCoordinator définit le coordinateur utilisé par défaut par le Saga EIP
+func (c *CamelContext) SetSagaCoordinator(coordinator SagaCoordinator) {
+	c.saga = coordinator
+}
+
 // CreateRoute crée une nouvelle route dans ce contexte
 func (c *CamelContext) CreateRoute() *Route {
 	route := NewRoute()
@@ -208,3 +306,8 @@ func (c *CamelContext) GetStartedRouteCount() int {
 func (c *CamelContext) CreateEndpoint(uri string) (Endpoint, error) {
 	return c.registry.CreateEndpoint(uri)
 }
+
+// CreateProducerTemplate crée un ProducerTemplate permettant d'envoyer des messages vers n'importe quel endpoint
+func (c *CamelContext) CreateProducerTemplate() *ProducerTemplate {
+	return NewProducerTemplate(c)
+}
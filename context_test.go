@@ -4,6 +4,14 @@ import (
 	"testing"
 )
 
+// newTestContext crée un contexte avec les composants direct et mock utilisés par les tests des EIPs
+func newTestContext() *CamelContext {
+	camel := NewCamelContext()
+	camel.AddComponent("direct", NewDirectComponent())
+	camel.AddComponent("mock", NewMockComponent())
+	return camel
+}
+
 func TestDefaultRouteID(t *testing.T) {
 	ctx := NewCamelContext()
 
@@ -271,9 +271,11 @@ func (c *CronConsumer) startCronTrigger(ctx context.Context) error {
 		exchange.GetIn().SetHeader(CronTriggerGroup, ep.group)
 		exchange.GetIn().SetHeader(CronRefireCount, n)
 
-		if err := c.processor.Process(exchange); err != nil && !errors.Is(err, ErrStopRouting) {
+		err := c.processor.Process(exchange)
+		if err != nil && !errors.Is(err, ErrStopRouting) {
 			fmt.Printf("error during traitement  [%s/%s]: %v\n", ep.group, ep.name, err)
 		}
+		exchange.Done(err)
 
 		t := now
 		c.prevFireTime.Store(&t)
@@ -385,9 +387,11 @@ func (c *CronConsumer) fireSimpleJob(ctx, gCtx context.Context, now time.Time) {
 	exchange.GetIn().SetHeader(CronTriggerGroup, ep.group)
 	exchange.GetIn().SetHeader(CronRefireCount, n)
 
-	if err := c.processor.Process(exchange); err != nil && !errors.Is(err, ErrStopRouting) {
+	err := c.processor.Process(exchange)
+	if err != nil && !errors.Is(err, ErrStopRouting) {
 		fmt.Printf("error during traitement  [%s/%s]: %v\n", ep.group, ep.name, err)
 	}
+	exchange.Done(err)
 
 	t := now
 	c.prevFireTime.Store(&t)
@@ -0,0 +1,235 @@
+package gocamel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Delayer implémente le Delayer EIP : l'exécution du bloc est retardée de la durée calculée
+// pour chaque échange. En mode synchrone, l'appelant attend ; en mode asynchrone, l'échange
+// est réinjecté dans le bloc en arrière-plan à l'échéance.
+//
+// L'attente est interrompue par l'annulation du contexte de l'échange (en mode asynchrone,
+// par celle du CamelContext) et par l'arrêt du CamelContext, ce qui évite que
+// CamelContext.Stop() reste bloqué.
+type Delayer struct {
+	context *CamelContext
+	// Expression calcule le délai de l'échange
+	Expression func(*Exchange) (time.Duration, error)
+	// AsyncDelayed libère l'appelant : l'échange poursuit le bloc en arrière-plan à l'échéance
+	AsyncDelayed bool
+	processors   []Processor
+	async        *asyncDelayed
+	stop         *stopSignal
+}
+
+// NewDelayer crée un Delayer dont le délai est calculé par la fonction donnée
+func NewDelayer(context *CamelContext, expression func(*Exchange) (time.Duration, error)) *Delayer {
+	return &Delayer{
+		context:    context,
+		Expression: expression,
+		processors: make([]Processor, 0),
+		async:      &asyncDelayed{name: "Delayer"},
+		stop:       newStopSignal(context),
+	}
+}
+
+// AddProcessor ajoute un processeur exécuté une fois le délai écoulé
+func (d *Delayer) AddProcessor(processor Processor) {
+	d.processors = append(d.processors, processor)
+}
+
+// Process implémente l'interface Processor
+func (d *Delayer) Process(exchange *Exchange) error {
+	delay, err := d.Expression(exchange)
+	if err != nil {
+		return fmt.Errorf("delay expression error: %w", err)
+	}
+
+	if delay > 0 {
+		if d.AsyncDelayed {
+			d.async.processLater(d.context.GetContext(), exchange, func(delayed *Exchange) error {
+				return d.sleep(delayed, delay)
+			}, d.processBlock)
+			return ErrStopRouting
+		}
+		if err := d.sleep(exchange, delay); err != nil {
+			return err
+		}
+	}
+	return d.processBlock(exchange)
+}
+
+func (d *Delayer) processBlock(exchange *Exchange) error {
+	for _, p := range d.processors {
+		if err := p.Process(exchange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sleep attend la durée donnée, l'annulation du contexte de l'échange ou l'arrêt du CamelContext
+func (d *Delayer) sleep(exchange *Exchange, delay time.Duration) error {
+	return d.stop.wait(exchangeContext(exchange), delay)
+}
+
+// toDelay convertit la valeur d'une expression en délai : une durée, un nombre de millisecondes,
+// une durée Go ("1m30s"), ou un instant (time.Time ou RFC 3339) jusqu'auquel attendre.
+// Un délai négatif (instant déjà passé) est ramené à zéro.
+func toDelay(value any) (time.Duration, error) {
+	var delay time.Duration
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case time.Duration:
+		delay = v
+	case time.Time:
+		delay = time.Until(v)
+	case int:
+		delay = time.Duration(v) * time.Millisecond
+	case int32:
+		delay = time.Duration(v) * time.Millisecond
+	case int64:
+		delay = time.Duration(v) * time.Millisecond
+	case float64:
+		delay = time.Duration(v * float64(time.Millisecond))
+	case []byte:
+		return toDelay(string(v))
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0, nil
+		}
+		if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+			delay = time.Duration(ms) * time.Millisecond
+		} else if duration, err := time.ParseDuration(s); err == nil {
+			delay = duration
+		} else if instant, err := time.Parse(time.RFC3339, s); err == nil {
+			delay = time.Until(instant)
+		} else {
+			return 0, fmt.Errorf("invalid delay: %q", v)
+		}
+	default:
+		return 0, fmt.Errorf("unsupported delay type: %T", value)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, nil
+}
+
+// Delay commence un bloc Delayer EIP. Le délai est une constante (millisecondes ou durée Go,
+// ex: "500", "2s") ou une expression Simple (ex: "${header.delay}"), dont la valeur peut aussi
+// être un instant jusqu'auquel attendre. Sans appel à End(), le délai s'applique au reste de la route.
+func (b *RouteBuilder) Delay(expression string) *DelayDefinition {
+	if !strings.Contains(expression, "${") {
+		delay, err := toDelay(expression)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse delay: %v", err))
+		}
+		return b.DelayFunc(func(exchange *Exchange) (time.Duration, error) {
+			return delay, nil
+		})
+	}
+
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for delay: %v", err))
+	}
+	expr := simpleValueExpression(template)
+	return b.DelayFunc(func(exchange *Exchange) (time.Duration, error) {
+		value, err := expr(exchange)
+		if err != nil {
+			return 0, err
+		}
+		return toDelay(value)
+	})
+}
+
+// DelayFunc commence un bloc Delayer EIP dont le délai est calculé par une fonction Go
+func (b *RouteBuilder) DelayFunc(expression func(*Exchange) (time.Duration, error)) *DelayDefinition {
+	d := NewDelayer(b.context, expression)
+	b.container.AddProcessor(d)
+
+	return &DelayDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: d,
+		},
+		parent:  b,
+		delayer: d,
+	}
+}
+
+// DelayDefinition permet de configurer le Delayer et les processeurs exécutés après le délai
+type DelayDefinition struct {
+	*RouteBuilder
+	parent  *RouteBuilder
+	delayer *Delayer
+}
+
+// AsyncDelayed libère l'appelant : l'échange est réinjecté dans le bloc en arrière-plan à
+// l'échéance, et l'appelant reçoit ErrStopRouting. L'échange retardé exécute ensuite les étapes
+// ajoutées après End() au même niveau que le bloc.
+func (d *DelayDefinition) AsyncDelayed() *DelayDefinition {
+	d.delayer.AsyncDelayed = true
+	d.delayer.async.capture(d.parent)
+	return d
+}
+
+// Process ajoute un processeur et reste dans le contexte du delayer
+func (d *DelayDefinition) Process(processor Processor) *DelayDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte du delayer
+func (d *DelayDefinition) ProcessFunc(f func(*Exchange) error) *DelayDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte du delayer
+func (d *DelayDefinition) To(uris ...string) *DelayDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD ajoute un ou plusieurs endpoints dynamiques et reste dans le contexte du delayer
+func (d *DelayDefinition) ToD(uriTemplates ...string) *DelayDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody définit le corps du message de sortie et reste dans le contexte du delayer
+func (d *DelayDefinition) SetBody(body interface{}) *DelayDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte du delayer
+func (d *DelayDefinition) SetHeader(key string, value interface{}) *DelayDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetProperty définit une propriété de l'échange et reste dans le contexte du delayer
+func (d *DelayDefinition) SetProperty(key string, value any) *DelayDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte du delayer
+func (d *DelayDefinition) Log(message string) *DelayDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End termine le bloc Delay et revient au builder parent
+func (d *DelayDefinition) End() *RouteBuilder {
+	return d.parent
+}
@@ -0,0 +1,192 @@
+package gocamel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelay_Constant(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Delay("100").
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("a")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	started := time.Now()
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "a"))
+	assert.GreaterOrEqual(t, time.Since(started), 100*time.Millisecond)
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestDelay_HeaderExpression(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Delay("${header.sendAt}").
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("scheduled", "now")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	sendAt := time.Now().Add(100 * time.Millisecond)
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "scheduled", map[string]any{"sendAt": sendAt}))
+	assert.False(t, time.Now().Before(sendAt))
+
+	// Sans en-tête, le message n'est pas retardé
+	started := time.Now()
+	require.NoError(t, template.SendBody("direct:start", "now"))
+	assert.Less(t, time.Since(started), 50*time.Millisecond)
+
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestDelay_AsyncDelayed(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Delay("100ms").
+		AsyncDelayed().
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("a")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	sync := &countingSynchronization{}
+	exchange := NewExchange(context.Background())
+	exchange.GetIn().SetBody("a")
+	exchange.AddSynchronization(sync)
+
+	started := time.Now()
+	err := camel.CreateProducerTemplate().Send("direct:start", exchange)
+	assert.ErrorIs(t, err, ErrStopRouting)
+	assert.Less(t, time.Since(started), 50*time.Millisecond)
+	exchange.Done(err)
+	assert.Equal(t, int32(0), sync.completed.Load())
+
+	result.AssertIsSatisfied(t, time.Second)
+	assert.Eventually(t, func() bool { return sync.completed.Load() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestDelay_AsyncDelayedStepsAfterEnd(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Delay("50ms").
+		AsyncDelayed().
+		To("mock:result").
+		End().
+		To("mock:after").
+		Build()
+
+	after, _ := camel.GetMockEndpoint("mock:after")
+	after.ExpectedBodiesReceived("a")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// L'échange retardé doit atteindre les étapes situées après End()
+	err := camel.CreateProducerTemplate().SendBody("direct:start", "a")
+	assert.ErrorIs(t, err, ErrStopRouting)
+
+	after.AssertIsSatisfied(t, time.Second)
+}
+
+func TestDelay_StopInterruptsWaits(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:sync").
+		Delay("1m").
+		To("mock:result").
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:async").
+		Delay("1m").
+		AsyncDelayed().
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedMessageCount(0)
+
+	require.NoError(t, camel.Start())
+
+	template := camel.CreateProducerTemplate()
+	sync := &countingSynchronization{}
+	exchange := NewExchange(context.Background())
+	exchange.AddSynchronization(sync)
+	assert.ErrorIs(t, template.Send("direct:async", exchange), ErrStopRouting)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- template.SendBody("direct:sync", "a")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, camel.Stop())
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("synchronous delay was not interrupted by Stop")
+	}
+	assert.Eventually(t, func() bool { return sync.failed.Load() == 1 }, time.Second, 10*time.Millisecond)
+	result.AssertIsSatisfied(t, 0)
+	// Le contexte n'est annulé qu'une fois les routes arrêtées
+	assert.ErrorIs(t, camel.GetContext().Err(), context.Canceled)
+}
+
+func TestDelay_InvalidConstant(t *testing.T) {
+	camel := newTestContext()
+	assert.PanicsWithValue(t, `failed to parse delay: invalid delay: "soon"`, func() {
+		camel.CreateRouteBuilder().From("direct:start").Delay("soon")
+	})
+}
+
+func TestToDelay(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected time.Duration
+	}{
+		{nil, 0},
+		{250, 250 * time.Millisecond},
+		{int64(1000), time.Second},
+		{"1500", 1500 * time.Millisecond},
+		{[]byte("2s"), 2 * time.Second},
+		{3 * time.Second, 3 * time.Second},
+		{time.Now().Add(-time.Hour), 0},
+		{time.Now().Add(-time.Hour).Format(time.RFC3339), 0},
+	}
+	for _, test := range tests {
+		delay, err := toDelay(test.value)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, delay, "value %v", test.value)
+	}
+
+	_, err := toDelay(struct{}{})
+	assert.Error(t, err)
+}
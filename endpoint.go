@@ -2,6 +2,7 @@ package gocamel
 
 import (
 	"context"
+	"time"
 )
 
 // Endpoint represents an endpoint in a route
@@ -37,3 +38,25 @@ type Component interface {
 	// CreateEndpoint crée un nouvel endpoint à partir d'une URI
 	CreateEndpoint(uri string) (Endpoint, error)
 }
+
+// PollingConsumer représente un consommateur dont les messages sont récupérés à la demande
+// (Polling Consumer EIP) plutôt que poussés vers un processeur
+type PollingConsumer interface {
+	// Start démarre le consommateur
+	Start(ctx context.Context) error
+	// Stop arrête le consommateur
+	Stop() error
+	// Receive attend un message jusqu'à ce qu'il soit disponible ou que le consommateur soit arrêté
+	Receive() (*Exchange, error)
+	// ReceiveNoWait retourne un message immédiatement disponible, ou nil
+	ReceiveNoWait() (*Exchange, error)
+	// ReceiveTimeout attend un message au plus pendant la durée donnée ; retourne nil si aucun n'est arrivé
+	ReceiveTimeout(timeout time.Duration) (*Exchange, error)
+}
+
+// PollingConsumerEndpoint est implémenté par les endpoints qui savent créer nativement un
+// PollingConsumer. Les autres endpoints sont adaptés via EventDrivenPollingConsumer.
+type PollingConsumerEndpoint interface {
+	// CreatePollingConsumer crée un consommateur interrogé à la demande
+	CreatePollingConsumer() (PollingConsumer, error)
+}
@@ -0,0 +1,167 @@
+package gocamel
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Enricher est un Processor qui implémente le Content Enricher EIP (enrich).
+// Une copie de l'échange est envoyée au producteur de l'URI (http, sql, mongodb...) et la
+// réponse est fusionnée dans l'échange courant via une AggregationStrategy.
+// Sans stratégie, la réponse remplace le message courant.
+type Enricher struct {
+	uri      string
+	strategy AggregationStrategy
+	cache    *producerCache
+}
+
+// NewEnricher crée un nouvel Enricher
+func NewEnricher(context *CamelContext, uri string, strategy AggregationStrategy) *Enricher {
+	return &Enricher{
+		uri:      uri,
+		strategy: strategy,
+		cache:    newRouteProducerCache(context),
+	}
+}
+
+// Process implémente l'interface Processor
+func (e *Enricher) Process(exchange *Exchange) error {
+	propagateOut(exchange)
+
+	resource := exchange.Copy()
+	if _, err := e.cache.send(resource, e.uri, false); err != nil {
+		return fmt.Errorf("enrich %s: %w", e.uri, err)
+	}
+	propagateOut(resource)
+
+	mergeEnrichment(exchange, resource, e.strategy)
+	return nil
+}
+
+// PollEnricher est un Processor qui implémente le Content Enricher EIP (pollEnrich).
+// Un seul message est récupéré à la demande depuis un endpoint consommateur (file, ftp, sftp,
+// imap...) puis fusionné dans l'échange courant via une AggregationStrategy.
+//
+// Le timeout détermine l'attente : négatif, on attend un message indéfiniment ; zéro, on ne
+// prend que ce qui est immédiatement disponible ; positif, on attend au plus cette durée.
+// Si aucun message n'est récupéré, l'échange courant est laissé inchangé.
+type PollEnricher struct {
+	context  *CamelContext
+	uri      string
+	timeout  time.Duration
+	strategy AggregationStrategy
+
+	consumer PollingConsumer
+	mu       sync.Mutex
+}
+
+// NewPollEnricher crée un nouveau PollEnricher
+func NewPollEnricher(context *CamelContext, uri string, timeout time.Duration, strategy AggregationStrategy) *PollEnricher {
+	p := &PollEnricher{
+		context:  context,
+		uri:      uri,
+		timeout:  timeout,
+		strategy: strategy,
+	}
+	context.addCleanupHook(func() { p.Stop() })
+	return p
+}
+
+// Process implémente l'interface Processor
+func (p *PollEnricher) Process(exchange *Exchange) error {
+	propagateOut(exchange)
+
+	consumer, err := p.pollingConsumer()
+	if err != nil {
+		return fmt.Errorf("pollEnrich %s: %w", p.uri, err)
+	}
+
+	var resource *Exchange
+	switch {
+	case p.timeout < 0:
+		resource, err = consumer.Receive()
+	case p.timeout == 0:
+		resource, err = consumer.ReceiveNoWait()
+	default:
+		resource, err = consumer.ReceiveTimeout(p.timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("pollEnrich %s: %w", p.uri, err)
+	}
+	if resource == nil {
+		return nil
+	}
+
+	mergeEnrichment(exchange, resource, p.strategy)
+	// Le post-traitement du consommateur (suppression, déplacement du fichier...) n'a lieu qu'à
+	// la fin de l'échange enrichi, selon son résultat : les données ne sont pas perdues s'il échoue
+	resource.handoverTo(exchange)
+	return nil
+}
+
+// pollingConsumer crée et démarre le consommateur au premier appel, puis le réutilise
+func (p *PollEnricher) pollingConsumer() (PollingConsumer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.consumer != nil {
+		return p.consumer, nil
+	}
+	endpoint, err := p.context.CreateEndpoint(p.uri)
+	if err != nil {
+		return nil, err
+	}
+	consumer, err := CreatePollingConsumer(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := consumer.Start(p.context.GetContext()); err != nil {
+		return nil, err
+	}
+	p.consumer = consumer
+	return consumer, nil
+}
+
+// Stop arrête le consommateur utilisé par le PollEnricher
+func (p *PollEnricher) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.consumer == nil {
+		return nil
+	}
+	err := p.consumer.Stop()
+	p.consumer = nil
+	return err
+}
+
+// mergeEnrichment fusionne la ressource dans l'échange courant
+func mergeEnrichment(exchange, resource *Exchange, strategy AggregationStrategy) {
+	if strategy == nil {
+		exchange.In = resource.In
+		exchange.Out = NewMessage()
+		return
+	}
+
+	result := strategy.Aggregate(exchange, resource)
+	if result != nil && result != exchange {
+		exchange.In = result.In
+		exchange.Out = result.Out
+		exchange.Properties = result.Properties
+	}
+}
+
+// Enrich ajoute un Content Enricher qui appelle le producteur de l'URI et fusionne sa réponse
+// dans l'échange courant. Sans stratégie (nil), la réponse remplace le message.
+func (b *RouteBuilder) Enrich(uri string, strategy AggregationStrategy) *RouteBuilder {
+	b.container.AddProcessor(NewEnricher(b.context, uri, strategy))
+	return b
+}
+
+// PollEnrich ajoute un Content Enricher qui récupère un message depuis l'endpoint consommateur
+// de l'URI. Un timeout négatif attend indéfiniment, zéro n'attend pas.
+func (b *RouteBuilder) PollEnrich(uri string, timeout time.Duration, strategy AggregationStrategy) *RouteBuilder {
+	b.container.AddProcessor(NewPollEnricher(b.context, uri, timeout, strategy))
+	return b
+}
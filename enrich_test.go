@@ -0,0 +1,254 @@
+package gocamel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mergeBodies ajoute le corps de la ressource au corps courant
+var mergeBodies = AggregationStrategyFunc(func(original, resource *Exchange) *Exchange {
+	body, _ := original.GetIn().GetBodyAsString()
+	original.GetIn().SetBody(fmt.Sprintf("%s+%s", body, resource.GetIn().GetBody()))
+	return original
+})
+
+func TestEnrich(t *testing.T) {
+	camel := newTestContext()
+
+	camel.CreateRouteBuilder().
+		From("direct:price").
+		ProcessFunc(func(e *Exchange) error {
+			sku, _ := e.GetIn().GetHeaderAsString("sku")
+			e.GetOut().SetBody("price(" + sku + ")")
+			return nil
+		}).
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:merge").
+		Enrich("direct:price", mergeBodies).
+		To("mock:merged").
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:replace").
+		Enrich("direct:price", nil).
+		To("mock:replaced").
+		Build()
+
+	merged, _ := camel.GetMockEndpoint("mock:merged")
+	merged.ExpectedBodiesReceived("order+price(A1)")
+	merged.ExpectedHeaderReceived("sku", "A1")
+	replaced, _ := camel.GetMockEndpoint("mock:replaced")
+	replaced.ExpectedBodiesReceived("price(B2)")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:merge", "order", map[string]any{"sku": "A1"}))
+	require.NoError(t, template.SendBodyAndHeaders("direct:replace", "order", map[string]any{"sku": "B2"}))
+
+	merged.AssertIsSatisfied(t, time.Second)
+	replaced.AssertIsSatisfied(t, time.Second)
+}
+
+func TestEnrich_Error(t *testing.T) {
+	camel := NewCamelContext()
+	camel.AddComponent("direct", NewDirectComponent())
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Enrich("unknown:resource", nil).
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	assert.ErrorContains(t, camel.CreateProducerTemplate().SendBody("direct:start", "x"), "unknown:resource")
+}
+
+func TestPollEnrich_File(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0644))
+
+	camel := NewCamelContext()
+	camel.AddComponent("direct", NewDirectComponent())
+	camel.AddComponent("file", NewFileComponent())
+	camel.AddComponent("mock", NewMockComponent())
+
+	uri := "file://" + dir + "?delete=true&delay=10ms"
+	camel.CreateRouteBuilder().
+		From("direct:now").
+		PollEnrich(uri, 0, mergeBodies).
+		To("mock:result").
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:wait").
+		PollEnrich(uri, time.Second, nil).
+		To("mock:result").
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("order+A", "order", "B")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	// Le fichier déjà présent est lu puis supprimé
+	require.NoError(t, template.SendBody("direct:now", "order"))
+	_, err := os.Stat(filepath.Join(dir, "a.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Aucun fichier disponible : l'échange n'est pas modifié
+	require.NoError(t, template.SendBody("direct:now", "order"))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.WriteFile(filepath.Join(dir, "b.txt"), []byte("B"), 0644)
+	}()
+	require.NoError(t, template.SendBody("direct:wait", "order"))
+
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestPollEnrich_FileKeptWhenRouteFails(t *testing.T) {
+	dir := t.TempDir()
+	failed := filepath.Join(dir, "failed")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0644))
+
+	camel := newTestContext()
+	camel.AddComponent("file", NewFileComponent())
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		PollEnrich("file://"+dir+"?delete=true&moveFailed="+failed+"&delay=10ms", 0, mergeBodies).
+		ProcessFunc(func(e *Exchange) error {
+			// Le fichier n'est pas encore supprimé tant que l'échange enrichi n'est pas terminé
+			_, err := os.Stat(filepath.Join(dir, "a.txt"))
+			assert.NoError(t, err)
+			return fmt.Errorf("downstream failure")
+		}).
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// L'échec de la route principale applique le post-traitement d'échec du consommateur
+	assert.Error(t, camel.CreateProducerTemplate().SendBody("direct:start", "order"))
+	_, err := os.Stat(filepath.Join(failed, "a.txt"))
+	assert.NoError(t, err)
+}
+
+func TestFilePollingConsumer_NoopRemembersFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("A"), 0644))
+
+	endpoint, err := NewFileComponent().CreateEndpoint("file://" + dir + "?noop=true")
+	require.NoError(t, err)
+	consumer, err := CreatePollingConsumer(endpoint)
+	require.NoError(t, err)
+
+	_, err = consumer.ReceiveNoWait()
+	assert.ErrorIs(t, err, ErrPollingConsumerStopped)
+
+	require.NoError(t, consumer.Start(context.Background()))
+	exchange, err := consumer.ReceiveNoWait()
+	require.NoError(t, err)
+	require.NotNil(t, exchange)
+	name, _ := exchange.GetHeaderAsString("FileName")
+	assert.Equal(t, "a.txt", name)
+	exchange.Done(nil)
+
+	exchange, err = consumer.ReceiveTimeout(20 * time.Millisecond)
+	require.NoError(t, err)
+	assert.Nil(t, exchange)
+
+	require.NoError(t, consumer.Stop())
+	_, err = consumer.Receive()
+	assert.ErrorIs(t, err, ErrPollingConsumerStopped)
+}
+
+func TestEnrich_ProducerStoppedWithContext(t *testing.T) {
+	camel := NewCamelContext()
+	camel.AddComponent("direct", NewDirectComponent())
+	tracking := &trackingComponent{}
+	camel.AddComponent("tracking", tracking)
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Enrich("tracking:prices", nil).
+		Build()
+
+	require.NoError(t, camel.Start())
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "order"))
+	assert.Equal(t, int32(1), tracking.started.Load())
+
+	require.NoError(t, camel.Stop())
+	assert.Equal(t, int32(0), tracking.started.Load())
+}
+
+func TestFilePollingConsumer_WaitsForCompleteFiles(t *testing.T) {
+	dir := t.TempDir()
+	endpoint, err := NewFileComponent().CreateEndpoint("file://" + dir + "?delete=true&delay=5ms&readLockCheckInterval=50ms")
+	require.NoError(t, err)
+	consumer, err := CreatePollingConsumer(endpoint)
+	require.NoError(t, err)
+	require.NoError(t, consumer.Start(context.Background()))
+	defer consumer.Stop()
+
+	// Le fichier est créé vide puis écrit en plusieurs fois
+	path := filepath.Join(dir, "order.txt")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	go func() {
+		defer file.Close()
+		for _, part := range []string{"part1,", "part2,", "part3"} {
+			time.Sleep(20 * time.Millisecond)
+			file.WriteString(part)
+		}
+	}()
+
+	exchange, err := consumer.ReceiveTimeout(2 * time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, exchange)
+	assert.Equal(t, []byte("part1,part2,part3"), exchange.GetIn().GetBody())
+	exchange.Done(nil)
+
+	// Le fichier supprimé est oublié
+	_, err = consumer.ReceiveNoWait()
+	require.NoError(t, err)
+	assert.Empty(t, consumer.(*FilePollingConsumer).seen)
+}
+
+func TestEventDrivenPollingConsumer_Timer(t *testing.T) {
+	endpoint, err := NewTimerComponent().CreateEndpoint("timer:tick?delay=1&period=5")
+	require.NoError(t, err)
+	consumer, err := CreatePollingConsumer(endpoint)
+	require.NoError(t, err)
+	require.IsType(t, &EventDrivenPollingConsumer{}, consumer)
+
+	require.NoError(t, consumer.Start(context.Background()))
+
+	for i := 0; i < 2; i++ {
+		exchange, err := consumer.ReceiveTimeout(time.Second)
+		require.NoError(t, err)
+		assert.NotNil(t, exchange)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		done <- consumer.Stop()
+	}()
+	for {
+		if _, err := consumer.Receive(); err != nil {
+			assert.ErrorIs(t, err, ErrPollingConsumerStopped)
+			break
+		}
+	}
+	require.NoError(t, <-done)
+}
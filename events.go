@@ -0,0 +1,84 @@
+package gocamel
+
+import (
+	"time"
+)
+
+// ExchangeEventType identifie le type d'un événement d'échange
+type ExchangeEventType int
+
+const (
+	// ExchangeCompletedEvent est émis lorsqu'un échange a été traité avec succès par une route
+	ExchangeCompletedEvent ExchangeEventType = iota
+	// ExchangeFailedEvent est émis lorsqu'une route retourne une erreur pour un échange
+	ExchangeFailedEvent
+)
+
+// String retourne le nom du type d'événement
+func (t ExchangeEventType) String() string {
+	switch t {
+	case ExchangeCompletedEvent:
+		return "ExchangeCompleted"
+	case ExchangeFailedEvent:
+		return "ExchangeFailed"
+	default:
+		return "Unknown"
+	}
+}
+
+// ExchangeEvent décrit la fin du traitement d'un échange par une route
+type ExchangeEvent struct {
+	Type      ExchangeEventType
+	Exchange  *Exchange
+	RouteID   string
+	Error     error
+	Elapsed   time.Duration
+	Timestamp time.Time
+	// Nested indique que la route a été appelée depuis une autre route (ex: via direct:)
+	Nested bool
+}
+
+// EventNotifier reçoit les événements émis par le CamelContext.
+// Notify est appelé de manière synchrone par la route et doit donc rester rapide.
+type EventNotifier interface {
+	Notify(event ExchangeEvent)
+}
+
+// EventNotifierFunc est un type de fonction qui implémente l'interface EventNotifier
+type EventNotifierFunc func(event ExchangeEvent)
+
+// Notify implémente l'interface EventNotifier pour EventNotifierFunc
+func (f EventNotifierFunc) Notify(event ExchangeEvent) {
+	f(event)
+}
+
+// AddEventNotifier enregistre un EventNotifier sur le contexte
+func (c *CamelContext) AddEventNotifier(notifier EventNotifier) {
+	c.notifiersLock.Lock()
+	defer c.notifiersLock.Unlock()
+	c.notifiers = append(c.notifiers, notifier)
+}
+
+// RemoveEventNotifier retire un EventNotifier du contexte.
+// Le notifier doit être comparable (typiquement un pointeur).
+func (c *CamelContext) RemoveEventNotifier(notifier EventNotifier) {
+	c.notifiersLock.Lock()
+	defer c.notifiersLock.Unlock()
+	for i, n := range c.notifiers {
+		if n == notifier {
+			c.notifiers = append(c.notifiers[:i:i], c.notifiers[i+1:]...)
+			return
+		}
+	}
+}
+
+// notify diffuse un événement à tous les notifiers enregistrés
+func (c *CamelContext) notify(event ExchangeEvent) {
+	c.notifiersLock.RLock()
+	notifiers := c.notifiers
+	c.notifiersLock.RUnlock()
+
+	for _, notifier := range notifiers {
+		notifier.Notify(event)
+	}
+}
@@ -75,6 +75,7 @@ func main() {
 			fmt.Printf("=== Agrégation complète (fichier XML : %s) ===\n%s\n", name, xmlContent)
 			return nil
 		}).
+		End().
 		Build()
 
 	camelCtx.AddRoute(route)
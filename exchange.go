@@ -35,9 +35,11 @@ const (
 	CamelRouteDescription = "CamelRouteDescription" // Description de la route
 
 	// Propriétés d'erreur
-	CamelExceptionCaught = "CamelExceptionCaught" // Exception capturée
-	CamelFailureEndpoint = "CamelFailureEndpoint" // Endpoint en échec
-	CamelFailureRouteId  = "CamelFailureRouteId"  // ID de la route en échec
+	CamelExceptionCaught   = "CamelExceptionCaught"   // Exception capturée
+	CamelFailureEndpoint   = "CamelFailureEndpoint"   // Endpoint en échec
+	CamelFailureRouteId    = "CamelFailureRouteId"    // ID de la route en échec
+	CamelRedelivered       = "CamelRedelivered"       // Échange redélivré
+	CamelRedeliveryCounter = "CamelRedeliveryCounter" // Nombre de redélivrances
 
 	// Propriétés de performance
 	CamelTimerName      = "CamelTimerName"      // Nom du timer
@@ -71,6 +73,7 @@ type Synchronization interface {
 
 // Exchange represents the exchange context of a message in a route
 type Exchange struct {
+	ID               string
 	Context          context.Context
 	In               *Message
 	Out              *Message
@@ -79,12 +82,17 @@ type Exchange struct {
 	Modified         time.Time
 	Error            error
 	synchronizations []Synchronization
+	// route est la route en train de traiter l'échange
+	route *Route
+	// intercepting est vrai pendant l'exécution d'un intercepteur
+	intercepting bool
 }
 
 // NewExchange creates a new Exchange instance
 func NewExchange(ctx context.Context) *Exchange {
 	now := time.Now()
 	return &Exchange{
+		ID:               generateUUID(),
 		Context:          ctx,
 		In:               NewMessage(),
 		Out:              NewMessage(),
@@ -113,6 +121,24 @@ func (e *Exchange) Done(err error) {
 	}
 }
 
+// handover transfère l'échange vers un traitement asynchrone : la copie retournée conserve
+// l'identifiant et reprend les synchronisations, que l'échange d'origine abandonne. C'est donc
+// l'appel à Done sur la copie, une fois le traitement asynchrone terminé, qui les déclenche.
+func (e *Exchange) handover(ctx context.Context) *Exchange {
+	copy := e.Copy()
+	copy.ID = e.ID
+	copy.Context = ctx
+	e.handoverTo(copy)
+	return copy
+}
+
+// handoverTo transfère les synchronisations de l'échange à un autre échange : elles sont
+// déclenchées par l'appel à Done sur ce dernier, avec son résultat.
+func (e *Exchange) handoverTo(target *Exchange) {
+	target.synchronizations = append(target.synchronizations, e.synchronizations...)
+	e.synchronizations = make([]Synchronization, 0)
+}
+
 // GetIn récupère le message d'entrée
 func (e *Exchange) GetIn() *Message {
 	return e.In
@@ -371,6 +397,8 @@ func (e *Exchange) Copy() *Exchange {
 	copy.Created = e.Created
 	copy.Modified = time.Now()
 	copy.Error = e.Error
+	copy.route = e.route
+	copy.intercepting = e.intercepting
 
 	return copy
 }
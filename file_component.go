@@ -7,7 +7,10 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
@@ -101,6 +104,48 @@ func (e *FileEndpoint) CreateConsumer(processor Processor) (Consumer, error) {
 	}, nil
 }
 
+// CreatePollingConsumer crée un consommateur File interrogé à la demande (pollEnrich).
+// Contrairement au consommateur classique, il lit aussi les fichiers déjà présents.
+func (e *FileEndpoint) CreatePollingConsumer() (PollingConsumer, error) {
+	delay := 500 * time.Millisecond
+	if s := GetConfigValue(e.url, "delay"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d > 0 {
+			delay = d
+		}
+	}
+	readLockCheckInterval := defaultReadLockCheckInterval
+	if s := GetConfigValue(e.url, "readLockCheckInterval"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+			readLockCheckInterval = d
+		}
+	}
+	readLockMinLength := int64(1)
+	if s := GetConfigValue(e.url, "readLockMinLength"); s != "" {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && n >= 0 {
+			readLockMinLength = n
+		}
+	}
+	return &FilePollingConsumer{
+		path:       e.path,
+		include:    GetConfigValue(e.url, "include"),
+		exclude:    GetConfigValue(e.url, "exclude"),
+		noop:       strings.EqualFold(GetConfigValue(e.url, "noop"), "true"),
+		delete:     strings.EqualFold(GetConfigValue(e.url, "delete"), "true"),
+		move:       GetConfigValue(e.url, "move"),
+		moveFailed: GetConfigValue(e.url, "moveFailed"),
+		recursive:  strings.EqualFold(GetConfigValue(e.url, "recursive"), "true"),
+		delay:      delay,
+
+		readLockCheckInterval: readLockCheckInterval,
+		readLockMinLength:     readLockMinLength,
+		seen:                  make(map[string]time.Time),
+	}, nil
+}
+
+// defaultReadLockCheckInterval est la durée pendant laquelle un fichier ne doit pas avoir été
+// modifié pour être lu par un FilePollingConsumer
+const defaultReadLockCheckInterval = 100 * time.Millisecond
+
 // FileProducer represents a producteur File
 type FileProducer struct {
 	path      string
@@ -355,6 +400,216 @@ func (c *FileConsumer) Stop() error {
 	return nil
 }
 
+// FilePollingConsumer lit un fichier par appel à Receive. Le post-traitement (noop, delete,
+// move, moveFailed) est appliqué lorsque l'appelant termine l'échange avec Done. Un fichier déjà
+// remis n'est plus relu tant qu'il n'a pas été modifié.
+//
+// Un fichier en cours d'écriture n'est pas lu : sa taille doit atteindre readLockMinLength
+// (1 octet par défaut) et ni sa taille ni sa date de modification ne doivent changer pendant
+// readLockCheckInterval (100ms par défaut).
+type FilePollingConsumer struct {
+	path       string
+	include    string
+	exclude    string
+	noop       bool
+	delete     bool
+	move       string
+	moveFailed string
+	recursive  bool
+	delay      time.Duration
+
+	readLockCheckInterval time.Duration
+	readLockMinLength     int64
+
+	ctx  context.Context
+	stop chan struct{}
+	seen map[string]time.Time
+	mu   sync.Mutex
+}
+
+// Start démarre le consommateur
+func (c *FilePollingConsumer) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ctx = ctx
+	c.stop = make(chan struct{})
+	return nil
+}
+
+// Stop arrête le consommateur et débloque les appels Receive en cours
+func (c *FilePollingConsumer) Stop() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	return nil
+}
+
+// Receive attend qu'un fichier soit disponible
+func (c *FilePollingConsumer) Receive() (*Exchange, error) {
+	return c.receive(nil)
+}
+
+// ReceiveNoWait retourne le prochain fichier disponible, ou nil
+func (c *FilePollingConsumer) ReceiveNoWait() (*Exchange, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop == nil {
+		return nil, ErrPollingConsumerStopped
+	}
+	return c.scan()
+}
+
+// ReceiveTimeout attend un fichier au plus pendant la durée donnée
+func (c *FilePollingConsumer) ReceiveTimeout(timeout time.Duration) (*Exchange, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	return c.receive(timer.C)
+}
+
+func (c *FilePollingConsumer) receive(timeout <-chan time.Time) (*Exchange, error) {
+	ticker := time.NewTicker(c.delay)
+	defer ticker.Stop()
+	for {
+		c.mu.Lock()
+		stop := c.stop
+		if stop == nil {
+			c.mu.Unlock()
+			return nil, ErrPollingConsumerStopped
+		}
+		exchange, err := c.scan()
+		c.mu.Unlock()
+		if exchange != nil || err != nil {
+			return exchange, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timeout:
+			return nil, nil
+		case <-stop:
+			return nil, ErrPollingConsumerStopped
+		}
+	}
+}
+
+// scan retourne le premier fichier pas encore remis, par ordre de nom
+func (c *FilePollingConsumer) scan() (*Exchange, error) {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error during l'accès au path: %v", err)
+	}
+
+	var candidates []string
+	if !info.IsDir() {
+		candidates = []string{c.path}
+	} else {
+		filepath.WalkDir(c.path, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != c.path && !c.recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			candidates = append(candidates, path)
+			return nil
+		})
+	}
+
+	// Les fichiers disparus (supprimés, déplacés) sont oubliés
+	present := make(map[string]bool, len(candidates))
+	for _, path := range candidates {
+		present[path] = true
+	}
+	for path := range c.seen {
+		if !present[path] {
+			delete(c.seen, path)
+		}
+	}
+
+	for _, path := range candidates {
+		filename := filepath.Base(path)
+		if !matchFileName(filename, c.include, c.exclude) {
+			continue
+		}
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if modTime, seen := c.seen[path]; seen && modTime.Equal(fileInfo.ModTime()) {
+			continue
+		}
+		if fileInfo, err = c.stableFile(path, fileInfo); err != nil || fileInfo == nil {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error during la reading du file %s: %v", path, err)
+		}
+		c.seen[path] = fileInfo.ModTime()
+
+		ctx := c.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		exchange := NewExchange(ctx)
+		exchange.SetBody(content)
+		exchange.SetHeader("FileName", filename)
+		exchange.SetHeader("FilePath", path)
+		exchange.AddSynchronization(&fileSynchronization{
+			path:       path,
+			noop:       c.noop,
+			delete:     c.delete,
+			move:       c.move,
+			moveFailed: c.moveFailed,
+		})
+		return exchange, nil
+	}
+	return nil, nil
+}
+
+// stableFile vérifie qu'un fichier n'est plus en cours d'écriture et retourne ses informations
+// à jour, ou nil. Un fichier modifié depuis moins de readLockCheckInterval est réexaminé à la fin
+// de cet intervalle ; il doit alors avoir la même taille et la même date de modification.
+func (c *FilePollingConsumer) stableFile(path string, info os.FileInfo) (os.FileInfo, error) {
+	if info.Size() < c.readLockMinLength {
+		return nil, nil
+	}
+	wait := c.readLockCheckInterval - time.Since(info.ModTime())
+	if wait <= 0 {
+		return info, nil
+	}
+
+	var done <-chan struct{}
+	if c.ctx != nil {
+		done = c.ctx.Done()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-done:
+		return nil, c.ctx.Err()
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if current.Size() != info.Size() || !current.ModTime().Equal(info.ModTime()) {
+		// Toujours en cours d'écriture : le fichier sera réexaminé au prochain passage
+		return nil, nil
+	}
+	return current, nil
+}
+
 // moveFilelocal déplace src vers destDir en créant le directory si nécessaire.
 func moveFilelocal(src, destDir string) {
 	if err := os.MkdirAll(destDir, 0755); err != nil {
@@ -0,0 +1,132 @@
+package gocamel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileIdempotentRepository is a file-based implementation of IdempotentRepository.
+// Keys are stored one per line, so they survive restarts. The file is loaded when the
+// repository is created; new keys are appended, removals rewrite the file.
+type FileIdempotentRepository struct {
+	mu   sync.Mutex
+	path string
+	keys map[string]struct{}
+}
+
+// NewFileIdempotentRepository creates a new FileIdempotentRepository backed by the given file.
+// The file and its directory are created if they don't exist.
+func NewFileIdempotentRepository(path string) (*FileIdempotentRepository, error) {
+	r := &FileIdempotentRepository{
+		path: path,
+		keys: make(map[string]struct{}),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *FileIdempotentRepository) load() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create idempotent repository directory: %w", err)
+	}
+	file, err := os.OpenFile(r.path, os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open idempotent repository file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if key := scanner.Text(); key != "" {
+			r.keys[key] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read idempotent repository file: %w", err)
+	}
+	return nil
+}
+
+// Add adds the key to the repository. Returns false if the key was already present.
+func (r *FileIdempotentRepository) Add(ctx context.Context, key string) (bool, error) {
+	if strings.ContainsAny(key, "\r\n") {
+		return false, fmt.Errorf("idempotent key must not contain line breaks: %q", key)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.keys[key]; exists {
+		return false, nil
+	}
+
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open idempotent repository file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(key + "\n"); err != nil {
+		return false, fmt.Errorf("failed to write idempotent key: %w", err)
+	}
+
+	r.keys[key] = struct{}{}
+	return true, nil
+}
+
+// Contains returns true if the key is present in the repository.
+func (r *FileIdempotentRepository) Contains(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := r.keys[key]
+	return exists, nil
+}
+
+// Remove removes the key from the repository.
+func (r *FileIdempotentRepository) Remove(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.keys[key]; !exists {
+		return nil
+	}
+	delete(r.keys, key)
+	return r.rewrite()
+}
+
+// Confirm is a no-op: keys are written as soon as they are added.
+func (r *FileIdempotentRepository) Confirm(ctx context.Context, key string) error {
+	return nil
+}
+
+// Clear removes all the keys from the repository.
+func (r *FileIdempotentRepository) Clear(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = make(map[string]struct{})
+	return r.rewrite()
+}
+
+// rewrite replaces the file content with the current keys, through a temporary file.
+func (r *FileIdempotentRepository) rewrite() error {
+	tmp := r.path + ".tmp"
+	var content strings.Builder
+	for key := range r.keys {
+		content.WriteString(key)
+		content.WriteString("\n")
+	}
+	if err := os.WriteFile(tmp, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write idempotent repository file: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to replace idempotent repository file: %w", err)
+	}
+	return nil
+}
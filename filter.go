@@ -0,0 +1,220 @@
+package gocamel
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// CamelFilterMatched est la propriété indiquant si l'échange a satisfait le prédicat du filtre
+const CamelFilterMatched = "CamelFilterMatched"
+
+// FilterStats contient les compteurs d'un filtre
+type FilterStats struct {
+	// Passed est le nombre d'échanges ayant satisfait le prédicat
+	Passed int64
+	// Filtered est le nombre d'échanges écartés
+	Filtered int64
+}
+
+// FilterProcessor implémente le Message Filter EIP.
+// Les processeurs du bloc ne sont exécutés que si le prédicat est satisfait ; dans tous les cas
+// l'échange poursuit ensuite la route après le bloc.
+type FilterProcessor struct {
+	Expression string
+	template   *SimpleTemplate
+	predicate  func(*Exchange) bool
+	processors []Processor
+
+	passed   atomic.Int64
+	filtered atomic.Int64
+}
+
+// NewFilterProcessor crée un filtre à partir d'un prédicat Simple (ex: "${header.active == true}")
+func NewFilterProcessor(expression string) (*FilterProcessor, error) {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterProcessor{
+		Expression: expression,
+		template:   template,
+		processors: make([]Processor, 0),
+	}, nil
+}
+
+// NewFilterProcessorFunc crée un filtre à partir d'un prédicat Go
+func NewFilterProcessorFunc(predicate func(*Exchange) bool) *FilterProcessor {
+	return &FilterProcessor{
+		predicate:  predicate,
+		processors: make([]Processor, 0),
+	}
+}
+
+// AddProcessor ajoute un processeur exécuté lorsque le prédicat est satisfait
+func (f *FilterProcessor) AddProcessor(processor Processor) {
+	f.processors = append(f.processors, processor)
+}
+
+// Stats retourne les compteurs du filtre
+func (f *FilterProcessor) Stats() FilterStats {
+	return FilterStats{
+		Passed:   f.passed.Load(),
+		Filtered: f.filtered.Load(),
+	}
+}
+
+// Process implémente l'interface Processor
+func (f *FilterProcessor) Process(exchange *Exchange) error {
+	matched, err := f.matches(exchange)
+	if err != nil {
+		return err
+	}
+
+	exchange.SetProperty(CamelFilterMatched, matched)
+	if !matched {
+		f.filtered.Add(1)
+		return nil
+	}
+	f.passed.Add(1)
+
+	for _, p := range f.processors {
+		if err := p.Process(exchange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FilterProcessor) matches(exchange *Exchange) (bool, error) {
+	if f.predicate != nil {
+		return f.predicate(exchange), nil
+	}
+	result, err := f.template.EvaluateAsBool(exchange)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate filter expression '%s': %w", f.Expression, err)
+	}
+	return result, nil
+}
+
+// Filter commence un bloc Filter EIP dont le prédicat est une expression Simple
+func (b *RouteBuilder) Filter(expression string) *FilterDefinition {
+	f, err := NewFilterProcessor(expression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for filter: %v", err))
+	}
+	return b.addFilter(f)
+}
+
+// FilterFunc commence un bloc Filter EIP dont le prédicat est une fonction Go
+func (b *RouteBuilder) FilterFunc(predicate func(*Exchange) bool) *FilterDefinition {
+	return b.addFilter(NewFilterProcessorFunc(predicate))
+}
+
+func (b *RouteBuilder) addFilter(f *FilterProcessor) *FilterDefinition {
+	b.container.AddProcessor(f)
+
+	return &FilterDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: f,
+		},
+		parent: b,
+		filter: f,
+	}
+}
+
+// FilterDefinition permet de configurer les processeurs exécutés lorsque le prédicat est satisfait
+type FilterDefinition struct {
+	*RouteBuilder
+	parent *RouteBuilder
+	filter *FilterProcessor
+}
+
+// Stats retourne les compteurs du filtre
+func (d *FilterDefinition) Stats() FilterStats {
+	return d.filter.Stats()
+}
+
+// Process ajoute un processeur et reste dans le contexte du filtre
+func (d *FilterDefinition) Process(processor Processor) *FilterDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte du filtre
+func (d *FilterDefinition) ProcessFunc(f func(*Exchange) error) *FilterDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte du filtre
+func (d *FilterDefinition) To(uris ...string) *FilterDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD ajoute un ou plusieurs endpoints dynamiques de destination et reste dans le contexte du filtre
+func (d *FilterDefinition) ToD(uriTemplates ...string) *FilterDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody définit le corps du message de sortie et reste dans le contexte du filtre
+func (d *FilterDefinition) SetBody(body interface{}) *FilterDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte du filtre
+func (d *FilterDefinition) SetHeader(key string, value interface{}) *FilterDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetHeaders définit plusieurs en-têtes et reste dans le contexte du filtre
+func (d *FilterDefinition) SetHeaders(headers map[string]any) *FilterDefinition {
+	d.RouteBuilder.SetHeaders(headers)
+	return d
+}
+
+// SetProperty définit une propriété de l'échange et reste dans le contexte du filtre
+func (d *FilterDefinition) SetProperty(key string, value any) *FilterDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// RemoveHeader supprime un en-tête du message entrant et reste dans le contexte du filtre
+func (d *FilterDefinition) RemoveHeader(name string) *FilterDefinition {
+	d.RouteBuilder.RemoveHeader(name)
+	return d
+}
+
+// RemoveHeaders supprime les en-têtes correspondants au pattern fourni et reste dans le contexte du filtre
+func (d *FilterDefinition) RemoveHeaders(pattern string, excludePatterns ...string) *FilterDefinition {
+	d.RouteBuilder.RemoveHeaders(pattern, excludePatterns...)
+	return d
+}
+
+// Stop arrête le traitement de l'échange et reste dans le contexte du filtre
+func (d *FilterDefinition) Stop() *FilterDefinition {
+	d.RouteBuilder.Stop()
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte du filtre
+func (d *FilterDefinition) Log(message string) *FilterDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// LogBody ajoute un log du corps et reste dans le contexte du filtre
+func (d *FilterDefinition) LogBody(message string) *FilterDefinition {
+	d.RouteBuilder.LogBody(message)
+	return d
+}
+
+// End termine le bloc Filter et revient au builder parent
+func (d *FilterDefinition) End() *RouteBuilder {
+	return d.parent
+}
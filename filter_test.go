@@ -0,0 +1,92 @@
+package gocamel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Simple(t *testing.T) {
+	camel := newTestContext()
+
+	filter := camel.CreateRouteBuilder().
+		From("direct:start").
+		Filter("${header.priority == 'high'}").
+		SetHeader("escalated", true).
+		To("mock:high")
+	filter.End().
+		To("mock:all").
+		Build()
+
+	high, _ := camel.GetMockEndpoint("mock:high")
+	high.ExpectedBodiesReceived("b")
+	high.ExpectedHeaderReceived("escalated", true)
+	all, _ := camel.GetMockEndpoint("mock:all")
+	all.ExpectedBodiesReceived("a", "b", "c")
+	all.MessageN(0).Predicate(func(e *Exchange) bool {
+		matched, _ := e.GetProperty(CamelFilterMatched)
+		return matched == false
+	})
+	all.MessageN(1).Predicate(func(e *Exchange) bool {
+		matched, _ := e.GetProperty(CamelFilterMatched)
+		return matched == true
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "a", map[string]any{"priority": "low"}))
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "b", map[string]any{"priority": "high"}))
+	require.NoError(t, template.SendBody("direct:start", "c"))
+
+	high.AssertIsSatisfied(t, time.Second)
+	all.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, FilterStats{Passed: 1, Filtered: 2}, filter.Stats())
+}
+
+func TestFilter_Func(t *testing.T) {
+	camel := newTestContext()
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		FilterFunc(func(e *Exchange) bool {
+			n, _ := e.GetIn().GetBodyAsInt()
+			return n%2 == 0
+		}).
+		To("mock:even").
+		Stop().
+		End().
+		To("mock:odd").
+		Build()
+
+	even, _ := camel.GetMockEndpoint("mock:even")
+	even.ExpectedBodiesReceived(2, 4)
+	odd, _ := camel.GetMockEndpoint("mock:odd")
+	odd.ExpectedBodiesReceived(1, 3)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	for i := 1; i <= 4; i++ {
+		err := template.SendBody("direct:start", i)
+		if i%2 == 0 {
+			assert.ErrorIs(t, err, ErrStopRouting)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+
+	even.AssertIsSatisfied(t, time.Second)
+	odd.AssertIsSatisfied(t, time.Second)
+}
+
+func TestFilter_InvalidExpressionPanics(t *testing.T) {
+	camel := NewCamelContext()
+	assert.Panics(t, func() {
+		camel.CreateRouteBuilder().From("direct:start").Filter("${unclosed")
+	})
+}
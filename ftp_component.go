@@ -367,29 +367,25 @@ func (c *FTPConsumer) doPoll(ctx context.Context) {
 	}
 	listDir(rootPath)
 
-	count := 0
-	for _, f := range files {
-		if c.opts.MaxMessagesPerPoll > 0 && count >= c.opts.MaxMessagesPerPoll {
-			break
-		}
-
+	batchSize := c.opts.batchSize(len(files))
+	processBatch(batchSize, func(i int) *Exchange {
+		f := files[i]
 		resp, err := conn.Retr(f.path)
 		if err != nil {
 			fmt.Printf("Erreur lors de la récupération du fichier FTP %s: %v\n", f.path, err)
-			continue
+			return nil
 		}
 		content, err := io.ReadAll(resp)
 		resp.Close()
 		if err != nil {
 			fmt.Printf("Erreur lors de la lecture du fichier FTP %s: %v\n", f.path, err)
-			continue
+			return nil
 		}
 
 		exchange := NewExchange(ctx)
 		exchange.SetBody(content)
 		exchange.SetHeader(CamelFileName, f.name)
 		exchange.SetHeader(CamelFilePath, f.path)
-
 		exchange.AddSynchronization(&ftpSynchronization{
 			conn:       conn,
 			path:       f.path,
@@ -398,14 +394,8 @@ func (c *FTPConsumer) doPoll(ctx context.Context) {
 			move:       c.opts.Move,
 			moveFailed: c.opts.MoveFailed,
 		})
-
-		procErr := c.processor.Process(exchange)
-		exchange.Done(procErr)
-
-		if procErr == nil || errors.Is(procErr, ErrStopRouting) {
-			count++
-		}
-	}
+		return exchange
+	}, c.processor)
 }
 
 func (c *FTPConsumer) Stop() error {
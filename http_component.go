@@ -160,8 +160,10 @@ func (c *HTTPConsumer) Start(ctx context.Context) error {
 			}
 		}
 
-		// Processing the message
-		if err := c.processor.Process(exchange); err != nil {
+		// Processing the message; the exchange is completed once the response is written
+		err = c.processor.Process(exchange)
+		defer func() { exchange.Done(err) }()
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
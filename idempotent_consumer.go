@@ -0,0 +1,251 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// CamelDuplicateMessage est la propriété positionnée à true sur les messages déjà traités
+const CamelDuplicateMessage = "CamelDuplicateMessage"
+
+// IdempotentConsumer implémente l'Idempotent Consumer EIP.
+// Une clé est calculée pour chaque échange ; si elle figure déjà dans le repository, le message
+// est un doublon. Les processeurs du bloc ne sont exécutés que pour les nouveaux messages
+// (sauf si SkipDuplicate est désactivé) ; l'échange poursuit ensuite la route après le bloc.
+//
+// En mode eager (par défaut), la clé est enregistrée avant le traitement, ce qui protège aussi
+// des traitements concurrents. Sinon, elle n'est enregistrée qu'à la fin de l'échange.
+// La fin de l'échange est suivie via Exchange.AddSynchronization : elle est signalée par les
+// consommateurs qui appellent Exchange.Done (file, ftp, sftp, smb, imap...).
+type IdempotentConsumer struct {
+	KeyExpression func(*Exchange) (string, error)
+	Repository    IdempotentRepository
+	// Eager enregistre la clé avant le traitement plutôt qu'à la fin de l'échange
+	Eager bool
+	// SkipDuplicate ignore les doublons ; désactivé, ils traversent le bloc avec CamelDuplicateMessage
+	SkipDuplicate bool
+	// RemoveOnFailure retire la clé si l'échange échoue, pour qu'il puisse être retraité
+	RemoveOnFailure bool
+	processors      []Processor
+}
+
+// NewIdempotentConsumer crée un IdempotentConsumer en mode eager qui ignore les doublons
+func NewIdempotentConsumer(keyExpression func(*Exchange) (string, error), repository IdempotentRepository) *IdempotentConsumer {
+	return &IdempotentConsumer{
+		KeyExpression:   keyExpression,
+		Repository:      repository,
+		Eager:           true,
+		SkipDuplicate:   true,
+		RemoveOnFailure: true,
+		processors:      make([]Processor, 0),
+	}
+}
+
+// AddProcessor ajoute un processeur exécuté pour les nouveaux messages
+func (c *IdempotentConsumer) AddProcessor(processor Processor) {
+	c.processors = append(c.processors, processor)
+}
+
+// Process implémente l'interface Processor
+func (c *IdempotentConsumer) Process(exchange *Exchange) error {
+	key, err := c.KeyExpression(exchange)
+	if err != nil {
+		return fmt.Errorf("idempotent key expression error: %w", err)
+	}
+	if key == "" {
+		return fmt.Errorf("idempotent key is empty for exchange %s", exchange.ID)
+	}
+
+	ctx := exchange.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var duplicate bool
+	if c.Eager {
+		added, err := c.Repository.Add(ctx, key)
+		if err != nil {
+			return err
+		}
+		duplicate = !added
+	} else {
+		duplicate, err = c.Repository.Contains(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if duplicate {
+		exchange.SetProperty(CamelDuplicateMessage, true)
+		if c.SkipDuplicate {
+			return nil
+		}
+	} else {
+		exchange.AddSynchronization(&idempotentSynchronization{consumer: c, key: key})
+	}
+
+	for _, p := range c.processors {
+		if err := p.Process(exchange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// idempotentSynchronization confirme ou retire la clé à la fin de l'échange
+type idempotentSynchronization struct {
+	consumer *IdempotentConsumer
+	key      string
+}
+
+func (s *idempotentSynchronization) OnComplete(exchange *Exchange) {
+	ctx := context.Background()
+	repo := s.consumer.Repository
+	if !s.consumer.Eager {
+		if _, err := repo.Add(ctx, s.key); err != nil {
+			log.Printf("idempotent consumer: error adding key %s: %v", s.key, err)
+			return
+		}
+	}
+	if err := repo.Confirm(ctx, s.key); err != nil {
+		log.Printf("idempotent consumer: error confirming key %s: %v", s.key, err)
+	}
+}
+
+func (s *idempotentSynchronization) OnFailure(exchange *Exchange) {
+	if errors.Is(exchange.Error, ErrStopRouting) {
+		s.OnComplete(exchange)
+		return
+	}
+
+	if s.consumer.Eager && s.consumer.RemoveOnFailure {
+		if err := s.consumer.Repository.Remove(context.Background(), s.key); err != nil {
+			log.Printf("idempotent consumer: error removing key %s: %v", s.key, err)
+		}
+	}
+}
+
+// IdempotentConsumer commence un bloc Idempotent Consumer EIP dont la clé est calculée par une
+// expression Simple (ex: "${header.Message-ID}")
+func (b *RouteBuilder) IdempotentConsumer(keyExpression string, repository IdempotentRepository) *IdempotentConsumerDefinition {
+	template, err := ParseSimpleTemplate(keyExpression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for idempotentConsumer: %v", err))
+	}
+	expression := simpleValueExpression(template)
+	return b.IdempotentConsumerFunc(func(exchange *Exchange) (string, error) {
+		value, err := expression(exchange)
+		if err != nil || value == nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", value), nil
+	}, repository)
+}
+
+// IdempotentConsumerFunc commence un bloc Idempotent Consumer EIP dont la clé est calculée par
+// une fonction Go
+func (b *RouteBuilder) IdempotentConsumerFunc(keyExpression func(*Exchange) (string, error), repository IdempotentRepository) *IdempotentConsumerDefinition {
+	c := NewIdempotentConsumer(keyExpression, repository)
+	b.container.AddProcessor(c)
+
+	return &IdempotentConsumerDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: c,
+		},
+		parent:     b,
+		idempotent: c,
+	}
+}
+
+// IdempotentConsumerDefinition permet de configurer l'Idempotent Consumer et les processeurs
+// exécutés pour les nouveaux messages
+type IdempotentConsumerDefinition struct {
+	*RouteBuilder
+	parent     *RouteBuilder
+	idempotent *IdempotentConsumer
+}
+
+// Eager choisit entre l'enregistrement de la clé avant le traitement (true, par défaut)
+// et à la fin de l'échange (false). Sans eager, la clé n'est enregistrée que lorsque l'échange
+// est terminé par Exchange.Done : un échange envoyé avec ProducerTemplate.Send doit donc être
+// terminé par l'appelant.
+func (d *IdempotentConsumerDefinition) Eager(eager bool) *IdempotentConsumerDefinition {
+	d.idempotent.Eager = eager
+	return d
+}
+
+// SkipDuplicate choisit entre ignorer les doublons (true, par défaut) et les faire traverser
+// le bloc avec la propriété CamelDuplicateMessage (false)
+func (d *IdempotentConsumerDefinition) SkipDuplicate(skip bool) *IdempotentConsumerDefinition {
+	d.idempotent.SkipDuplicate = skip
+	return d
+}
+
+// RemoveOnFailure choisit si la clé est retirée lorsque l'échange échoue (true par défaut)
+func (d *IdempotentConsumerDefinition) RemoveOnFailure(remove bool) *IdempotentConsumerDefinition {
+	d.idempotent.RemoveOnFailure = remove
+	return d
+}
+
+// Process ajoute un processeur et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) Process(processor Processor) *IdempotentConsumerDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) ProcessFunc(f func(*Exchange) error) *IdempotentConsumerDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) To(uris ...string) *IdempotentConsumerDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD ajoute un ou plusieurs endpoints dynamiques et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) ToD(uriTemplates ...string) *IdempotentConsumerDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody définit le corps du message de sortie et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) SetBody(body interface{}) *IdempotentConsumerDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) SetHeader(key string, value interface{}) *IdempotentConsumerDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetProperty définit une propriété de l'échange et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) SetProperty(key string, value any) *IdempotentConsumerDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// Stop arrête le traitement de l'échange et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) Stop() *IdempotentConsumerDefinition {
+	d.RouteBuilder.Stop()
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte de l'idempotent consumer
+func (d *IdempotentConsumerDefinition) Log(message string) *IdempotentConsumerDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End termine le bloc Idempotent Consumer et revient au builder parent
+func (d *IdempotentConsumerDefinition) End() *RouteBuilder {
+	return d.parent
+}
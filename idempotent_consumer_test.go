@@ -0,0 +1,191 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendMessage envoie un message puis termine l'échange comme le ferait un consommateur
+func sendMessage(t *testing.T, camel *CamelContext, id string) error {
+	t.Helper()
+	exchange := NewExchange(context.Background())
+	exchange.GetIn().SetBody("mail " + id)
+	exchange.GetIn().SetHeader("Message-ID", id)
+	err := camel.CreateProducerTemplate().Send("direct:start", exchange)
+	exchange.Done(err)
+	return err
+}
+
+func TestIdempotentConsumer_SkipDuplicate(t *testing.T) {
+	camel := newTestContext()
+	repo := NewMemoryIdempotentRepository(0)
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		IdempotentConsumer("${header.Message-ID}", repo).
+		To("mock:new").
+		End().
+		To("mock:all").
+		Build()
+
+	newMessages, _ := camel.GetMockEndpoint("mock:new")
+	newMessages.ExpectedBodiesReceived("mail 1", "mail 2")
+	all, _ := camel.GetMockEndpoint("mock:all")
+	all.ExpectedMessageCount(3)
+	all.MessageN(2).Predicate(func(e *Exchange) bool {
+		duplicate, _ := e.GetProperty(CamelDuplicateMessage)
+		return duplicate == true
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	for _, id := range []string{"1", "2", "1"} {
+		require.NoError(t, sendMessage(t, camel, id))
+	}
+
+	newMessages.AssertIsSatisfied(t, time.Second)
+	all.AssertIsSatisfied(t, time.Second)
+}
+
+func TestIdempotentConsumer_MarkDuplicate(t *testing.T) {
+	camel := newTestContext()
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		IdempotentConsumerFunc(func(e *Exchange) (string, error) {
+			id, _ := e.GetIn().GetHeaderAsString("Message-ID")
+			return id, nil
+		}, NewMemoryIdempotentRepository(0)).
+		SkipDuplicate(false).
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedMessageCount(2)
+	result.MessageN(1).Predicate(func(e *Exchange) bool {
+		duplicate, _ := e.GetProperty(CamelDuplicateMessage)
+		return duplicate == true
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	require.NoError(t, sendMessage(t, camel, "1"))
+	require.NoError(t, sendMessage(t, camel, "1"))
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestIdempotentConsumer_EagerRemovesKeyOnFailure(t *testing.T) {
+	camel := newTestContext()
+	repo := NewMemoryIdempotentRepository(0)
+	failures := 1
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		IdempotentConsumer("${header.Message-ID}", repo).
+		ProcessFunc(func(e *Exchange) error {
+			if failures > 0 {
+				failures--
+				return errors.New("temporary failure")
+			}
+			return nil
+		}).
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("mail 1")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	assert.Error(t, sendMessage(t, camel, "1"))
+	contains, _ := repo.Contains(context.Background(), "1")
+	assert.False(t, contains, "failed message can be processed again")
+
+	require.NoError(t, sendMessage(t, camel, "1"))
+	require.NoError(t, sendMessage(t, camel, "1"))
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestIdempotentConsumer_CompletionConfirmed(t *testing.T) {
+	camel := newTestContext()
+	repo := NewMemoryIdempotentRepository(0)
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		IdempotentConsumer("${header.Message-ID}", repo).
+		Eager(false).
+		ProcessFunc(func(e *Exchange) error {
+			contains, _ := repo.Contains(e.Context, "1")
+			assert.False(t, contains, "key is only stored once the exchange completes")
+			return nil
+		}).
+		End().
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	require.NoError(t, sendMessage(t, camel, "1"))
+	contains, _ := repo.Contains(context.Background(), "1")
+	assert.True(t, contains)
+}
+
+func TestIdempotentConsumer_NotEagerWithCompletingConsumers(t *testing.T) {
+	camel := newTestContext()
+	camel.AddComponent("timer", NewTimerComponent())
+	repo := NewMemoryIdempotentRepository(0)
+
+	camel.CreateRouteBuilder().
+		From("timer:tick?delay=0&period=10&repeatCount=3").
+		SetHeader("Message-ID", "tick").
+		To("direct:start").
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		IdempotentConsumer("${header.Message-ID}", repo).
+		Eager(false).
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// Le timer et le ProducerTemplate terminent leurs échanges : la clé est enregistrée
+	// dès le premier, les suivants sont des doublons
+	assert.Eventually(t, func() bool {
+		contains, _ := repo.Contains(context.Background(), "tick")
+		return contains
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, result.ReceivedCounter())
+
+	require.NoError(t, camel.CreateProducerTemplate().SendBodyAndHeaders("direct:start", "x", map[string]any{"Message-ID": "template"}))
+	require.NoError(t, camel.CreateProducerTemplate().SendBodyAndHeaders("direct:start", "x", map[string]any{"Message-ID": "template"}))
+	assert.Equal(t, 2, result.ReceivedCounter())
+}
+
+func TestIdempotentConsumer_MissingKey(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		IdempotentConsumer("${header.unknown}", NewMemoryIdempotentRepository(0)).
+		End().
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	assert.ErrorContains(t, camel.CreateProducerTemplate().SendBody("direct:start", "x"), "empty")
+}
@@ -0,0 +1,114 @@
+package gocamel
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// IdempotentRepository defines the interface for storing the keys of already processed messages.
+type IdempotentRepository interface {
+	// Add adds the key to the repository. Returns false if the key was already present.
+	Add(ctx context.Context, key string) (bool, error)
+
+	// Contains returns true if the key is present in the repository.
+	Contains(ctx context.Context, key string) (bool, error)
+
+	// Remove removes the key from the repository.
+	Remove(ctx context.Context, key string) error
+
+	// Confirm confirms that the message identified by the key was processed successfully.
+	Confirm(ctx context.Context, key string) error
+
+	// Clear removes all the keys from the repository.
+	Clear(ctx context.Context) error
+}
+
+// DefaultIdempotentCacheSize is the default maximum number of keys kept by MemoryIdempotentRepository.
+const DefaultIdempotentCacheSize = 1000
+
+// MemoryIdempotentRepository is an in-memory LRU implementation of IdempotentRepository.
+// When the maximum size is reached, the least recently used key is evicted.
+type MemoryIdempotentRepository struct {
+	mu      sync.Mutex
+	maxSize int
+	order   *list.List
+	keys    map[string]*list.Element
+}
+
+// NewMemoryIdempotentRepository creates a new MemoryIdempotentRepository keeping at most maxSize keys.
+// A maxSize <= 0 uses DefaultIdempotentCacheSize.
+func NewMemoryIdempotentRepository(maxSize int) *MemoryIdempotentRepository {
+	if maxSize <= 0 {
+		maxSize = DefaultIdempotentCacheSize
+	}
+	return &MemoryIdempotentRepository{
+		maxSize: maxSize,
+		order:   list.New(),
+		keys:    make(map[string]*list.Element),
+	}
+}
+
+// Add adds the key to the repository. Returns false if the key was already present.
+func (r *MemoryIdempotentRepository) Add(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if element, exists := r.keys[key]; exists {
+		r.order.MoveToFront(element)
+		return false, nil
+	}
+	r.keys[key] = r.order.PushFront(key)
+	if r.order.Len() > r.maxSize {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.keys, oldest.Value.(string))
+	}
+	return true, nil
+}
+
+// Contains returns true if the key is present in the repository.
+func (r *MemoryIdempotentRepository) Contains(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element, exists := r.keys[key]
+	if exists {
+		r.order.MoveToFront(element)
+	}
+	return exists, nil
+}
+
+// Remove removes the key from the repository.
+func (r *MemoryIdempotentRepository) Remove(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if element, exists := r.keys[key]; exists {
+		r.order.Remove(element)
+		delete(r.keys, key)
+	}
+	return nil
+}
+
+// Confirm is a no-op: keys are stored as soon as they are added.
+func (r *MemoryIdempotentRepository) Confirm(ctx context.Context, key string) error {
+	return nil
+}
+
+// Clear removes all the keys from the repository.
+func (r *MemoryIdempotentRepository) Clear(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.order.Init()
+	r.keys = make(map[string]*list.Element)
+	return nil
+}
+
+// Size returns the number of keys in the repository.
+func (r *MemoryIdempotentRepository) Size() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.keys)
+}
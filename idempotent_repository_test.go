@@ -0,0 +1,69 @@
+package gocamel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIdempotentRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryIdempotentRepository(2)
+
+	added, err := repo.Add(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, _ = repo.Add(ctx, "a")
+	assert.False(t, added)
+
+	repo.Add(ctx, "b")
+	// "a" est le plus récemment utilisé : c'est "b" qui est évincé
+	contains, _ := repo.Contains(ctx, "a")
+	assert.True(t, contains)
+	repo.Add(ctx, "c")
+	assert.Equal(t, 2, repo.Size())
+	contains, _ = repo.Contains(ctx, "b")
+	assert.False(t, contains)
+
+	require.NoError(t, repo.Remove(ctx, "a"))
+	contains, _ = repo.Contains(ctx, "a")
+	assert.False(t, contains)
+
+	require.NoError(t, repo.Clear(ctx))
+	assert.Equal(t, 0, repo.Size())
+}
+
+func TestFileIdempotentRepository(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store", "processed.txt")
+
+	repo, err := NewFileIdempotentRepository(path)
+	require.NoError(t, err)
+	for _, key := range []string{"a", "b", "c"} {
+		added, err := repo.Add(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, added)
+	}
+	require.NoError(t, repo.Remove(ctx, "b"))
+	_, err = repo.Add(ctx, "bad\nkey")
+	assert.Error(t, err)
+
+	// Les clés survivent à un redémarrage
+	reloaded, err := NewFileIdempotentRepository(path)
+	require.NoError(t, err)
+	added, _ := reloaded.Add(ctx, "a")
+	assert.False(t, added)
+	contains, _ := reloaded.Contains(ctx, "b")
+	assert.False(t, contains)
+	contains, _ = reloaded.Contains(ctx, "c")
+	assert.True(t, contains)
+
+	require.NoError(t, reloaded.Clear(ctx))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+}
@@ -0,0 +1,100 @@
+package gocamel
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// InflightExchange décrit un échange en cours de traitement dans une route
+type InflightExchange struct {
+	Exchange *Exchange
+	RouteID  string
+	NodeID   string
+	Started  time.Time
+}
+
+// Elapsed retourne le temps écoulé depuis l'entrée de l'échange dans la route
+func (i InflightExchange) Elapsed() time.Duration {
+	return time.Since(i.Started)
+}
+
+// InflightRepository suit les échanges en cours de traitement dans les routes du contexte
+type InflightRepository struct {
+	entries map[*Exchange]*InflightExchange
+	mu      sync.RWMutex
+}
+
+// NewInflightRepository crée une nouvelle instance de InflightRepository
+func NewInflightRepository() *InflightRepository {
+	return &InflightRepository{
+		entries: make(map[*Exchange]*InflightExchange),
+	}
+}
+
+// add enregistre l'entrée d'un échange dans une route. La fonction retournée
+// restaure l'état précédent, ce qui permet de gérer les routes imbriquées (direct:) ;
+// nested indique que l'échange était déjà en cours dans une autre route.
+func (r *InflightRepository) add(exchange *Exchange, routeID string) (restore func(), nested bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, nested := r.entries[exchange]
+	r.entries[exchange] = &InflightExchange{
+		Exchange: exchange,
+		RouteID:  routeID,
+		Started:  time.Now(),
+	}
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if nested {
+			r.entries[exchange] = previous
+		} else {
+			delete(r.entries, exchange)
+		}
+	}, nested
+}
+
+// setNode met à jour le nœud courant d'un échange en cours
+func (r *InflightRepository) setNode(exchange *Exchange, nodeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, exists := r.entries[exchange]; exists {
+		entry.NodeID = nodeID
+	}
+}
+
+// Size retourne le nombre d'échanges en cours
+func (r *InflightRepository) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.entries)
+}
+
+// Browse retourne un instantané des échanges en cours, du plus ancien au plus récent
+func (r *InflightRepository) Browse() []InflightExchange {
+	r.mu.RLock()
+	result := make([]InflightExchange, 0, len(r.entries))
+	for _, entry := range r.entries {
+		result = append(result, *entry)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Started.Before(result[j].Started)
+	})
+	return result
+}
+
+// BrowseRoute retourne les échanges en cours pour une route donnée
+func (r *InflightRepository) BrowseRoute(routeID string) []InflightExchange {
+	result := make([]InflightExchange, 0)
+	for _, entry := range r.Browse() {
+		if entry.RouteID == routeID {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
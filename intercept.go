@@ -0,0 +1,426 @@
+package gocamel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CamelInterceptedEndpoint est la propriété contenant l'URI de l'endpoint dont l'envoi a été intercepté
+const CamelInterceptedEndpoint = "CamelInterceptedEndpoint"
+
+// interceptor exécute un pipeline avant chaque nœud d'une route (Intercept)
+// ou à l'entrée d'une route (InterceptFrom)
+type interceptor struct {
+	// from filtre les routes par URI source (InterceptFrom uniquement)
+	from     func(uri string) bool
+	when     *SimpleTemplate
+	pipeline *Pipeline
+}
+
+// sendInterceptor intercepte les envois vers les endpoints dont l'URI correspond au motif
+type sendInterceptor struct {
+	pattern string
+	matches func(uri string) bool
+	when    *SimpleTemplate
+	// pipeline est exécuté avant l'envoi vers l'endpoint d'origine
+	pipeline *Pipeline
+	// skip indique que l'envoi vers l'endpoint d'origine doit être ignoré
+	skip bool
+	// after est exécuté après l'envoi (AfterUri)
+	after Processor
+}
+
+func newSendInterceptor(pattern string) *sendInterceptor {
+	return &sendInterceptor{
+		pattern:  pattern,
+		matches:  newEndpointMatcher(pattern),
+		pipeline: NewPipeline(),
+	}
+}
+
+// Intercept ajoute un intercepteur exécuté avant chaque nœud de premier niveau de toutes les routes
+func (c *CamelContext) Intercept() *InterceptDefinition {
+	i := &interceptor{pipeline: NewPipeline()}
+	c.interceptors = append(c.interceptors, i)
+	return newInterceptDefinition(c, nil, nil, i)
+}
+
+// InterceptFrom ajoute un intercepteur exécuté à l'entrée des routes dont l'endpoint source
+// correspond au motif (tous les endpoints si le motif est vide)
+func (c *CamelContext) InterceptFrom(pattern string) *InterceptDefinition {
+	i := &interceptor{from: newFromMatcher(pattern), pipeline: NewPipeline()}
+	c.fromInterceptors = append(c.fromInterceptors, i)
+	return newInterceptDefinition(c, nil, nil, i)
+}
+
+// InterceptSendToEndpoint intercepte les envois de toutes les routes vers les endpoints dont l'URI
+// correspond au motif (URI exacte, jokers "*" ou expression régulière)
+func (c *CamelContext) InterceptSendToEndpoint(pattern string) *InterceptSendToEndpointDefinition {
+	s := newSendInterceptor(pattern)
+	c.sendInterceptors = append(c.sendInterceptors, s)
+	return newInterceptSendToEndpointDefinition(c, nil, nil, s)
+}
+
+// Intercept ajoute un intercepteur exécuté avant chaque nœud de premier niveau de la route
+func (b *RouteBuilder) Intercept() *InterceptDefinition {
+	i := &interceptor{pipeline: NewPipeline()}
+	b.route.interceptors = append(b.route.interceptors, i)
+	return newInterceptDefinition(b.context, b.route, b, i)
+}
+
+// InterceptFrom ajoute un intercepteur exécuté à l'entrée de la route si son endpoint source
+// correspond au motif (toujours si le motif est vide)
+func (b *RouteBuilder) InterceptFrom(pattern string) *InterceptDefinition {
+	i := &interceptor{from: newFromMatcher(pattern), pipeline: NewPipeline()}
+	b.route.fromInterceptors = append(b.route.fromInterceptors, i)
+	return newInterceptDefinition(b.context, b.route, b, i)
+}
+
+// InterceptSendToEndpoint intercepte les envois de la route vers les endpoints dont l'URI
+// correspond au motif
+func (b *RouteBuilder) InterceptSendToEndpoint(pattern string) *InterceptSendToEndpointDefinition {
+	s := newSendInterceptor(pattern)
+	b.route.sendInterceptors = append(b.route.sendInterceptors, s)
+	return newInterceptSendToEndpointDefinition(b.context, b.route, b, s)
+}
+
+// InterceptDefinition configure un intercepteur Intercept ou InterceptFrom
+type InterceptDefinition struct {
+	*RouteBuilder
+	parent      *RouteBuilder
+	interceptor *interceptor
+}
+
+func newInterceptDefinition(context *CamelContext, route *Route, parent *RouteBuilder, i *interceptor) *InterceptDefinition {
+	return &InterceptDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   context,
+			route:     route,
+			container: i.pipeline,
+		},
+		parent:      parent,
+		interceptor: i,
+	}
+}
+
+// When n'applique l'intercepteur que si l'expression Simple est vraie
+func (d *InterceptDefinition) When(expression string) *InterceptDefinition {
+	d.interceptor.when = mustParseInterceptWhen(expression)
+	return d
+}
+
+// Process ajoute un processeur et reste dans le contexte de l'intercepteur
+func (d *InterceptDefinition) Process(processor Processor) *InterceptDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte de l'intercepteur
+func (d *InterceptDefinition) ProcessFunc(f func(*Exchange) error) *InterceptDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte de l'intercepteur
+func (d *InterceptDefinition) To(uris ...string) *InterceptDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte de l'intercepteur
+func (d *InterceptDefinition) SetHeader(key string, value interface{}) *InterceptDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// RemoveHeader supprime un en-tête du message entrant et reste dans le contexte de l'intercepteur
+func (d *InterceptDefinition) RemoveHeader(name string) *InterceptDefinition {
+	d.RouteBuilder.RemoveHeader(name)
+	return d
+}
+
+// RemoveHeaders supprime les en-têtes correspondant au pattern et reste dans le contexte de l'intercepteur
+func (d *InterceptDefinition) RemoveHeaders(pattern string, excludePatterns ...string) *InterceptDefinition {
+	d.RouteBuilder.RemoveHeaders(pattern, excludePatterns...)
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte de l'intercepteur
+func (d *InterceptDefinition) Log(message string) *InterceptDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// Stop arrête le routage de l'échange intercepté
+func (d *InterceptDefinition) Stop() *InterceptDefinition {
+	d.RouteBuilder.Stop()
+	return d
+}
+
+// End termine la définition d'un intercepteur de route et revient au builder de la route.
+// Retourne nil pour un intercepteur défini sur le contexte.
+func (d *InterceptDefinition) End() *RouteBuilder {
+	return d.parent
+}
+
+// InterceptSendToEndpointDefinition configure un intercepteur d'envoi
+type InterceptSendToEndpointDefinition struct {
+	*RouteBuilder
+	parent      *RouteBuilder
+	interceptor *sendInterceptor
+}
+
+func newInterceptSendToEndpointDefinition(context *CamelContext, route *Route, parent *RouteBuilder, s *sendInterceptor) *InterceptSendToEndpointDefinition {
+	return &InterceptSendToEndpointDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   context,
+			route:     route,
+			container: s.pipeline,
+		},
+		parent:      parent,
+		interceptor: s,
+	}
+}
+
+// SkipSendToOriginalEndpoint n'envoie pas l'échange à l'endpoint d'origine.
+// Combiné avec To, cela permet de rediriger l'envoi (ex: vers direct: ou mock:).
+func (d *InterceptSendToEndpointDefinition) SkipSendToOriginalEndpoint() *InterceptSendToEndpointDefinition {
+	d.interceptor.skip = true
+	return d
+}
+
+// AfterUri envoie l'échange vers uri après l'envoi vers l'endpoint d'origine
+func (d *InterceptSendToEndpointDefinition) AfterUri(uri string) *InterceptSendToEndpointDefinition {
+	d.interceptor.after = createToProcessor(d.context, uri)
+	return d
+}
+
+// When n'applique l'intercepteur que si l'expression Simple est vraie
+func (d *InterceptSendToEndpointDefinition) When(expression string) *InterceptSendToEndpointDefinition {
+	d.interceptor.when = mustParseInterceptWhen(expression)
+	return d
+}
+
+// Process ajoute un processeur et reste dans le contexte de l'intercepteur
+func (d *InterceptSendToEndpointDefinition) Process(processor Processor) *InterceptSendToEndpointDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte de l'intercepteur
+func (d *InterceptSendToEndpointDefinition) ProcessFunc(f func(*Exchange) error) *InterceptSendToEndpointDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte de l'intercepteur
+func (d *InterceptSendToEndpointDefinition) To(uris ...string) *InterceptSendToEndpointDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte de l'intercepteur
+func (d *InterceptSendToEndpointDefinition) SetHeader(key string, value interface{}) *InterceptSendToEndpointDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// RemoveHeader supprime un en-tête du message entrant et reste dans le contexte de l'intercepteur
+func (d *InterceptSendToEndpointDefinition) RemoveHeader(name string) *InterceptSendToEndpointDefinition {
+	d.RouteBuilder.RemoveHeader(name)
+	return d
+}
+
+// RemoveHeaders supprime les en-têtes correspondant au pattern et reste dans le contexte de l'intercepteur
+func (d *InterceptSendToEndpointDefinition) RemoveHeaders(pattern string, excludePatterns ...string) *InterceptSendToEndpointDefinition {
+	d.RouteBuilder.RemoveHeaders(pattern, excludePatterns...)
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte de l'intercepteur
+func (d *InterceptSendToEndpointDefinition) Log(message string) *InterceptSendToEndpointDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End termine la définition d'un intercepteur de route et revient au builder de la route.
+// Retourne nil pour un intercepteur défini sur le contexte ou via AdviceWith.
+func (d *InterceptSendToEndpointDefinition) End() *RouteBuilder {
+	return d.parent
+}
+
+// interceptRoute applique les intercepteurs InterceptFrom du contexte et de la route
+func (r *Route) interceptRoute(exchange *Exchange) error {
+	if exchange.intercepting {
+		return nil
+	}
+	uri := ""
+	if r.from != nil {
+		uri = r.from.URI()
+	}
+	for _, i := range r.allInterceptors(true) {
+		if i.from != nil && !i.from(uri) {
+			continue
+		}
+		if err := runInterceptor(exchange, i.when, i.pipeline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interceptNode applique les intercepteurs Intercept du contexte et de la route avant un nœud
+func (r *Route) interceptNode(exchange *Exchange) error {
+	if exchange.intercepting {
+		return nil
+	}
+	for _, i := range r.allInterceptors(false) {
+		if err := runInterceptor(exchange, i.when, i.pipeline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allInterceptors retourne les intercepteurs du contexte suivis de ceux de la route
+func (r *Route) allInterceptors(from bool) []*interceptor {
+	var result []*interceptor
+	if r.context != nil {
+		if from {
+			result = append(result, r.context.fromInterceptors...)
+		} else {
+			result = append(result, r.context.interceptors...)
+		}
+	}
+	if from {
+		return append(result, r.fromInterceptors...)
+	}
+	return append(result, r.interceptors...)
+}
+
+// interceptSend applique les intercepteurs d'envoi du contexte puis de la route courante
+// avant d'appeler send. Les envois effectués depuis un intercepteur ne sont pas eux-mêmes interceptés.
+func interceptSend(context *CamelContext, exchange *Exchange, uri string, send func() error) error {
+	if exchange.intercepting {
+		return send()
+	}
+
+	var interceptors []*sendInterceptor
+	if context != nil {
+		interceptors = append(interceptors, context.sendInterceptors...)
+	}
+	if exchange.route != nil {
+		interceptors = append(interceptors, exchange.route.sendInterceptors...)
+	}
+	if len(interceptors) == 0 {
+		return send()
+	}
+
+	skip := false
+	var after []Processor
+	for _, s := range interceptors {
+		if !s.matches(uri) {
+			continue
+		}
+		if s.when != nil {
+			matched, err := s.when.EvaluateAsBool(exchange)
+			if err != nil {
+				return fmt.Errorf("erreur lors de l'évaluation de la condition de l'intercepteur %s: %w", s.pattern, err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		exchange.SetProperty(CamelInterceptedEndpoint, uri)
+		if err := runInterceptor(exchange, nil, s.pipeline); err != nil {
+			return err
+		}
+		propagateOut(exchange)
+		skip = skip || s.skip
+		if s.after != nil {
+			after = append(after, s.after)
+		}
+	}
+
+	if !skip {
+		if err := send(); err != nil {
+			return err
+		}
+	}
+	for _, processor := range after {
+		if err := runInterceptor(exchange, nil, processor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runInterceptor exécute le processeur d'un intercepteur si la condition est vraie,
+// en désactivant l'interception des nœuds et envois qu'il effectue
+func runInterceptor(exchange *Exchange, when *SimpleTemplate, processor Processor) error {
+	if when != nil {
+		matched, err := when.EvaluateAsBool(exchange)
+		if err != nil {
+			return fmt.Errorf("erreur lors de l'évaluation de la condition de l'intercepteur: %w", err)
+		}
+		if !matched {
+			return nil
+		}
+	}
+
+	exchange.intercepting = true
+	defer func() { exchange.intercepting = false }()
+	return processor.Process(exchange)
+}
+
+func mustParseInterceptWhen(expression string) *SimpleTemplate {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for intercept: %v", err))
+	}
+	return template
+}
+
+// newFromMatcher retourne un filtre d'URI source ; un motif vide accepte toutes les routes
+func newFromMatcher(pattern string) func(uri string) bool {
+	if pattern == "" {
+		return func(string) bool { return true }
+	}
+	return newEndpointMatcher(pattern)
+}
+
+// newEndpointMatcher retourne une fonction testant si une URI correspond au motif.
+// Le motif peut être une URI exacte, un motif avec jokers (ex: "ftp:*", "smtp://*@mail.example.com*")
+// ou une expression régulière. Les URIs sont aussi comparées sans leurs paramètres.
+func newEndpointMatcher(pattern string) func(uri string) bool {
+	var expressions []*regexp.Regexp
+	if strings.Contains(pattern, "*") {
+		parts := strings.Split(pattern, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		expressions = append(expressions, regexp.MustCompile("^"+strings.Join(parts, ".*")+"$"))
+	}
+	if re, err := regexp.Compile("^(?:" + pattern + ")$"); err == nil {
+		expressions = append(expressions, re)
+	}
+
+	return func(uri string) bool {
+		candidates := []string{uri}
+		if i := strings.Index(uri, "?"); i >= 0 {
+			candidates = append(candidates, uri[:i])
+		}
+		for _, candidate := range candidates {
+			if candidate == pattern {
+				return true
+			}
+			for _, re := range expressions {
+				if re.MatchString(candidate) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
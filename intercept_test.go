@@ -0,0 +1,183 @@
+package gocamel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInterceptTestContext(t *testing.T) *CamelContext {
+	t.Helper()
+	camel := newTestContext()
+	return camel
+}
+
+func TestIntercept_EveryNode(t *testing.T) {
+	camel := newInterceptTestContext(t)
+
+	var visited []string
+	camel.Intercept().When("${header.trace == true}").ProcessFunc(func(e *Exchange) error {
+		body, _ := e.GetIn().GetBodyAsString()
+		visited = append(visited, body)
+		return nil
+	})
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		ProcessFunc(func(e *Exchange) error {
+			e.GetIn().SetBody("step1")
+			return nil
+		}).
+		To("direct:sub")
+	camel.CreateRouteBuilder().
+		From("direct:sub").
+		SetBody("step2")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "in", map[string]any{"trace": true}))
+	// Deux nœuds dans la première route, un dans la route appelée via direct:
+	assert.Equal(t, []string{"in", "step1", "step1"}, visited)
+
+	visited = nil
+	require.NoError(t, template.SendBody("direct:start", "in"))
+	assert.Empty(t, visited)
+}
+
+func TestIntercept_Stop(t *testing.T) {
+	camel := newInterceptTestContext(t)
+
+	camel.CreateRouteBuilder().
+		Intercept().When("${header.blocked == true}").Stop().End().
+		From("direct:start").
+		To("mock:result")
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("allowed")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	err := template.SendBodyAndHeaders("direct:start", "blocked", map[string]any{"blocked": true})
+	assert.ErrorIs(t, err, ErrStopRouting)
+	require.NoError(t, template.SendBody("direct:start", "allowed"))
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestInterceptFrom(t *testing.T) {
+	camel := newInterceptTestContext(t)
+
+	camel.InterceptFrom("direct:public*").RemoveHeaders("X-Internal-*")
+
+	camel.CreateRouteBuilder().From("direct:public-orders").To("mock:public")
+	camel.CreateRouteBuilder().From("direct:internal").To("mock:internal")
+
+	public, _ := camel.GetMockEndpoint("mock:public")
+	public.MessageN(0).Predicate(func(e *Exchange) bool {
+		return !e.GetIn().HasHeader("X-Internal-Token") && e.GetIn().HasHeader("X-Request")
+	})
+	internal, _ := camel.GetMockEndpoint("mock:internal")
+	internal.ExpectedHeaderReceived("X-Internal-Token", "secret")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	headers := map[string]any{"X-Internal-Token": "secret", "X-Request": "1"}
+	require.NoError(t, template.SendBodyAndHeaders("direct:public-orders", "a", headers))
+	require.NoError(t, template.SendBodyAndHeaders("direct:internal", "b", headers))
+
+	public.AssertIsSatisfied(t, time.Second)
+	internal.AssertIsSatisfied(t, time.Second)
+}
+
+func TestInterceptSendToEndpoint(t *testing.T) {
+	camel := newInterceptTestContext(t)
+
+	// Audit de tous les envois vers les endpoints mock:out*, suivi d'un envoi vers mock:audit
+	camel.InterceptSendToEndpoint("mock:out*").
+		SetHeader("audited", true).
+		AfterUri("mock:audit")
+	// Les envois marqués "dryRun" vers mock:out-b sont ignorés
+	camel.InterceptSendToEndpoint("mock:out-b").When("${header.dryRun == true}").SkipSendToOriginalEndpoint()
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		To("mock:out-a").
+		ToD("mock:out-${header.target}")
+
+	outA, _ := camel.GetMockEndpoint("mock:out-a")
+	outA.ExpectedMessageCount(2)
+	outA.ExpectedHeaderReceived("audited", true)
+	outB, _ := camel.GetMockEndpoint("mock:out-b")
+	outB.ExpectedBodiesReceived("real")
+	audit, _ := camel.GetMockEndpoint("mock:audit")
+	audit.ExpectedMessageCount(4)
+	audit.MessageN(1).Predicate(func(e *Exchange) bool {
+		uri, _ := e.GetPropertyAsString(CamelInterceptedEndpoint)
+		return uri == "mock:out-b"
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "real", map[string]any{"target": "b"}))
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "dry", map[string]any{"target": "b", "dryRun": true}))
+
+	outA.AssertIsSatisfied(t, time.Second)
+	outB.AssertIsSatisfied(t, time.Second)
+	audit.AssertIsSatisfied(t, time.Second)
+}
+
+func TestInterceptSendToEndpoint_RouteScoped(t *testing.T) {
+	camel := newInterceptTestContext(t)
+
+	camel.CreateRouteBuilder().
+		InterceptSendToEndpoint("mock:*").RemoveHeader("secret").End().
+		From("direct:scrubbed").
+		To("mock:scrubbed")
+	camel.CreateRouteBuilder().
+		From("direct:raw").
+		To("mock:raw")
+
+	scrubbed, _ := camel.GetMockEndpoint("mock:scrubbed")
+	scrubbed.MessageN(0).Predicate(func(e *Exchange) bool { return !e.GetIn().HasHeader("secret") })
+	raw, _ := camel.GetMockEndpoint("mock:raw")
+	raw.ExpectedHeaderReceived("secret", "s3cret")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:scrubbed", "a", map[string]any{"secret": "s3cret"}))
+	require.NoError(t, template.SendBodyAndHeaders("direct:raw", "b", map[string]any{"secret": "s3cret"}))
+
+	scrubbed.AssertIsSatisfied(t, time.Second)
+	raw.AssertIsSatisfied(t, time.Second)
+}
+
+func TestEndpointMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		uri     string
+		match   bool
+	}{
+		{"direct:start", "direct:start", true},
+		{"direct:start", "direct:start?timeout=1", true},
+		{"direct:start", "direct:other", false},
+		{"ftp:*", "ftp://host/dir", true},
+		{"*", "mock:x", true},
+		{"smtp://*@mail.example.com*", "smtp://bob@mail.example.com?to=x", true},
+		{"mongodb:.*", "mongodb:conn?database=test", true},
+		{"mongodb:.*", "mock:conn", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, newEndpointMatcher(tt.pattern)(tt.uri), "%s ~ %s", tt.uri, tt.pattern)
+	}
+}
@@ -0,0 +1,377 @@
+package gocamel
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// LoadBalancerPolicy choisit la ou les cibles d'un load balancer pour chaque échange
+type LoadBalancerPolicy interface {
+	Process(exchange *Exchange, processors []Processor) error
+}
+
+// LoadBalancer implémente le Load Balancer EIP : chaque échange est envoyé à l'une des cibles
+// (endpoints ou processeurs) choisie par la politique, round-robin par défaut.
+type LoadBalancer struct {
+	Policy     LoadBalancerPolicy
+	processors []Processor
+}
+
+// NewLoadBalancer crée un load balancer round-robin
+func NewLoadBalancer() *LoadBalancer {
+	return &LoadBalancer{
+		Policy:     &RoundRobinLoadBalancer{},
+		processors: make([]Processor, 0),
+	}
+}
+
+// AddProcessor ajoute une cible au load balancer
+func (l *LoadBalancer) AddProcessor(processor Processor) {
+	l.processors = append(l.processors, processor)
+}
+
+// Process implémente l'interface Processor
+func (l *LoadBalancer) Process(exchange *Exchange) error {
+	if len(l.processors) == 0 {
+		return fmt.Errorf("load balancer has no target")
+	}
+	return l.Policy.Process(exchange, l.processors)
+}
+
+// RoundRobinLoadBalancer envoie les échanges à tour de rôle à chaque cible
+type RoundRobinLoadBalancer struct {
+	counter atomic.Uint64
+}
+
+// Process implémente l'interface LoadBalancerPolicy
+func (p *RoundRobinLoadBalancer) Process(exchange *Exchange, processors []Processor) error {
+	index := (p.counter.Add(1) - 1) % uint64(len(processors))
+	return processors[index].Process(exchange)
+}
+
+// RandomLoadBalancer envoie chaque échange à une cible tirée au hasard
+type RandomLoadBalancer struct{}
+
+// Process implémente l'interface LoadBalancerPolicy
+func (p *RandomLoadBalancer) Process(exchange *Exchange, processors []Processor) error {
+	return processors[rand.IntN(len(processors))].Process(exchange)
+}
+
+// WeightedLoadBalancer répartit les échanges proportionnellement aux poids des cibles
+// (ex: 4, 2, 1), à tour de rôle ou par tirage aléatoire pondéré
+type WeightedLoadBalancer struct {
+	Weights []int
+	// Random tire les cibles au hasard plutôt qu'à tour de rôle
+	Random bool
+
+	mu      sync.Mutex
+	current []int
+}
+
+// Process implémente l'interface LoadBalancerPolicy
+func (p *WeightedLoadBalancer) Process(exchange *Exchange, processors []Processor) error {
+	if len(p.Weights) != len(processors) {
+		return fmt.Errorf("weighted load balancer has %d weights for %d targets", len(p.Weights), len(processors))
+	}
+	total := 0
+	for _, weight := range p.Weights {
+		if weight < 0 {
+			return fmt.Errorf("weighted load balancer weights must not be negative: %v", p.Weights)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("weighted load balancer weights must not all be zero")
+	}
+
+	var index int
+	if p.Random {
+		index = p.pickRandom(total)
+	} else {
+		index = p.pickNext(total)
+	}
+	return processors[index].Process(exchange)
+}
+
+func (p *WeightedLoadBalancer) pickRandom(total int) int {
+	n := rand.IntN(total)
+	for i, weight := range p.Weights {
+		if n < weight {
+			return i
+		}
+		n -= weight
+	}
+	return len(p.Weights) - 1
+}
+
+// pickNext applique un round-robin pondéré lissé : les cibles sont entrelacées plutôt
+// qu'envoyées par rafales (4, 2, 1 donne a b a c a b a et non a a a a b b c)
+func (p *WeightedLoadBalancer) pickNext(total int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.current) != len(p.Weights) {
+		p.current = make([]int, len(p.Weights))
+	}
+	best := 0
+	for i, weight := range p.Weights {
+		p.current[i] += weight
+		if p.current[i] > p.current[best] {
+			best = i
+		}
+	}
+	p.current[best] -= total
+	return best
+}
+
+// StickyLoadBalancer envoie toujours les échanges d'une même clé de corrélation à la même cible
+type StickyLoadBalancer struct {
+	Expression func(*Exchange) (string, error)
+}
+
+// Process implémente l'interface LoadBalancerPolicy
+func (p *StickyLoadBalancer) Process(exchange *Exchange, processors []Processor) error {
+	key, err := p.Expression(exchange)
+	if err != nil {
+		return fmt.Errorf("sticky load balancer expression error: %w", err)
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return processors[hash.Sum32()%uint32(len(processors))].Process(exchange)
+}
+
+// TopicLoadBalancer envoie une copie de chaque échange à toutes les cibles
+type TopicLoadBalancer struct{}
+
+// Process implémente l'interface LoadBalancerPolicy
+func (p *TopicLoadBalancer) Process(exchange *Exchange, processors []Processor) error {
+	var firstErr error
+	for _, processor := range processors {
+		if err := processor.Process(exchange.Copy()); err != nil && !errors.Is(err, ErrStopRouting) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// FailoverLoadBalancer essaie les cibles l'une après l'autre tant qu'elles échouent.
+// Chaque tentative porte sur une copie de l'échange d'origine ; le résultat de la tentative
+// réussie est reporté sur l'échange.
+type FailoverLoadBalancer struct {
+	// Errors liste les erreurs (comparées avec errors.Is) déclenchant un failover ;
+	// sans Errors ni When, toute erreur déclenche un failover
+	Errors []error
+	// When décide si une erreur déclenche un failover, en complément de Errors
+	When func(error) bool
+	// MaximumFailoverAttempts est le nombre maximal de failovers après la première tentative ;
+	// une valeur négative essaie chaque cible une fois
+	MaximumFailoverAttempts int
+	// RoundRobin fait commencer chaque échange par la cible suivante plutôt que par la première
+	RoundRobin bool
+
+	counter atomic.Uint64
+}
+
+// NewFailoverLoadBalancer crée une politique de failover sur les erreurs données
+func NewFailoverLoadBalancer(errs ...error) *FailoverLoadBalancer {
+	return &FailoverLoadBalancer{
+		Errors:                  errs,
+		MaximumFailoverAttempts: -1,
+	}
+}
+
+// Process implémente l'interface LoadBalancerPolicy
+func (p *FailoverLoadBalancer) Process(exchange *Exchange, processors []Processor) error {
+	start := 0
+	if p.RoundRobin {
+		start = int((p.counter.Add(1) - 1) % uint64(len(processors)))
+	}
+	attempts := len(processors)
+	if p.MaximumFailoverAttempts >= 0 {
+		attempts = p.MaximumFailoverAttempts + 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		candidate := exchange.Copy()
+		candidate.ID = exchange.ID
+		err = processors[(start+attempt)%len(processors)].Process(candidate)
+		if err == nil || errors.Is(err, ErrStopRouting) || !p.shouldFailover(err) {
+			exchange.In = candidate.In
+			exchange.Out = candidate.Out
+			exchange.Properties = candidate.Properties
+			return err
+		}
+	}
+	return err
+}
+
+func (p *FailoverLoadBalancer) shouldFailover(err error) bool {
+	if len(p.Errors) == 0 && p.When == nil {
+		return true
+	}
+	for _, target := range p.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return p.When != nil && p.When(err)
+}
+
+// MatchErrorType retourne un prédicat reconnaissant les erreurs de type T dans la chaîne
+// d'erreurs, à utiliser avec FailoverWhen (ex: MatchErrorType[*net.OpError]())
+func MatchErrorType[T error]() func(error) bool {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// LoadBalance commence un bloc Load Balancer EIP ; chaque endpoint ou processeur du bloc est
+// une cible. La politique par défaut est le round-robin.
+func (b *RouteBuilder) LoadBalance() *LoadBalanceDefinition {
+	l := NewLoadBalancer()
+	b.container.AddProcessor(l)
+
+	return &LoadBalanceDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: l,
+		},
+		parent:       b,
+		loadBalancer: l,
+	}
+}
+
+// LoadBalanceDefinition permet de configurer la politique et les cibles du load balancer
+type LoadBalanceDefinition struct {
+	*RouteBuilder
+	parent       *RouteBuilder
+	loadBalancer *LoadBalancer
+}
+
+// RoundRobin envoie les échanges à tour de rôle à chaque cible
+func (d *LoadBalanceDefinition) RoundRobin() *LoadBalanceDefinition {
+	d.loadBalancer.Policy = &RoundRobinLoadBalancer{}
+	return d
+}
+
+// Random envoie chaque échange à une cible tirée au hasard
+func (d *LoadBalanceDefinition) Random() *LoadBalanceDefinition {
+	d.loadBalancer.Policy = &RandomLoadBalancer{}
+	return d
+}
+
+// Weighted répartit les échanges selon les poids des cibles, dans l'ordre de leur déclaration.
+// Avec random à false, les cibles sont choisies à tour de rôle.
+func (d *LoadBalanceDefinition) Weighted(random bool, weights ...int) *LoadBalanceDefinition {
+	d.loadBalancer.Policy = &WeightedLoadBalancer{Weights: weights, Random: random}
+	return d
+}
+
+// Sticky envoie les échanges d'une même valeur de l'expression Simple à la même cible
+// (ex: "${header.customerId}")
+func (d *LoadBalanceDefinition) Sticky(expression string) *LoadBalanceDefinition {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for sticky load balancer: %v", err))
+	}
+	expr := simpleValueExpression(template)
+	return d.StickyFunc(func(exchange *Exchange) (string, error) {
+		value, err := expr(exchange)
+		if err != nil || value == nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", value), nil
+	})
+}
+
+// StickyFunc envoie les échanges d'une même clé, calculée par la fonction, à la même cible
+func (d *LoadBalanceDefinition) StickyFunc(expression func(*Exchange) (string, error)) *LoadBalanceDefinition {
+	d.loadBalancer.Policy = &StickyLoadBalancer{Expression: expression}
+	return d
+}
+
+// Topic envoie une copie de chaque échange à toutes les cibles
+func (d *LoadBalanceDefinition) Topic() *LoadBalanceDefinition {
+	d.loadBalancer.Policy = &TopicLoadBalancer{}
+	return d
+}
+
+// Failover essaie la cible suivante lorsqu'une cible échoue avec l'une des erreurs données
+// (comparées avec errors.Is), ou avec n'importe quelle erreur si aucune n'est donnée
+func (d *LoadBalanceDefinition) Failover(errs ...error) *LoadBalanceDefinition {
+	d.loadBalancer.Policy = NewFailoverLoadBalancer(errs...)
+	return d
+}
+
+// FailoverWhen ajoute un prédicat déclenchant le failover (ex: MatchErrorType[*net.OpError]())
+func (d *LoadBalanceDefinition) FailoverWhen(predicate func(error) bool) *LoadBalanceDefinition {
+	d.failover().When = predicate
+	return d
+}
+
+// MaximumFailoverAttempts limite le nombre de failovers après la première tentative ; les cibles
+// sont reprises depuis le début si la limite dépasse leur nombre
+func (d *LoadBalanceDefinition) MaximumFailoverAttempts(attempts int) *LoadBalanceDefinition {
+	d.failover().MaximumFailoverAttempts = attempts
+	return d
+}
+
+// RoundRobinFailover fait commencer chaque échange par la cible suivante plutôt que par la première
+func (d *LoadBalanceDefinition) RoundRobinFailover() *LoadBalanceDefinition {
+	d.failover().RoundRobin = true
+	return d
+}
+
+// Policy définit une politique de répartition personnalisée
+func (d *LoadBalanceDefinition) Policy(policy LoadBalancerPolicy) *LoadBalanceDefinition {
+	d.loadBalancer.Policy = policy
+	return d
+}
+
+func (d *LoadBalanceDefinition) failover() *FailoverLoadBalancer {
+	failover, ok := d.loadBalancer.Policy.(*FailoverLoadBalancer)
+	if !ok {
+		panic("failover options require the Failover() load balancer policy")
+	}
+	return failover
+}
+
+// Process ajoute un processeur comme cible et reste dans le contexte du load balancer
+func (d *LoadBalanceDefinition) Process(processor Processor) *LoadBalanceDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement comme cible et reste dans le contexte du load balancer
+func (d *LoadBalanceDefinition) ProcessFunc(f func(*Exchange) error) *LoadBalanceDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute chaque endpoint comme une cible distincte et reste dans le contexte du load balancer
+func (d *LoadBalanceDefinition) To(uris ...string) *LoadBalanceDefinition {
+	for _, uri := range uris {
+		d.RouteBuilder.To(uri)
+	}
+	return d
+}
+
+// ToD ajoute chaque endpoint dynamique comme une cible distincte et reste dans le contexte du load balancer
+func (d *LoadBalanceDefinition) ToD(uriTemplates ...string) *LoadBalanceDefinition {
+	for _, uriTemplate := range uriTemplates {
+		d.RouteBuilder.ToD(uriTemplate)
+	}
+	return d
+}
+
+// End termine le bloc LoadBalance et revient au builder parent
+func (d *LoadBalanceDefinition) End() *RouteBuilder {
+	return d.parent
+}
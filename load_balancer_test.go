@@ -0,0 +1,268 @@
+package gocamel
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBalance_RoundRobin(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		LoadBalance().
+		To("mock:a", "mock:b").
+		End().
+		Build()
+
+	a, _ := camel.GetMockEndpoint("mock:a")
+	a.ExpectedBodiesReceived("1", "3")
+	b, _ := camel.GetMockEndpoint("mock:b")
+	b.ExpectedBodiesReceived("2", "4")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, template.SendBody("direct:start", fmt.Sprint(i)))
+	}
+	a.AssertIsSatisfied(t, time.Second)
+	b.AssertIsSatisfied(t, time.Second)
+}
+
+func TestLoadBalance_Weighted(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		LoadBalance().
+		Weighted(false, 2, 1).
+		To("mock:a", "mock:b").
+		End().
+		Build()
+
+	a, _ := camel.GetMockEndpoint("mock:a")
+	a.ExpectedBodiesReceived("1", "3", "4", "6")
+	b, _ := camel.GetMockEndpoint("mock:b")
+	b.ExpectedBodiesReceived("2", "5")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	for i := 1; i <= 6; i++ {
+		require.NoError(t, template.SendBody("direct:start", fmt.Sprint(i)))
+	}
+	a.AssertIsSatisfied(t, time.Second)
+	b.AssertIsSatisfied(t, time.Second)
+}
+
+func TestLoadBalance_WeightedRandom(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		LoadBalance().
+		Weighted(true, 1, 0).
+		To("mock:a", "mock:b").
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:invalid").
+		LoadBalance().
+		Weighted(true, 1).
+		To("mock:a", "mock:b").
+		End().
+		Build()
+
+	a, _ := camel.GetMockEndpoint("mock:a")
+	a.ExpectedMessageCount(10)
+	b, _ := camel.GetMockEndpoint("mock:b")
+	b.ExpectedMessageCount(0)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, template.SendBody("direct:start", i))
+	}
+	assert.ErrorContains(t, template.SendBody("direct:invalid", "x"), "1 weights for 2 targets")
+	a.AssertIsSatisfied(t, time.Second)
+	b.AssertIsSatisfied(t, time.Second)
+}
+
+func TestLoadBalance_RandomAndSticky(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:random").
+		LoadBalance().
+		Random().
+		To("mock:a", "mock:b").
+		End().
+		Build()
+
+	targets := map[string]map[string]bool{}
+	record := func(name string) func(*Exchange) error {
+		return func(e *Exchange) error {
+			customer, _ := e.GetIn().GetHeaderAsString("customerId")
+			if targets[customer] == nil {
+				targets[customer] = map[string]bool{}
+			}
+			targets[customer][name] = true
+			return nil
+		}
+	}
+	camel.CreateRouteBuilder().
+		From("direct:sticky").
+		LoadBalance().
+		Sticky("${header.customerId}").
+		ProcessFunc(record("first")).
+		ProcessFunc(record("second")).
+		ProcessFunc(record("third")).
+		End().
+		Build()
+
+	a, _ := camel.GetMockEndpoint("mock:a")
+	b, _ := camel.GetMockEndpoint("mock:b")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	for i := 0; i < 20; i++ {
+		require.NoError(t, template.SendBody("direct:random", i))
+		customer := fmt.Sprintf("c%d", i%5)
+		require.NoError(t, template.SendBodyAndHeaders("direct:sticky", i, map[string]any{"customerId": customer}))
+	}
+
+	assert.Equal(t, 20, a.ReceivedCounter()+b.ReceivedCounter())
+	assert.Len(t, targets, 5)
+	for customer, used := range targets {
+		assert.Len(t, used, 1, "customer %s was sent to several targets", customer)
+	}
+}
+
+func TestLoadBalance_Topic(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		LoadBalance().
+		Topic().
+		To("mock:a", "mock:b").
+		End().
+		Build()
+
+	a, _ := camel.GetMockEndpoint("mock:a")
+	a.ExpectedBodiesReceived("x", "y")
+	b, _ := camel.GetMockEndpoint("mock:b")
+	b.ExpectedBodiesReceived("x", "y")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "x"))
+	require.NoError(t, template.SendBody("direct:start", "y"))
+	a.AssertIsSatisfied(t, time.Second)
+	b.AssertIsSatisfied(t, time.Second)
+}
+
+var errRelayDown = errors.New("relay down")
+
+type backendError struct{ status int }
+
+func (e *backendError) Error() string { return fmt.Sprintf("backend returned %d", e.status) }
+
+func TestLoadBalance_Failover(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		LoadBalance().
+		Failover(errRelayDown).
+		FailoverWhen(MatchErrorType[*backendError]()).
+		ProcessFunc(func(e *Exchange) error {
+			e.GetOut().SetBody("partial")
+			failure, _ := e.GetIn().GetHeaderAsString("failure")
+			switch failure {
+			case "relay":
+				return fmt.Errorf("smtp: %w", errRelayDown)
+			case "backend":
+				return &backendError{status: 503}
+			default:
+				return errors.New("invalid message")
+			}
+		}).
+		To("mock:backup").
+		End().
+		Build()
+
+	backup, _ := camel.GetMockEndpoint("mock:backup")
+	backup.ExpectedBodiesReceived("a", "b")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "a", map[string]any{"failure": "relay"}))
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "b", map[string]any{"failure": "backend"}))
+
+	// Une erreur non listée n'est pas reprise par une autre cible
+	exchange, err := template.Request("direct:start", "c", map[string]any{"failure": "other"})
+	assert.ErrorContains(t, err, "invalid message")
+	assert.Equal(t, "partial", exchange.GetOut().GetBody())
+
+	backup.AssertIsSatisfied(t, time.Second)
+}
+
+func TestLoadBalance_FailoverAttempts(t *testing.T) {
+	camel := newTestContext()
+	calls := map[string]int{}
+	failing := func(name string) func(*Exchange) error {
+		return func(e *Exchange) error {
+			calls[name]++
+			return fmt.Errorf("%s unavailable", name)
+		}
+	}
+	camel.CreateRouteBuilder().
+		From("direct:limited").
+		LoadBalance().
+		Failover().
+		MaximumFailoverAttempts(4).
+		ProcessFunc(failing("a")).
+		ProcessFunc(failing("b")).
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:roundrobin").
+		LoadBalance().
+		Failover().
+		RoundRobinFailover().
+		ProcessFunc(failing("c")).
+		To("mock:d").
+		End().
+		Build()
+
+	d, _ := camel.GetMockEndpoint("mock:d")
+	d.ExpectedMessageCount(2)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	assert.ErrorContains(t, template.SendBody("direct:limited", "x"), "a unavailable")
+	assert.Equal(t, map[string]int{"a": 3, "b": 2}, calls)
+
+	// Le second échange commence directement par mock:d
+	require.NoError(t, template.SendBody("direct:roundrobin", "1"))
+	require.NoError(t, template.SendBody("direct:roundrobin", "2"))
+	assert.Equal(t, 1, calls["c"])
+	d.AssertIsSatisfied(t, time.Second)
+
+	assert.Panics(t, func() {
+		camel.CreateRouteBuilder().From("direct:other").LoadBalance().MaximumFailoverAttempts(1)
+	})
+}
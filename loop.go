@@ -0,0 +1,287 @@
+package gocamel
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Propriétés positionnées par le Loop EIP sur l'échange
+const (
+	CamelLoopIndex = "CamelLoopIndex" // Index de l'itération en cours, à partir de 0
+	CamelLoopSize  = "CamelLoopSize"  // Nombre d'itérations (mode comptage uniquement)
+)
+
+// DefaultLoopMaxIterations est le nombre maximal d'itérations d'une boucle par défaut
+const DefaultLoopMaxIterations = 10000
+
+// ErrLoopMaxIterationsExceeded est retournée lorsqu'une boucle dépasse son nombre maximal d'itérations
+var ErrLoopMaxIterationsExceeded = errors.New("loop exceeded maximum iterations")
+
+// LoopProcessor implémente le Loop EIP : le bloc est exécuté un nombre de fois calculé à
+// l'entrée de la boucle (mode comptage), ou tant qu'un prédicat est satisfait (mode doWhile,
+// le prédicat étant évalué avant chaque itération).
+//
+// Chaque itération reçoit le résultat de la précédente ; en mode copie, elle repart au contraire
+// d'une copie de l'échange tel qu'il est entré dans la boucle. Le résultat de la dernière
+// itération poursuit la route.
+type LoopProcessor struct {
+	// Count calcule le nombre d'itérations (mode comptage)
+	Count func(*Exchange) (int, error)
+	// While est le prédicat évalué avant chaque itération (mode doWhile)
+	While func(*Exchange) (bool, error)
+	// Copy fait repartir chaque itération de l'échange d'origine
+	Copy bool
+	// MaxIterations protège contre les boucles infinies ; 0 désactive la limite
+	MaxIterations int
+	processors    []Processor
+}
+
+// NewLoopProcessor crée une boucle exécutant le bloc le nombre de fois calculé par la fonction
+func NewLoopProcessor(count func(*Exchange) (int, error)) *LoopProcessor {
+	return &LoopProcessor{
+		Count:         count,
+		MaxIterations: DefaultLoopMaxIterations,
+		processors:    make([]Processor, 0),
+	}
+}
+
+// NewLoopDoWhileProcessor crée une boucle exécutant le bloc tant que le prédicat est satisfait
+func NewLoopDoWhileProcessor(predicate func(*Exchange) (bool, error)) *LoopProcessor {
+	return &LoopProcessor{
+		While:         predicate,
+		MaxIterations: DefaultLoopMaxIterations,
+		processors:    make([]Processor, 0),
+	}
+}
+
+// AddProcessor ajoute un processeur exécuté à chaque itération
+func (l *LoopProcessor) AddProcessor(processor Processor) {
+	l.processors = append(l.processors, processor)
+}
+
+// Process implémente l'interface Processor
+func (l *LoopProcessor) Process(exchange *Exchange) error {
+	promoteOut(exchange)
+
+	count := 0
+	if l.While == nil {
+		var err error
+		if count, err = l.Count(exchange); err != nil {
+			return fmt.Errorf("loop count expression error: %w", err)
+		}
+		if l.MaxIterations > 0 && count > l.MaxIterations {
+			return fmt.Errorf("%w: %d iterations requested, maximum is %d", ErrLoopMaxIterationsExceeded, count, l.MaxIterations)
+		}
+	}
+
+	var original *Exchange
+	if l.Copy {
+		original = exchange.Copy()
+	}
+
+	for index := 0; l.While != nil || index < count; index++ {
+		if l.While != nil {
+			matched, err := l.While(exchange)
+			if err != nil {
+				return fmt.Errorf("loop predicate error: %w", err)
+			}
+			if !matched {
+				return nil
+			}
+			if l.MaxIterations > 0 && index >= l.MaxIterations {
+				return fmt.Errorf("%w: %d", ErrLoopMaxIterationsExceeded, l.MaxIterations)
+			}
+		}
+		if err := exchangeContext(exchange).Err(); err != nil {
+			return err
+		}
+
+		if original != nil && index > 0 {
+			restart := original.Copy()
+			exchange.In = restart.In
+			exchange.Out = restart.Out
+			exchange.Properties = restart.Properties
+		}
+		exchange.SetProperty(CamelLoopIndex, index)
+		if l.While == nil {
+			exchange.SetProperty(CamelLoopSize, count)
+		}
+
+		for _, p := range l.processors {
+			if err := p.Process(exchange); err != nil {
+				return err
+			}
+		}
+		promoteOut(exchange)
+	}
+	return nil
+}
+
+// toLoopCount convertit la valeur d'une expression en nombre d'itérations
+func toLoopCount(value any) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return v, nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case []byte:
+		return toLoopCount(string(v))
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid loop count: %q", v)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("unsupported loop count type: %T", value)
+}
+
+// Loop commence un bloc Loop EIP exécuté un nombre de fois donné par une constante (ex: "3")
+// ou une expression Simple (ex: "${header.pages}"), évaluée une fois à l'entrée de la boucle
+func (b *RouteBuilder) Loop(countExpression string) *LoopDefinition {
+	if !strings.Contains(countExpression, "${") {
+		count, err := toLoopCount(countExpression)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse loop count: %v", err))
+		}
+		return b.LoopFunc(func(exchange *Exchange) (int, error) {
+			return count, nil
+		})
+	}
+
+	template, err := ParseSimpleTemplate(countExpression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for loop: %v", err))
+	}
+	expr := simpleValueExpression(template)
+	return b.LoopFunc(func(exchange *Exchange) (int, error) {
+		value, err := expr(exchange)
+		if err != nil {
+			return 0, err
+		}
+		return toLoopCount(value)
+	})
+}
+
+// LoopFunc commence un bloc Loop EIP dont le nombre d'itérations est calculé par une fonction Go
+func (b *RouteBuilder) LoopFunc(count func(*Exchange) (int, error)) *LoopDefinition {
+	return b.addLoop(NewLoopProcessor(count))
+}
+
+// LoopDoWhile commence un bloc Loop EIP exécuté tant que le prédicat Simple est satisfait
+// (ex: "${header.next}")
+func (b *RouteBuilder) LoopDoWhile(predicate string) *LoopDefinition {
+	template, err := ParseSimpleTemplate(predicate)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for loop: %v", err))
+	}
+	return b.addLoop(NewLoopDoWhileProcessor(template.EvaluateAsBool))
+}
+
+// LoopDoWhileFunc commence un bloc Loop EIP exécuté tant que le prédicat Go est satisfait
+func (b *RouteBuilder) LoopDoWhileFunc(predicate func(*Exchange) bool) *LoopDefinition {
+	return b.addLoop(NewLoopDoWhileProcessor(func(exchange *Exchange) (bool, error) {
+		return predicate(exchange), nil
+	}))
+}
+
+func (b *RouteBuilder) addLoop(l *LoopProcessor) *LoopDefinition {
+	b.container.AddProcessor(l)
+
+	return &LoopDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: l,
+		},
+		parent: b,
+		loop:   l,
+	}
+}
+
+// LoopDefinition permet de configurer la boucle et les processeurs exécutés à chaque itération
+type LoopDefinition struct {
+	*RouteBuilder
+	parent *RouteBuilder
+	loop   *LoopProcessor
+}
+
+// Copy fait repartir chaque itération d'une copie de l'échange tel qu'il est entré dans la boucle
+func (d *LoopDefinition) Copy() *LoopDefinition {
+	d.loop.Copy = true
+	return d
+}
+
+// MaxIterations définit le nombre maximal d'itérations ; au-delà, la boucle échoue avec
+// ErrLoopMaxIterationsExceeded. 0 désactive la limite.
+func (d *LoopDefinition) MaxIterations(maximum int) *LoopDefinition {
+	d.loop.MaxIterations = maximum
+	return d
+}
+
+// Process ajoute un processeur et reste dans le contexte de la boucle
+func (d *LoopDefinition) Process(processor Processor) *LoopDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte de la boucle
+func (d *LoopDefinition) ProcessFunc(f func(*Exchange) error) *LoopDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte de la boucle
+func (d *LoopDefinition) To(uris ...string) *LoopDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD ajoute un ou plusieurs endpoints dynamiques et reste dans le contexte de la boucle
+func (d *LoopDefinition) ToD(uriTemplates ...string) *LoopDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody définit le corps du message de sortie et reste dans le contexte de la boucle
+func (d *LoopDefinition) SetBody(body interface{}) *LoopDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte de la boucle
+func (d *LoopDefinition) SetHeader(key string, value interface{}) *LoopDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetProperty définit une propriété de l'échange et reste dans le contexte de la boucle
+func (d *LoopDefinition) SetProperty(key string, value any) *LoopDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// RemoveHeader supprime un en-tête du message entrant et reste dans le contexte de la boucle
+func (d *LoopDefinition) RemoveHeader(name string) *LoopDefinition {
+	d.RouteBuilder.RemoveHeader(name)
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte de la boucle
+func (d *LoopDefinition) Log(message string) *LoopDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End termine le bloc Loop et revient au builder parent
+func (d *LoopDefinition) End() *RouteBuilder {
+	return d.parent
+}
@@ -0,0 +1,158 @@
+package gocamel
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLoopContext() *CamelContext {
+	camel := newTestContext()
+	camel.AddComponent("http", NewHTTPComponent())
+	return camel
+}
+
+// appendToBody ajoute un suffixe au corps du message
+func appendToBody(suffix string) func(*Exchange) error {
+	return func(e *Exchange) error {
+		e.GetOut().SetBody(fmt.Sprint(e.GetIn().GetBody()) + suffix)
+		return nil
+	}
+}
+
+func TestLoop_Count(t *testing.T) {
+	camel := newLoopContext()
+	camel.CreateRouteBuilder().
+		From("direct:constant").
+		Loop("3").
+		ProcessFunc(appendToBody("x")).
+		To("mock:iteration").
+		End().
+		To("mock:result").
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:copy").
+		Loop("${header.times}").
+		Copy().
+		ProcessFunc(appendToBody("y")).
+		End().
+		To("mock:result").
+		Build()
+
+	iteration, _ := camel.GetMockEndpoint("mock:iteration")
+	iteration.ExpectedBodiesReceived("ax", "axx", "axxx")
+	for i := 0; i < 3; i++ {
+		iteration.MessageN(i).Predicate(func(e *Exchange) bool {
+			index, _ := e.GetPropertyAsInt(CamelLoopIndex)
+			size, _ := e.GetPropertyAsInt(CamelLoopSize)
+			return index == i && size == 3
+		})
+	}
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("axxx", "by")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:constant", "a"))
+	require.NoError(t, template.SendBodyAndHeaders("direct:copy", "b", map[string]any{"times": "4"}))
+
+	iteration.AssertIsSatisfied(t, time.Second)
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestLoop_DoWhilePaging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 3 {
+			w.Header().Set("X-Next-Page", fmt.Sprintf("http://%s/items?page=%d", r.Host, page+1))
+		}
+		fmt.Fprintf(w, "items of page %d", page)
+	}))
+	defer server.Close()
+
+	camel := newLoopContext()
+	camel.CreateRouteBuilder().
+		From("direct:sync").
+		LoopDoWhile("${header.next}").
+		ToD("${header.next}").
+		ProcessFunc(func(e *Exchange) error {
+			next, _ := e.GetOut().GetHeaderAsString("X-Next-Page")
+			if next == "" {
+				e.GetIn().RemoveHeader("next")
+				return nil
+			}
+			e.GetOut().SetHeader("next", next)
+			return nil
+		}).
+		To("mock:page").
+		End().
+		To("mock:result").
+		Build()
+
+	page, _ := camel.GetMockEndpoint("mock:page")
+	page.ExpectedBodiesReceived([]byte("items of page 1"), []byte("items of page 2"), []byte("items of page 3"))
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedMessageCount(1)
+	result.MessageN(0).Predicate(func(e *Exchange) bool {
+		index, _ := e.GetPropertyAsInt(CamelLoopIndex)
+		_, hasSize := e.GetProperty(CamelLoopSize)
+		return index == 2 && !hasSize
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	err := camel.CreateProducerTemplate().SendBodyAndHeaders("direct:sync", nil, map[string]any{"next": server.URL + "/items?page=1"})
+	require.NoError(t, err)
+
+	page.AssertIsSatisfied(t, time.Second)
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestLoop_MaxIterations(t *testing.T) {
+	camel := newLoopContext()
+	calls := 0
+	camel.CreateRouteBuilder().
+		From("direct:forever").
+		LoopDoWhileFunc(func(e *Exchange) bool { return true }).
+		MaxIterations(5).
+		ProcessFunc(func(e *Exchange) error {
+			calls++
+			return nil
+		}).
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:count").
+		Loop("${header.times}").
+		MaxIterations(10).
+		ProcessFunc(func(e *Exchange) error {
+			calls++
+			return nil
+		}).
+		End().
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	assert.ErrorIs(t, template.SendBody("direct:forever", "x"), ErrLoopMaxIterationsExceeded)
+	assert.Equal(t, 5, calls)
+
+	assert.ErrorIs(t, template.SendBodyAndHeaders("direct:count", "x", map[string]any{"times": 11}), ErrLoopMaxIterationsExceeded)
+	assert.Equal(t, 5, calls)
+	assert.ErrorContains(t, template.SendBodyAndHeaders("direct:count", "x", map[string]any{"times": "many"}), "invalid loop count")
+
+	assert.PanicsWithValue(t, `failed to parse loop count: invalid loop count: "three"`, func() {
+		camel.CreateRouteBuilder().From("direct:other").Loop("three")
+	})
+}
@@ -1103,7 +1103,9 @@ func (c *MailConsumer) processMessage(ctx context.Context, uid imap.UID, selecte
 	}
 
 	// Traitement par le processor
-	if err := c.processor.Process(exchange); err != nil {
+	err = c.processor.Process(exchange)
+	exchange.Done(err)
+	if err != nil {
 		// Echec du traitement - rollback si peek=true
 		if ep.peek {
 			if err2 := c.markUnseen(uid); err2 != nil {
@@ -1973,7 +1975,9 @@ func (c *Pop3Consumer) processMessage(ctx context.Context, msgID int) error {
 	c.populateExchange(exchange, mailMsg)
 
 	// Traitement par le processor
-	if err := c.processor.Process(exchange); err != nil {
+	err = c.processor.Process(exchange)
+	exchange.Done(err)
+	if err != nil {
 		return err
 	}
 
@@ -3,23 +3,52 @@ package gocamel
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // ManagementServer expose une API REST pour monitorer et contrôler les routes
 type ManagementServer struct {
-	context *CamelContext
-	server  *http.Server
+	context        *CamelContext
+	server         *http.Server
+	authenticators []ManagementAuthenticator
+	tlsOptions     *ManagementTLSOptions
+	auditLogger    *log.Logger
+	failures       *failureHistory
 }
 
 // NewManagementServer crée une nouvelle instance de ManagementServer
 func NewManagementServer(context *CamelContext) *ManagementServer {
+	failures := newFailureHistory(defaultFailureHistorySize)
+	context.AddEventNotifier(failures)
 	return &ManagementServer{
-		context: context,
+		context:     context,
+		auditLogger: log.Default(),
+		failures:    failures,
 	}
 }
 
+// AddAuthenticator ajoute un mécanisme d'authentification. Dès qu'un authentificateur
+// est configuré, toute requête non authentifiée est refusée.
+func (m *ManagementServer) AddAuthenticator(authenticator ManagementAuthenticator) *ManagementServer {
+	m.authenticators = append(m.authenticators, authenticator)
+	return m
+}
+
+// SetTLS active HTTPS (et le mTLS si une autorité cliente est fournie)
+func (m *ManagementServer) SetTLS(options ManagementTLSOptions) *ManagementServer {
+	m.tlsOptions = &options
+	return m
+}
+
+// SetAuditLogger définit le logger du journal d'audit (nil pour le désactiver)
+func (m *ManagementServer) SetAuditLogger(logger *log.Logger) *ManagementServer {
+	m.auditLogger = logger
+	return m
+}
+
 // RouteInfo représente les informations publiques d'une route pour l'API REST
 type RouteInfo struct {
 	ID          string `json:"id"`
@@ -35,21 +64,81 @@ type ContextInfo struct {
 	StartedRoutes    int  `json:"startedRoutes"`
 }
 
-// Start démarre le serveur REST de management sur l'adresse spécifiée (ex: ":8081")
-func (m *ManagementServer) Start(addr string) error {
-	mux := http.NewServeMux()
+// InflightInfo représente un échange en cours de traitement pour l'API REST
+type InflightInfo struct {
+	ExchangeID string    `json:"exchangeId"`
+	RouteID    string    `json:"routeId"`
+	NodeID     string    `json:"nodeId,omitempty"`
+	Started    time.Time `json:"started"`
+	Elapsed    int64     `json:"elapsed"` // en millisecondes
+}
+
+// SendRequest représente une demande d'envoi d'un message de test vers un endpoint
+type SendRequest struct {
+	URI     string         `json:"uri"`
+	Body    any            `json:"body"`
+	Headers map[string]any `json:"headers,omitempty"`
+	Reply   bool           `json:"reply,omitempty"` // retourne la réponse de l'endpoint si true
+}
+
+// SendResponse représente le résultat d'un envoi de message de test
+type SendResponse struct {
+	ExchangeID string         `json:"exchangeId"`
+	Body       any            `json:"body,omitempty"`
+	Headers    map[string]any `json:"headers,omitempty"`
+}
+
+// ThrottlerInfo représente un throttler de premier niveau d'une route pour l'API REST
+type ThrottlerInfo struct {
+	RouteID     string `json:"routeId"`
+	NodeID      string `json:"nodeId"`
+	MaxRequests int    `json:"maxRequests"`
+	Period      int64  `json:"period"` // en millisecondes
+}
+
+// ThrottlerUpdateRequest représente une demande de modification de la limite d'un throttler
+type ThrottlerUpdateRequest struct {
+	MaxRequests int `json:"maxRequests"`
+}
 
-	mux.HandleFunc("/api/context", m.handleContext)
-	mux.HandleFunc("/api/routes", m.handleRoutes)
-	mux.HandleFunc("/api/routes/", m.handleRouteAction)
+// CircuitBreakerInfo représente l'état d'un circuit breaker de premier niveau d'une route pour l'API REST
+type CircuitBreakerInfo struct {
+	RouteID string `json:"routeId"`
+	NodeID  string `json:"nodeId"`
+	CircuitBreakerStats
+}
+
+// FailureInfo représente un échec récent de traitement pour l'API REST
+type FailureInfo struct {
+	ExchangeID string    `json:"exchangeId"`
+	RouteID    string    `json:"routeId"`
+	Error      string    `json:"error"`
+	Timestamp  time.Time `json:"timestamp"`
+}
 
+// Start démarre le serveur REST de management sur l'adresse spécifiée (ex: ":8081")
+func (m *ManagementServer) Start(addr string) error {
 	m.server = &http.Server{
 		Addr:    addr,
-		Handler: mux,
+		Handler: m.Handler(),
+	}
+
+	if m.tlsOptions != nil {
+		tlsConfig, err := m.tlsOptions.buildTLSConfig()
+		if err != nil {
+			return err
+		}
+		m.server.TLSConfig = tlsConfig
 	}
 
 	go func() {
-		if err := m.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if m.server.TLSConfig != nil {
+			err = m.server.ListenAndServeTLS("", "")
+		} else {
+			err = m.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			fmt.Printf("Erreur du serveur de management REST: %v\n", err)
 		}
 	}()
@@ -57,6 +146,26 @@ func (m *ManagementServer) Start(addr string) error {
 	return nil
 }
 
+// Handler retourne le handler HTTP de l'API, avec l'authentification et les rôles appliqués par endpoint
+func (m *ManagementServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/context", m.secure(RoleReadOnly, m.handleContext))
+	mux.HandleFunc("/api/routes", m.secure(RoleReadOnly, m.handleRoutes))
+	mux.HandleFunc("/api/routes/", m.secure(RoleOperator, m.handleRouteAction))
+	mux.HandleFunc("/api/endpoints/send", m.secure(RoleOperator, m.handleSend))
+	mux.HandleFunc("/api/inflight", m.secure(RoleReadOnly, m.handleInflight))
+	mux.HandleFunc("/api/failures", m.secure(RoleReadOnly, m.handleFailures))
+	mux.HandleFunc("/api/throttlers", m.secure(RoleReadOnly, m.handleThrottlers))
+	mux.HandleFunc("/api/throttlers/", m.secure(RoleOperator, m.handleThrottlerUpdate))
+	mux.HandleFunc("/api/circuitbreakers", m.secure(RoleReadOnly, m.handleCircuitBreakers))
+	mux.HandleFunc("/api/circuitbreakers/", m.secure(RoleOperator, m.handleCircuitBreakerAction))
+	mux.HandleFunc("/console", m.secure(RoleReadOnly, m.handleConsole))
+	mux.HandleFunc("/console/", m.secure(RoleReadOnly, m.handleConsole))
+
+	return mux
+}
+
 // Stop arrête le serveur REST de management
 func (m *ManagementServer) Stop() error {
 	if m.server != nil {
@@ -164,3 +273,200 @@ func (m *ManagementServer) handleStopAction(w http.ResponseWriter, route *Route)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status":"stopped"}`))
 }
+
+func (m *ManagementServer) handleSend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.URI == "" {
+		http.Error(w, "Missing endpoint uri", http.StatusBadRequest)
+		return
+	}
+
+	// Les URIs étant libres, le producteur n'est pas conservé au-delà de la requête
+	template := m.context.CreateProducerTemplate()
+	defer template.Stop()
+	exchange, err := template.Request(req.URI, req.Body, req.Headers)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	resp := SendResponse{ExchangeID: exchange.ID}
+	if req.Reply {
+		response := exchange.GetResponse()
+		resp.Body = response.GetBody()
+		if body, ok := resp.Body.([]byte); ok {
+			resp.Body = string(body)
+		}
+		resp.Headers = response.GetHeaders()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (m *ManagementServer) handleInflight(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var entries []InflightExchange
+	if routeID := r.URL.Query().Get("routeId"); routeID != "" {
+		entries = m.context.GetInflightRepository().BrowseRoute(routeID)
+	} else {
+		entries = m.context.GetInflightRepository().Browse()
+	}
+
+	inflightInfo := make([]InflightInfo, 0, len(entries))
+	for _, entry := range entries {
+		inflightInfo = append(inflightInfo, InflightInfo{
+			ExchangeID: entry.Exchange.ID,
+			RouteID:    entry.RouteID,
+			NodeID:     entry.NodeID,
+			Started:    entry.Started,
+			Elapsed:    entry.Elapsed().Milliseconds(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inflightInfo)
+}
+
+func (m *ManagementServer) handleFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.failures.list())
+}
+
+func (m *ManagementServer) handleThrottlers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	throttlers := make([]ThrottlerInfo, 0)
+	for _, route := range m.context.GetRoutes() {
+		routeThrottlers := route.throttlers()
+		for _, nodeID := range route.NodeIDs() {
+			if throttler, ok := routeThrottlers[nodeID]; ok {
+				throttlers = append(throttlers, newThrottlerInfo(route.ID, nodeID, throttler))
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(throttlers)
+}
+
+func (m *ManagementServer) handleThrottlerUpdate(w http.ResponseWriter, r *http.Request) {
+	// Attend un chemin de la forme /api/throttlers/{routeId}/{nodeId}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/throttlers/"), "/")
+	if len(parts) != 2 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	route := m.context.GetRoute(parts[0])
+	if route == nil {
+		http.Error(w, "Route not found", http.StatusNotFound)
+		return
+	}
+	throttler, ok := route.throttlers()[parts[1]]
+	if !ok {
+		http.Error(w, "Throttler not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ThrottlerUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := throttler.SetMaxRequests(req.MaxRequests); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newThrottlerInfo(route.ID, parts[1], throttler))
+}
+
+func newThrottlerInfo(routeID, nodeID string, throttler *Throttler) ThrottlerInfo {
+	return ThrottlerInfo{
+		RouteID:     routeID,
+		NodeID:      nodeID,
+		MaxRequests: throttler.MaxRequests(),
+		Period:      throttler.Period().Milliseconds(),
+	}
+}
+
+func (m *ManagementServer) handleCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	breakers := make([]CircuitBreakerInfo, 0)
+	for _, route := range m.context.GetRoutes() {
+		routeBreakers := route.circuitBreakers()
+		for _, nodeID := range route.NodeIDs() {
+			if breaker, ok := routeBreakers[nodeID]; ok {
+				breakers = append(breakers, CircuitBreakerInfo{RouteID: route.ID, NodeID: nodeID, CircuitBreakerStats: breaker.Stats()})
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(breakers)
+}
+
+func (m *ManagementServer) handleCircuitBreakerAction(w http.ResponseWriter, r *http.Request) {
+	// Attend un chemin de la forme /api/circuitbreakers/{routeId}/{nodeId}/reset
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/circuitbreakers/"), "/")
+	if len(parts) != 3 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	route := m.context.GetRoute(parts[0])
+	if route == nil {
+		http.Error(w, "Route not found", http.StatusNotFound)
+		return
+	}
+	breaker, ok := route.circuitBreakers()[parts[1]]
+	if !ok {
+		http.Error(w, "Circuit breaker not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if parts[2] != "reset" {
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+
+	breaker.Reset()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CircuitBreakerInfo{RouteID: route.ID, NodeID: parts[1], CircuitBreakerStats: breaker.Stats()})
+}
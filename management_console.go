@@ -0,0 +1,79 @@
+package gocamel
+
+import (
+	"embed"
+	"net/http"
+	"sync"
+)
+
+// consoleAssets contient la console web embarquée servie sur /console.
+// Elle ne charge aucune ressource externe : HTML, CSS et JavaScript sont dans un seul fichier.
+//
+//go:embed console/index.html
+var consoleAssets embed.FS
+
+// defaultFailureHistorySize est le nombre d'échecs récents conservés pour la console
+const defaultFailureHistorySize = 50
+
+// failureHistory conserve les derniers échecs de traitement signalés par le contexte
+type failureHistory struct {
+	failures []FailureInfo
+	size     int
+	mu       sync.Mutex
+}
+
+// newFailureHistory crée un historique conservant au plus size échecs
+func newFailureHistory(size int) *failureHistory {
+	return &failureHistory{
+		failures: make([]FailureInfo, 0, size),
+		size:     size,
+	}
+}
+
+// Notify implémente EventNotifier en enregistrant les échecs des routes
+func (h *failureHistory) Notify(event ExchangeEvent) {
+	// Un échec dans une route imbriquée remonte aussi dans la route appelante : seul le premier est conservé
+	if event.Type != ExchangeFailedEvent || event.Nested {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.failures) == h.size {
+		h.failures = h.failures[1:]
+	}
+	h.failures = append(h.failures, FailureInfo{
+		ExchangeID: event.Exchange.ID,
+		RouteID:    event.RouteID,
+		Error:      event.Error.Error(),
+		Timestamp:  event.Timestamp,
+	})
+}
+
+// list retourne les échecs du plus récent au plus ancien
+func (h *failureHistory) list() []FailureInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := make([]FailureInfo, 0, len(h.failures))
+	for i := len(h.failures) - 1; i >= 0; i-- {
+		result = append(result, h.failures[i])
+	}
+	return result
+}
+
+func (m *ManagementServer) handleConsole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page, err := consoleAssets.ReadFile("console/index.html")
+	if err != nil {
+		http.Error(w, "Console not available", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline'; script-src 'self' 'unsafe-inline'")
+	w.Write(page)
+}
@@ -0,0 +1,289 @@
+package gocamel
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ManagementRole définit le niveau d'accès accordé à un appelant de l'API de management
+type ManagementRole int
+
+const (
+	// RoleReadOnly autorise uniquement la consultation (contexte, routes, échanges en cours)
+	RoleReadOnly ManagementRole = iota
+	// RoleOperator autorise en plus les appels modifiant l'état (démarrage/arrêt de routes, envoi de messages)
+	RoleOperator
+)
+
+// String retourne le nom du rôle
+func (r ManagementRole) String() string {
+	switch r {
+	case RoleReadOnly:
+		return "read-only"
+	case RoleOperator:
+		return "operator"
+	default:
+		return fmt.Sprintf("role(%d)", int(r))
+	}
+}
+
+// ErrInvalidCredentials est retournée lorsque les identifiants fournis sont invalides
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ManagementPrincipal représente l'appelant authentifié d'une requête de management
+type ManagementPrincipal struct {
+	Name string
+	Role ManagementRole
+}
+
+// ManagementAuthenticator authentifie les requêtes adressées au ManagementServer.
+type ManagementAuthenticator interface {
+	// Authenticate retourne le principal associé à la requête.
+	// Elle retourne (nil, nil) si la requête ne contient pas d'identifiants pris en charge
+	// par cet authentificateur, et une erreur si les identifiants fournis sont invalides.
+	Authenticate(r *http.Request) (*ManagementPrincipal, error)
+}
+
+// BearerTokenAuthenticator authentifie les requêtes portant un en-tête "Authorization: Bearer <token>"
+type BearerTokenAuthenticator struct {
+	tokens map[string]ManagementPrincipal
+	mu     sync.RWMutex
+}
+
+// NewBearerTokenAuthenticator crée un authentificateur par jetons statiques
+func NewBearerTokenAuthenticator() *BearerTokenAuthenticator {
+	return &BearerTokenAuthenticator{
+		tokens: make(map[string]ManagementPrincipal),
+	}
+}
+
+// AddToken enregistre un jeton statique associé à un nom et un rôle
+func (a *BearerTokenAuthenticator) AddToken(token, name string, role ManagementRole) *BearerTokenAuthenticator {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens[token] = ManagementPrincipal{Name: name, Role: role}
+	return a
+}
+
+// Authenticate implémente ManagementAuthenticator
+func (a *BearerTokenAuthenticator) Authenticate(r *http.Request) (*ManagementPrincipal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, nil
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	// Comparaison en temps constant pour ne pas divulguer d'information sur les jetons valides
+	for candidate, principal := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			p := principal
+			return &p, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// basicUser représente un utilisateur de l'authentification basique
+type basicUser struct {
+	passwordHash []byte
+	role         ManagementRole
+}
+
+// BasicAuthenticator authentifie les requêtes HTTP Basic à partir de mots de passe hachés avec bcrypt
+type BasicAuthenticator struct {
+	users map[string]basicUser
+	mu    sync.RWMutex
+}
+
+// NewBasicAuthenticator crée un authentificateur HTTP Basic
+func NewBasicAuthenticator() *BasicAuthenticator {
+	return &BasicAuthenticator{
+		users: make(map[string]basicUser),
+	}
+}
+
+// AddUser enregistre un utilisateur avec le hash bcrypt de son mot de passe
+func (a *BasicAuthenticator) AddUser(username, bcryptHash string, role ManagementRole) *BasicAuthenticator {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.users[username] = basicUser{passwordHash: []byte(bcryptHash), role: role}
+	return a
+}
+
+// Authenticate implémente ManagementAuthenticator
+func (a *BasicAuthenticator) Authenticate(r *http.Request) (*ManagementPrincipal, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+
+	a.mu.RLock()
+	user, exists := a.users[username]
+	a.mu.RUnlock()
+	if !exists {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.passwordHash, []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &ManagementPrincipal{Name: username, Role: user.role}, nil
+}
+
+// ClientCertAuthenticator authentifie les requêtes à partir du Common Name (CN)
+// du certificat client vérifié lors de la poignée de main mTLS
+type ClientCertAuthenticator struct {
+	clients map[string]ManagementRole
+	mu      sync.RWMutex
+}
+
+// NewClientCertAuthenticator crée un authentificateur par certificat client
+func NewClientCertAuthenticator() *ClientCertAuthenticator {
+	return &ClientCertAuthenticator{
+		clients: make(map[string]ManagementRole),
+	}
+}
+
+// AddClient associe un rôle au CN d'un certificat client
+func (a *ClientCertAuthenticator) AddClient(commonName string, role ManagementRole) *ClientCertAuthenticator {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.clients[commonName] = role
+	return a
+}
+
+// Authenticate implémente ManagementAuthenticator
+func (a *ClientCertAuthenticator) Authenticate(r *http.Request) (*ManagementPrincipal, error) {
+	// Seuls les certificats vérifiés par la configuration TLS du serveur sont pris en compte
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	commonName := r.TLS.VerifiedChains[0][0].Subject.CommonName
+
+	a.mu.RLock()
+	role, exists := a.clients[commonName]
+	a.mu.RUnlock()
+	if !exists {
+		return nil, ErrInvalidCredentials
+	}
+	return &ManagementPrincipal{Name: commonName, Role: role}, nil
+}
+
+// ManagementTLSOptions contient les options TLS du ManagementServer.
+type ManagementTLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile active le mTLS : les certificats clients sont vérifiés avec ces autorités
+	ClientCAFile string
+	// RequireClientCert refuse les connexions sans certificat client valide
+	RequireClientCert bool
+}
+
+// buildTLSConfig construit la configuration TLS du serveur à partir des options
+func (o ManagementTLSOptions) buildTLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load management server certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if o.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(o.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificate found in client CA file %s", o.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if o.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return config, nil
+}
+
+// statusRecorder capture le code de statut HTTP pour le journal d'audit
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// authenticate identifie l'appelant de la requête avec les authentificateurs configurés.
+// Sans authentificateur, l'API reste ouverte et l'appelant est considéré comme opérateur anonyme.
+func (m *ManagementServer) authenticate(r *http.Request) (*ManagementPrincipal, error) {
+	if len(m.authenticators) == 0 {
+		return &ManagementPrincipal{Name: "anonymous", Role: RoleOperator}, nil
+	}
+
+	for _, authenticator := range m.authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+		if principal != nil {
+			return principal, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// secure protège un handler en exigeant le rôle donné. Les appels exigeant le rôle
+// opérateur modifient l'état du contexte et sont systématiquement tracés dans le journal d'audit.
+func (m *ManagementServer) secure(role ManagementRole, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := m.authenticate(r)
+		if err != nil {
+			m.audit(r, nil, http.StatusUnauthorized, role)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gocamel", Basic realm="gocamel"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if principal.Role < role {
+			m.audit(r, principal, http.StatusForbidden, role)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+		m.audit(r, principal, recorder.status, role)
+	}
+}
+
+// audit trace un appel dans le journal d'audit s'il s'agit d'un appel modifiant l'état
+func (m *ManagementServer) audit(r *http.Request, principal *ManagementPrincipal, status int, role ManagementRole) {
+	if m.auditLogger == nil || role < RoleOperator {
+		return
+	}
+	name := "-"
+	if principal != nil {
+		name = principal.Name
+	}
+	m.auditLogger.Printf("[audit] principal=%s remote=%s method=%s path=%s status=%d",
+		name, r.RemoteAddr, r.Method, r.URL.Path, status)
+}
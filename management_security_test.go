@@ -0,0 +1,236 @@
+package gocamel
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newSecuredTestServer(t *testing.T) (*ManagementServer, *Route, *bytes.Buffer) {
+	t.Helper()
+	ctx := NewCamelContext()
+	ctx.AddComponent("stub", &stubComponent{})
+	route := ctx.CreateRoute()
+	route.ID = "secured-route"
+	route.From("stub:source")
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	var auditLog bytes.Buffer
+	mgmt := NewManagementServer(ctx).
+		AddAuthenticator(NewBearerTokenAuthenticator().
+			AddToken("viewer-token", "viewer", RoleReadOnly).
+			AddToken("operator-token", "ops", RoleOperator)).
+		AddAuthenticator(NewBasicAuthenticator().AddUser("admin", string(hash), RoleOperator)).
+		SetAuditLogger(log.New(&auditLog, "", 0))
+	return mgmt, route, &auditLog
+}
+
+func TestManagementServer_Authentication(t *testing.T) {
+	mgmt, _, _ := newSecuredTestServer(t)
+	handler := mgmt.Handler()
+
+	tests := []struct {
+		name     string
+		setup    func(r *http.Request)
+		expected int
+	}{
+		{"no credentials", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"invalid token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized},
+		{"valid token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer viewer-token") }, http.StatusOK},
+		{"invalid password", func(r *http.Request) { r.SetBasicAuth("admin", "wrong") }, http.StatusUnauthorized},
+		{"unknown user", func(r *http.Request) { r.SetBasicAuth("nobody", "s3cret") }, http.StatusUnauthorized},
+		{"valid password", func(r *http.Request) { r.SetBasicAuth("admin", "s3cret") }, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/routes", nil)
+			tt.setup(req)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestManagementServer_RolesAndAudit(t *testing.T) {
+	mgmt, route, auditLog := newSecuredTestServer(t)
+	handler := mgmt.Handler()
+
+	// Un utilisateur en lecture seule ne peut pas démarrer une route
+	req := httptest.NewRequest(http.MethodPost, "/api/routes/secured-route/start", nil)
+	req.Header.Set("Authorization", "Bearer viewer-token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status Forbidden for read-only role, got %v", w.Code)
+	}
+	if route.IsStarted() {
+		t.Errorf("Expected route to stay stopped")
+	}
+
+	// Un opérateur peut démarrer la route
+	req = httptest.NewRequest(http.MethodPost, "/api/routes/secured-route/start", nil)
+	req.Header.Set("Authorization", "Bearer operator-token")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status OK for operator role, got %v: %s", w.Code, w.Body.String())
+	}
+	if !route.IsStarted() {
+		t.Errorf("Expected route to be started")
+	}
+
+	audit := auditLog.String()
+	if !strings.Contains(audit, "principal=viewer") || !strings.Contains(audit, "status=403") {
+		t.Errorf("Expected forbidden call in audit log, got %q", audit)
+	}
+	if !strings.Contains(audit, "principal=ops") || !strings.Contains(audit, "method=POST path=/api/routes/secured-route/start status=200") {
+		t.Errorf("Expected successful start in audit log, got %q", audit)
+	}
+
+	// Les lectures ne sont pas tracées
+	auditLog.Reset()
+	req = httptest.NewRequest(http.MethodGet, "/api/context", nil)
+	req.Header.Set("Authorization", "Bearer viewer-token")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if auditLog.Len() != 0 {
+		t.Errorf("Expected read-only calls not to be audited, got %q", auditLog.String())
+	}
+}
+
+// writeTestCertificate génère un certificat signé par parent (auto-signé si parent est nil)
+func writeTestCertificate(t *testing.T, dir, name string, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func TestManagementServer_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	notAfter := time.Now().Add(time.Hour)
+
+	ca, caKey := writeTestCertificate(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	writeTestCertificate(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	writeTestCertificate(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "deploy-bot"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	mgmt := NewManagementServer(NewCamelContext()).
+		SetTLS(ManagementTLSOptions{
+			CertFile:          filepath.Join(dir, "server.crt"),
+			KeyFile:           filepath.Join(dir, "server.key"),
+			ClientCAFile:      filepath.Join(dir, "ca.crt"),
+			RequireClientCert: true,
+		}).
+		AddAuthenticator(NewClientCertAuthenticator().AddClient("deploy-bot", RoleReadOnly)).
+		SetAuditLogger(nil)
+
+	tlsConfig, err := mgmt.tlsOptions.buildTLSConfig()
+	if err != nil {
+		t.Fatalf("Failed to build TLS config: %v", err)
+	}
+	server := httptest.NewUnstartedServer(mgmt.Handler())
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	clientCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+	if err != nil {
+		t.Fatalf("Failed to load client certificate: %v", err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{clientCert},
+		ServerName:   "localhost",
+	}}}
+	resp, err := client.Get(server.URL + "/api/context")
+	if err != nil {
+		t.Fatalf("Request with client certificate failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status OK with client certificate, got %v", resp.StatusCode)
+	}
+
+	// Le rôle associé au CN est en lecture seule
+	resp, err = client.Post(server.URL+"/api/routes/any/stop", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status Forbidden for read-only client, got %v", resp.StatusCode)
+	}
+
+	// Sans certificat client, la poignée de main échoue
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost"}}}
+	if resp, err := anonymous.Get(server.URL + "/api/context"); err == nil {
+		resp.Body.Close()
+		t.Errorf("Expected TLS handshake failure without client certificate")
+	}
+}
@@ -3,9 +3,12 @@ package gocamel
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestManagementServer_ContextInfo(t *testing.T) {
@@ -60,44 +63,44 @@ func TestManagementServer_Routes(t *testing.T) {
 	}
 }
 
-// MockEndpoint is a dummy endpoint for testing
-type MockEndpoint struct {
+// stubEndpoint is a dummy endpoint for testing
+type stubEndpoint struct {
 	uri string
 }
 
-func (e *MockEndpoint) URI() string { return e.uri }
-func (e *MockEndpoint) CreateProducer() (Producer, error) {
-	return &MockProducer{}, nil
+func (e *stubEndpoint) URI() string { return e.uri }
+func (e *stubEndpoint) CreateProducer() (Producer, error) {
+	return &stubProducer{}, nil
 }
-func (e *MockEndpoint) CreateConsumer(p Processor) (Consumer, error) {
-	return &MockConsumer{}, nil
+func (e *stubEndpoint) CreateConsumer(p Processor) (Consumer, error) {
+	return &stubConsumer{}, nil
 }
 
-type MockProducer struct{}
+type stubProducer struct{}
 
-func (p *MockProducer) Start(ctx context.Context) error { return nil }
-func (p *MockProducer) Stop() error                     { return nil }
-func (p *MockProducer) Send(e *Exchange) error          { return nil }
+func (p *stubProducer) Start(ctx context.Context) error { return nil }
+func (p *stubProducer) Stop() error                     { return nil }
+func (p *stubProducer) Send(e *Exchange) error          { return nil }
 
-type MockConsumer struct{}
+type stubConsumer struct{}
 
-func (c *MockConsumer) Start(ctx context.Context) error { return nil }
-func (c *MockConsumer) Stop() error                     { return nil }
+func (c *stubConsumer) Start(ctx context.Context) error { return nil }
+func (c *stubConsumer) Stop() error                     { return nil }
 
-type MockComponent struct{}
+type stubComponent struct{}
 
-func (c *MockComponent) CreateEndpoint(uri string) (Endpoint, error) {
-	return &MockEndpoint{uri: uri}, nil
+func (c *stubComponent) CreateEndpoint(uri string) (Endpoint, error) {
+	return &stubEndpoint{uri: uri}, nil
 }
 
 func TestManagementServer_RouteAction(t *testing.T) {
 	ctx := NewCamelContext()
-	ctx.AddComponent("mock", &MockComponent{})
+	ctx.AddComponent("stub", &stubComponent{})
 	mgmt := NewManagementServer(ctx)
 
 	route := ctx.CreateRoute()
 	route.ID = "test-route"
-	route.From("mock:source")
+	route.From("stub:source")
 
 	// Test Start
 	req := httptest.NewRequest(http.MethodPost, "/api/routes/test-route/start", nil)
@@ -143,3 +146,276 @@ func TestManagementServer_RouteAction(t *testing.T) {
 		t.Errorf("Expected status NotFound for unknown route, got %v", w.Code)
 	}
 }
+
+func TestManagementServer_Send(t *testing.T) {
+	ctx := NewCamelContext()
+	ctx.AddComponent("direct", NewDirectComponent())
+	mgmt := NewManagementServer(ctx)
+
+	ctx.CreateRouteBuilder().
+		From("direct:echo").
+		SetID("echo").
+		ProcessFunc(func(e *Exchange) error {
+			body, _ := e.GetIn().GetBodyAsString()
+			e.GetOut().SetBody("echo: " + body)
+			e.GetOut().SetHeader("X-Test", e.GetIn().Headers["X-Test"])
+			return nil
+		}).
+		Build()
+	if err := ctx.Start(); err != nil {
+		t.Fatalf("Failed to start context: %v", err)
+	}
+	defer ctx.Stop()
+
+	payload := `{"uri":"direct:echo","body":"ping","headers":{"X-Test":"yes"},"reply":true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/endpoints/send", strings.NewReader(payload))
+	w := httptest.NewRecorder()
+	mgmt.handleSend(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+
+	var resp SendResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.ExchangeID == "" {
+		t.Errorf("Expected an exchange id")
+	}
+	if resp.Body != "echo: ping" {
+		t.Errorf("Expected reply body 'echo: ping', got %v", resp.Body)
+	}
+	if resp.Headers["X-Test"] != "yes" {
+		t.Errorf("Expected reply header X-Test=yes, got %v", resp.Headers)
+	}
+
+	// Sans reply, seul l'ID de l'échange est retourné
+	req = httptest.NewRequest(http.MethodPost, "/api/endpoints/send", strings.NewReader(`{"uri":"direct:echo","body":"ping"}`))
+	w = httptest.NewRecorder()
+	mgmt.handleSend(w, req)
+	resp = SendResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp.Body != nil {
+		t.Errorf("Expected no reply body, got %v (%v)", resp.Body, w.Code)
+	}
+
+	// Endpoint inconnu
+	req = httptest.NewRequest(http.MethodPost, "/api/endpoints/send", strings.NewReader(`{"uri":"unknown:foo"}`))
+	w = httptest.NewRecorder()
+	mgmt.handleSend(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status InternalServerError for unknown component, got %v", w.Code)
+	}
+
+	// URI manquante
+	req = httptest.NewRequest(http.MethodPost, "/api/endpoints/send", strings.NewReader(`{"body":"ping"}`))
+	w = httptest.NewRecorder()
+	mgmt.handleSend(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status BadRequest for missing uri, got %v", w.Code)
+	}
+}
+
+func TestManagementServer_Inflight(t *testing.T) {
+	ctx := NewCamelContext()
+	ctx.AddComponent("direct", NewDirectComponent())
+	mgmt := NewManagementServer(ctx)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	ctx.CreateRouteBuilder().
+		From("direct:slow").
+		SetID("slow").
+		SetHeader("step", "first").
+		ProcessFunc(func(e *Exchange) error {
+			close(entered)
+			<-release
+			return nil
+		}).
+		Build()
+	if err := ctx.Start(); err != nil {
+		t.Fatalf("Failed to start context: %v", err)
+	}
+	defer ctx.Stop()
+
+	done := make(chan error)
+	go func() {
+		done <- ctx.CreateProducerTemplate().SendBody("direct:slow", "payload")
+	}()
+	<-entered
+
+	req := httptest.NewRequest(http.MethodGet, "/api/inflight?routeId=slow", nil)
+	w := httptest.NewRecorder()
+	mgmt.handleInflight(w, req)
+
+	var inflight []InflightInfo
+	if err := json.NewDecoder(w.Body).Decode(&inflight); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(inflight) != 1 {
+		t.Fatalf("Expected 1 in-flight exchange, got %v", inflight)
+	}
+	if inflight[0].RouteID != "slow" || inflight[0].NodeID != "node-2" || inflight[0].ExchangeID == "" {
+		t.Errorf("Unexpected in-flight info: %+v", inflight[0])
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if size := ctx.GetInflightRepository().Size(); size != 0 {
+		t.Errorf("Expected no in-flight exchanges after completion, got %d", size)
+	}
+}
+
+func TestManagementServer_FailuresAndConsole(t *testing.T) {
+	ctx := NewCamelContext()
+	ctx.AddComponent("direct", NewDirectComponent())
+	mgmt := NewManagementServer(ctx)
+
+	ctx.CreateRouteBuilder().
+		From("direct:fail").
+		SetID("failing").
+		ProcessFunc(func(e *Exchange) error {
+			return fmt.Errorf("boom")
+		}).
+		Build()
+	if err := ctx.Start(); err != nil {
+		t.Fatalf("Failed to start context: %v", err)
+	}
+	defer ctx.Stop()
+
+	if err := ctx.CreateProducerTemplate().SendBody("direct:fail", "payload"); err == nil {
+		t.Fatalf("Expected route failure")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/failures", nil)
+	w := httptest.NewRecorder()
+	mgmt.Handler().ServeHTTP(w, req)
+
+	var failures []FailureInfo
+	if err := json.NewDecoder(w.Body).Decode(&failures); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(failures) != 1 || failures[0].RouteID != "failing" || failures[0].Error != "boom" {
+		t.Errorf("Unexpected failures: %+v", failures)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/console", nil)
+	w = httptest.NewRecorder()
+	mgmt.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK for console, got %v", w.Code)
+	}
+	page := w.Body.String()
+	if !strings.Contains(page, "GoCamel Console") || !strings.Contains(page, "/api/endpoints/send") {
+		t.Errorf("Unexpected console content")
+	}
+	if strings.Contains(page, "http://") || strings.Contains(page, "https://") {
+		t.Errorf("Console must not reference external assets")
+	}
+}
+
+func TestManagementServer_Throttlers(t *testing.T) {
+	ctx := NewCamelContext()
+	ctx.AddComponent("direct", NewDirectComponent())
+	mgmt := NewManagementServer(ctx)
+
+	throttle := ctx.CreateRouteBuilder().
+		From("direct:api").
+		SetID("api").
+		Throttle(10, time.Second)
+	throttle.NodeID("quota")
+	throttle.End().Build()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/throttlers", nil)
+	w := httptest.NewRecorder()
+	mgmt.handleThrottlers(w, req)
+
+	var throttlers []ThrottlerInfo
+	if err := json.NewDecoder(w.Body).Decode(&throttlers); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := ThrottlerInfo{RouteID: "api", NodeID: "quota", MaxRequests: 10, Period: 1000}
+	if len(throttlers) != 1 || throttlers[0] != expected {
+		t.Fatalf("Unexpected throttlers: %+v", throttlers)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/throttlers/api/quota", strings.NewReader(`{"maxRequests":3}`))
+	w = httptest.NewRecorder()
+	mgmt.handleThrottlerUpdate(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if max := throttle.Throttler().MaxRequests(); max != 3 {
+		t.Errorf("Expected maximum requests to be 3, got %d", max)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/throttlers/api/quota", strings.NewReader(`{"maxRequests":0}`))
+	w = httptest.NewRecorder()
+	mgmt.handleThrottlerUpdate(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status BadRequest, got %v", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/throttlers/api/unknown", strings.NewReader(`{"maxRequests":3}`))
+	w = httptest.NewRecorder()
+	mgmt.handleThrottlerUpdate(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status NotFound, got %v", w.Code)
+	}
+}
+
+func TestManagementServer_CircuitBreakers(t *testing.T) {
+	ctx := NewCamelContext()
+	ctx.AddComponent("direct", NewDirectComponent())
+	mgmt := NewManagementServer(ctx)
+
+	cb := ctx.CreateRouteBuilder().
+		From("direct:partner").
+		SetID("partner").
+		CircuitBreaker().
+		MinimumNumberOfCalls(1).
+		ProcessFunc(func(e *Exchange) error {
+			return fmt.Errorf("partner down")
+		})
+	cb.NodeID("partner-cb")
+	cb.End().Build()
+	if err := ctx.Start(); err != nil {
+		t.Fatalf("Failed to start context: %v", err)
+	}
+	defer ctx.Stop()
+	ctx.CreateProducerTemplate().SendBody("direct:partner", "x")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/circuitbreakers", nil)
+	w := httptest.NewRecorder()
+	mgmt.handleCircuitBreakers(w, req)
+
+	var breakers []CircuitBreakerInfo
+	if err := json.NewDecoder(w.Body).Decode(&breakers); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(breakers) != 1 || breakers[0].NodeID != "partner-cb" || breakers[0].State != CircuitOpen {
+		t.Fatalf("Unexpected circuit breakers: %+v", breakers)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/circuitbreakers/partner/partner-cb/reset", nil)
+	w = httptest.NewRecorder()
+	mgmt.handleCircuitBreakerAction(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+	if state := cb.CircuitBreaker().State(); state != CircuitClosed {
+		t.Errorf("Expected circuit to be closed after reset, got %s", state)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/circuitbreakers/partner/partner-cb/open", nil)
+	w = httptest.NewRecorder()
+	mgmt.handleCircuitBreakerAction(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status BadRequest, got %v", w.Code)
+	}
+}
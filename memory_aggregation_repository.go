@@ -3,18 +3,39 @@ package gocamel
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 // MemoryAggregationRepository is an in-memory implementation of AggregationRepository.
+// It also implements RecoverableAggregationRepository: the completed exchanges are redelivered
+// after a downstream failure, but are lost when the application stops.
 type MemoryAggregationRepository struct {
-	mu    sync.RWMutex
-	store map[string]*Exchange
+	mu        sync.RWMutex
+	store     map[string]*Exchange
+	completed map[string]*completedExchange
+}
+
+// completedExchange is a completed exchange with its number of redeliveries and the time of
+// its completion or of its last redelivery
+type completedExchange struct {
+	exchange     *Exchange
+	redeliveries int
+	updated      time.Time
+}
+
+// recoverable tells whether the completed exchange can be recovered with the options.
+func (c *completedExchange) recoverable(options RecoveryOptions, now time.Time) bool {
+	if options.MaximumRedeliveries > 0 && c.redeliveries >= options.MaximumRedeliveries {
+		return false
+	}
+	return !c.updated.After(now.Add(-options.Lease))
 }
 
 // NewMemoryAggregationRepository creates a new MemoryAggregationRepository instance.
 func NewMemoryAggregationRepository() *MemoryAggregationRepository {
 	return &MemoryAggregationRepository{
-		store: make(map[string]*Exchange),
+		store:     make(map[string]*Exchange),
+		completed: make(map[string]*completedExchange),
 	}
 }
 
@@ -44,3 +65,52 @@ func (r *MemoryAggregationRepository) Remove(ctx context.Context, key string) er
 	delete(r.store, key)
 	return nil
 }
+
+// Complete removes the group of the correlation key and keeps the aggregated exchange until it is confirmed.
+func (r *MemoryAggregationRepository) Complete(ctx context.Context, key string, exchange *Exchange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.store, key)
+	r.completed[exchange.ID] = &completedExchange{exchange: exchange.Copy(), updated: time.Now()}
+	return nil
+}
+
+// Confirm removes a completed exchange.
+func (r *MemoryAggregationRepository) Confirm(ctx context.Context, exchangeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.completed, exchangeID)
+	return nil
+}
+
+// Scan returns the IDs of the completed exchanges not confirmed yet that can be recovered.
+func (r *MemoryAggregationRepository) Scan(ctx context.Context, options RecoveryOptions) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	ids := make([]string, 0, len(r.completed))
+	for id, completed := range r.completed {
+		if completed.recoverable(options, now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// Recover returns a copy of a completed exchange, with its ID and its number of redeliveries,
+// incremented. Returns nil if it doesn't exist or can't be recovered.
+func (r *MemoryAggregationRepository) Recover(ctx context.Context, exchangeID string, options RecoveryOptions) (*Exchange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	completed, exists := r.completed[exchangeID]
+	now := time.Now()
+	if !exists || !completed.recoverable(options, now) {
+		return nil, nil
+	}
+	completed.redeliveries++
+	completed.updated = now
+	recovered := completed.exchange.Copy()
+	recovered.ID = exchangeID
+	recovered.GetIn().SetHeader(CamelRedeliveryCounter, completed.redeliveries)
+	return recovered, nil
+}
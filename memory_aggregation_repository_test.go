@@ -3,8 +3,10 @@ package gocamel
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMemoryAggregationRepository_AddGetRemove(t *testing.T) {
@@ -47,3 +49,71 @@ func TestMemoryAggregationRepository_AddGetRemove(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, exchange)
 }
+
+// testRecoverableAggregationRepository vérifie le contrat commun des dépôts récupérables
+func testRecoverableAggregationRepository(t *testing.T, repo RecoverableAggregationRepository) {
+	ctx := context.Background()
+
+	group := NewExchange(ctx)
+	group.GetIn().SetBody("a1")
+	require.NoError(t, repo.Add(ctx, "A", group))
+
+	// La complétion retire le groupe et conserve l'échange agrégé jusqu'à sa confirmation
+	completed := NewExchange(ctx)
+	completed.GetIn().SetBody("a1,a2")
+	completed.GetIn().SetHeader("orderId", "42")
+	require.NoError(t, repo.Complete(ctx, "A", completed))
+
+	exchange, err := repo.Get(ctx, "A")
+	require.NoError(t, err)
+	assert.Nil(t, exchange)
+
+	ids, err := repo.Scan(ctx, RecoveryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{completed.ID}, ids)
+
+	recovered, err := repo.Recover(ctx, completed.ID, RecoveryOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, recovered)
+	assert.Equal(t, completed.ID, recovered.ID)
+	assert.Equal(t, "a1,a2", recovered.GetIn().GetBody())
+	orderID, _ := recovered.GetHeader("orderId")
+	assert.Equal(t, "42", orderID)
+
+	// Chaque récupération incrémente le nombre de redélivrances conservé par le dépôt
+	counter, _ := recovered.GetHeaderAsInt(CamelRedeliveryCounter)
+	assert.Equal(t, 1, counter)
+	recovered, err = repo.Recover(ctx, completed.ID, RecoveryOptions{})
+	require.NoError(t, err)
+	counter, _ = recovered.GetHeaderAsInt(CamelRedeliveryCounter)
+	assert.Equal(t, 2, counter)
+
+	// Les options excluent les échanges épuisés et ceux dont le bail n'a pas expiré
+	ids, err = repo.Scan(ctx, RecoveryOptions{MaximumRedeliveries: 2})
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	recovered, err = repo.Recover(ctx, completed.ID, RecoveryOptions{MaximumRedeliveries: 2})
+	require.NoError(t, err)
+	assert.Nil(t, recovered)
+	ids, err = repo.Scan(ctx, RecoveryOptions{Lease: time.Hour})
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	recovered, err = repo.Recover(ctx, completed.ID, RecoveryOptions{Lease: time.Hour})
+	require.NoError(t, err)
+	assert.Nil(t, recovered)
+	ids, err = repo.Scan(ctx, RecoveryOptions{MaximumRedeliveries: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{completed.ID}, ids)
+
+	require.NoError(t, repo.Confirm(ctx, completed.ID))
+	ids, err = repo.Scan(ctx, RecoveryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	recovered, err = repo.Recover(ctx, completed.ID, RecoveryOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, recovered)
+}
+
+func TestMemoryAggregationRepository_Recoverable(t *testing.T) {
+	testRecoverableAggregationRepository(t, NewMemoryAggregationRepository())
+}
@@ -0,0 +1,537 @@
+package gocamel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TestingT est le sous-ensemble de *testing.T utilisé par les assertions des endpoints mock
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// MockComponent crée des endpoints mock qui enregistrent les échanges reçus
+// et permettent de vérifier des attentes dans les tests de routes.
+// Les endpoints sont partagés par nom : mock:result et mock:result?x=y désignent le même endpoint.
+type MockComponent struct {
+	endpoints map[string]*MockEndpoint
+	mu        sync.Mutex
+}
+
+// NewMockComponent crée une nouvelle instance de MockComponent
+func NewMockComponent() *MockComponent {
+	return &MockComponent{
+		endpoints: make(map[string]*MockEndpoint),
+	}
+}
+
+// CreateEndpoint retourne l'endpoint mock correspondant à l'URI, en le créant si nécessaire
+func (c *MockComponent) CreateEndpoint(uri string) (Endpoint, error) {
+	name := mockEndpointName(uri)
+	if name == "" {
+		return nil, fmt.Errorf("le nom de l'endpoint mock est obligatoire: %s", uri)
+	}
+	return c.GetEndpoint(name), nil
+}
+
+// GetEndpoint retourne l'endpoint mock du nom donné (avec ou sans préfixe mock:)
+func (c *MockComponent) GetEndpoint(name string) *MockEndpoint {
+	name = mockEndpointName(name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if endpoint, exists := c.endpoints[name]; exists {
+		return endpoint
+	}
+	endpoint := newMockEndpoint("mock:" + name)
+	c.endpoints[name] = endpoint
+	return endpoint
+}
+
+// Reset réinitialise tous les endpoints mock du composant
+func (c *MockComponent) Reset() {
+	c.mu.Lock()
+	endpoints := make([]*MockEndpoint, 0, len(c.endpoints))
+	for _, endpoint := range c.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	c.mu.Unlock()
+
+	for _, endpoint := range endpoints {
+		endpoint.Reset()
+	}
+}
+
+// GetMockEndpoint retourne l'endpoint mock associé à l'URI (ex: "mock:result").
+// Le composant mock doit être enregistré dans le contexte.
+func (c *CamelContext) GetMockEndpoint(uri string) (*MockEndpoint, error) {
+	endpoint, err := c.CreateEndpoint(uri)
+	if err != nil {
+		return nil, err
+	}
+	mock, ok := endpoint.(*MockEndpoint)
+	if !ok {
+		return nil, fmt.Errorf("l'endpoint %s n'est pas un endpoint mock", uri)
+	}
+	return mock, nil
+}
+
+// mockEndpointName extrait le nom d'un endpoint mock : "mock:result?x=y" -> "result"
+func mockEndpointName(uri string) string {
+	name := strings.TrimPrefix(uri, "mock:")
+	name = strings.TrimPrefix(name, "//")
+	if i := strings.Index(name, "?"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// mockHeaderExpectation décrit un en-tête attendu sur chaque message reçu
+type mockHeaderExpectation struct {
+	name  string
+	value any
+}
+
+// MockEndpoint enregistre les échanges reçus et vérifie des attentes.
+// Il ne peut être utilisé que comme destination (To).
+type MockEndpoint struct {
+	uri string
+
+	mu        sync.Mutex
+	exchanges []*Exchange
+	// changed est fermé puis recréé à chaque réception pour réveiller AssertIsSatisfied
+	changed chan struct{}
+
+	expectedCount          int
+	expectedBodies         []any
+	expectedBodiesAnyOrder bool
+	expectedHeaders        []mockHeaderExpectation
+	messages               map[int]*MockMessageExpectation
+
+	defaultProcessor Processor
+	processors       map[int]Processor
+	replyBody        any
+	replyError       error
+}
+
+func newMockEndpoint(uri string) *MockEndpoint {
+	e := &MockEndpoint{uri: uri}
+	e.reset()
+	return e
+}
+
+// URI retourne l'URI de l'endpoint
+func (e *MockEndpoint) URI() string {
+	return e.uri
+}
+
+// CreateProducer crée un producteur qui enregistre les échanges dans l'endpoint
+func (e *MockEndpoint) CreateProducer() (Producer, error) {
+	return &MockProducer{endpoint: e}, nil
+}
+
+// CreateConsumer n'est pas supporté : un endpoint mock est une destination
+func (e *MockEndpoint) CreateConsumer(processor Processor) (Consumer, error) {
+	return nil, fmt.Errorf("les endpoints mock ne supportent pas de consumer: %s", e.uri)
+}
+
+// ExpectedMessageCount définit le nombre exact de messages attendus
+func (e *MockEndpoint) ExpectedMessageCount(count int) *MockEndpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expectedCount = count
+	return e
+}
+
+// ExpectedBodiesReceived attend exactement ces corps, dans cet ordre
+func (e *MockEndpoint) ExpectedBodiesReceived(bodies ...any) *MockEndpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expectedBodies = bodies
+	e.expectedBodiesAnyOrder = false
+	e.expectedCount = len(bodies)
+	return e
+}
+
+// ExpectedBodiesReceivedInAnyOrder attend exactement ces corps, dans un ordre quelconque
+func (e *MockEndpoint) ExpectedBodiesReceivedInAnyOrder(bodies ...any) *MockEndpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expectedBodies = bodies
+	e.expectedBodiesAnyOrder = true
+	e.expectedCount = len(bodies)
+	return e
+}
+
+// ExpectedHeaderReceived attend que chaque message reçu porte l'en-tête avec cette valeur
+func (e *MockEndpoint) ExpectedHeaderReceived(name string, value any) *MockEndpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expectedHeaders = append(e.expectedHeaders, mockHeaderExpectation{name: name, value: value})
+	return e
+}
+
+// MessageN retourne les attentes portant sur le message d'index n (à partir de 0)
+func (e *MockEndpoint) MessageN(n int) *MockMessageExpectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if expectation, exists := e.messages[n]; exists {
+		return expectation
+	}
+	expectation := &MockMessageExpectation{index: n}
+	e.messages[n] = expectation
+	return expectation
+}
+
+// WhenAnyExchangeReceived exécute le processeur sur chaque échange reçu,
+// ce qui permet de simuler une réponse ou une erreur du système cible
+func (e *MockEndpoint) WhenAnyExchangeReceived(processor Processor) *MockEndpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.defaultProcessor = processor
+	return e
+}
+
+// WhenExchangeReceived exécute le processeur uniquement sur l'échange d'index n (à partir de 0)
+func (e *MockEndpoint) WhenExchangeReceived(n int, processor Processor) *MockEndpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.processors[n] = processor
+	return e
+}
+
+// ReturnReplyBody remplace le corps du message de chaque échange reçu, comme le ferait un producteur request/reply
+func (e *MockEndpoint) ReturnReplyBody(body any) *MockEndpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.replyBody = body
+	return e
+}
+
+// ReturnError fait échouer l'envoi de chaque échange avec l'erreur donnée
+func (e *MockEndpoint) ReturnError(err error) *MockEndpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.replyError = err
+	return e
+}
+
+// Reset efface les échanges reçus, les attentes et les réponses programmées
+func (e *MockEndpoint) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reset()
+}
+
+func (e *MockEndpoint) reset() {
+	e.exchanges = nil
+	e.changed = make(chan struct{})
+	e.expectedCount = -1
+	e.expectedBodies = nil
+	e.expectedBodiesAnyOrder = false
+	e.expectedHeaders = nil
+	e.messages = make(map[int]*MockMessageExpectation)
+	e.defaultProcessor = nil
+	e.processors = make(map[int]Processor)
+	e.replyBody = nil
+	e.replyError = nil
+}
+
+// ReceivedCounter retourne le nombre d'échanges reçus
+func (e *MockEndpoint) ReceivedCounter() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.exchanges)
+}
+
+// ReceivedExchanges retourne une copie des échanges reçus, tels qu'ils étaient à la réception
+func (e *MockEndpoint) ReceivedExchanges() []*Exchange {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Exchange(nil), e.exchanges...)
+}
+
+// ReceivedBodies retourne les corps des messages reçus
+func (e *MockEndpoint) ReceivedBodies() []any {
+	exchanges := e.ReceivedExchanges()
+	bodies := make([]any, len(exchanges))
+	for i, exchange := range exchanges {
+		bodies[i] = exchange.GetIn().GetBody()
+	}
+	return bodies
+}
+
+// AssertIsSatisfied attend au plus timeout que le nombre de messages attendus soit atteint,
+// puis vérifie toutes les attentes. Chaque attente non satisfaite est signalée via t.Errorf.
+// Si ExpectedMessageCount(0) est utilisé, l'attente dure tout le timeout.
+func (e *MockEndpoint) AssertIsSatisfied(t TestingT, timeout time.Duration) bool {
+	t.Helper()
+
+	e.waitForMessages(timeout)
+
+	failures := e.verify()
+	for _, failure := range failures {
+		t.Errorf("%s: %s", e.uri, failure)
+	}
+	return len(failures) == 0
+}
+
+// waitForMessages attend que le nombre de messages requis par les attentes soit reçu
+func (e *MockEndpoint) waitForMessages(timeout time.Duration) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		e.mu.Lock()
+		required := e.expectedCount
+		for index := range e.messages {
+			if index+1 > required {
+				required = index + 1
+			}
+		}
+		received := len(e.exchanges)
+		changed := e.changed
+		e.mu.Unlock()
+
+		if required != 0 && received >= required {
+			return
+		}
+		if required < 0 && received > 0 {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return
+		}
+	}
+}
+
+// verify retourne la liste des attentes non satisfaites
+func (e *MockEndpoint) verify() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var failures []string
+	received := len(e.exchanges)
+
+	if e.expectedCount >= 0 && received != e.expectedCount {
+		failures = append(failures, fmt.Sprintf("expected %d message(s) but received %d", e.expectedCount, received))
+	}
+
+	if e.expectedBodies != nil {
+		actual := make([]any, received)
+		for i, exchange := range e.exchanges {
+			actual[i] = exchange.GetIn().GetBody()
+		}
+		if e.expectedBodiesAnyOrder {
+			if !mockSameElements(e.expectedBodies, actual) {
+				failures = append(failures, fmt.Sprintf("expected bodies %v in any order but received %v", e.expectedBodies, actual))
+			}
+		} else {
+			for i, expected := range e.expectedBodies {
+				if i >= received {
+					break
+				}
+				if !expectedValueMatches(expected, actual[i]) {
+					failures = append(failures, fmt.Sprintf("message %d: expected body %v but was %v", i, expected, actual[i]))
+				}
+			}
+		}
+	}
+
+	for _, header := range e.expectedHeaders {
+		if received == 0 {
+			failures = append(failures, fmt.Sprintf("expected header %s=%v but no message was received", header.name, header.value))
+			continue
+		}
+		for i, exchange := range e.exchanges {
+			value, _ := exchange.GetIn().GetHeader(header.name)
+			if !expectedValueMatches(header.value, value) {
+				failures = append(failures, fmt.Sprintf("message %d: expected header %s=%v but was %v", i, header.name, header.value, value))
+			}
+		}
+	}
+
+	indexes := make([]int, 0, len(e.messages))
+	for index := range e.messages {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		expectation := e.messages[index]
+		if index >= received {
+			failures = append(failures, fmt.Sprintf("message %d was not received", index))
+			continue
+		}
+		failures = append(failures, expectation.verify(e.exchanges[index])...)
+	}
+
+	return failures
+}
+
+// receive enregistre un échange puis applique les réponses programmées
+func (e *MockEndpoint) receive(exchange *Exchange) error {
+	received := exchange.Copy()
+	received.ID = exchange.ID
+
+	e.mu.Lock()
+	index := len(e.exchanges)
+	e.exchanges = append(e.exchanges, received)
+	close(e.changed)
+	e.changed = make(chan struct{})
+
+	processor := e.defaultProcessor
+	if p, exists := e.processors[index]; exists {
+		processor = p
+	}
+	replyBody := e.replyBody
+	replyError := e.replyError
+	e.mu.Unlock()
+
+	if processor != nil {
+		if err := processor.Process(exchange); err != nil {
+			return err
+		}
+	}
+	if replyBody != nil {
+		exchange.GetIn().SetBody(replyBody)
+	}
+	return replyError
+}
+
+// mockCheck est une vérification nommée appliquée à un message reçu
+type mockCheck struct {
+	description string
+	check       func(exchange *Exchange) (bool, error)
+}
+
+// MockMessageExpectation regroupe les attentes portant sur un message précis
+type MockMessageExpectation struct {
+	index  int
+	mu     sync.Mutex
+	checks []mockCheck
+}
+
+// Predicate ajoute une condition arbitraire sur l'échange reçu
+func (m *MockMessageExpectation) Predicate(predicate func(exchange *Exchange) bool) *MockMessageExpectation {
+	return m.add("predicate", func(exchange *Exchange) (bool, error) {
+		return predicate(exchange), nil
+	})
+}
+
+// Simple ajoute une condition exprimée en Simple language (ex: "${header.type == 'gold'}")
+func (m *MockMessageExpectation) Simple(expression string) *MockMessageExpectation {
+	template, parseErr := ParseSimpleTemplate(expression)
+	return m.add("simple "+expression, func(exchange *Exchange) (bool, error) {
+		if parseErr != nil {
+			return false, parseErr
+		}
+		return template.EvaluateAsBool(exchange)
+	})
+}
+
+// Body attend un corps précis pour ce message
+func (m *MockMessageExpectation) Body(expected any) *MockMessageExpectation {
+	return m.add(fmt.Sprintf("body %v", expected), func(exchange *Exchange) (bool, error) {
+		return expectedValueMatches(expected, exchange.GetIn().GetBody()), nil
+	})
+}
+
+// Header attend une valeur d'en-tête précise pour ce message
+func (m *MockMessageExpectation) Header(name string, expected any) *MockMessageExpectation {
+	return m.add(fmt.Sprintf("header %s=%v", name, expected), func(exchange *Exchange) (bool, error) {
+		value, _ := exchange.GetIn().GetHeader(name)
+		return expectedValueMatches(expected, value), nil
+	})
+}
+
+func (m *MockMessageExpectation) add(description string, check func(*Exchange) (bool, error)) *MockMessageExpectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks = append(m.checks, mockCheck{description: description, check: check})
+	return m
+}
+
+func (m *MockMessageExpectation) verify(exchange *Exchange) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var failures []string
+	for _, c := range m.checks {
+		ok, err := c.check(exchange)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("message %d: %s: %v", m.index, c.description, err))
+		} else if !ok {
+			failures = append(failures, fmt.Sprintf("message %d: expected %s", m.index, c.description))
+		}
+	}
+	return failures
+}
+
+// MockProducer transmet les échanges à son MockEndpoint
+type MockProducer struct {
+	endpoint *MockEndpoint
+}
+
+// Start démarre le producteur
+func (p *MockProducer) Start(ctx context.Context) error {
+	return nil
+}
+
+// Stop arrête le producteur
+func (p *MockProducer) Stop() error {
+	return nil
+}
+
+// Send enregistre l'échange dans l'endpoint mock
+func (p *MockProducer) Send(exchange *Exchange) error {
+	return p.endpoint.receive(exchange)
+}
+
+// expectedValueMatches compare une valeur attendue à une valeur reçue (mock, NotifyBuilder).
+// Une chaîne attendue est comparée à la représentation textuelle de la valeur reçue,
+// ce qui permet par exemple d'attendre "42" pour un en-tête entier ou un corps []byte.
+func expectedValueMatches(expected, actual any) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	s, ok := expected.(string)
+	if !ok || actual == nil {
+		return false
+	}
+	if b, ok := actual.([]byte); ok {
+		return s == string(b)
+	}
+	return s == fmt.Sprintf("%v", actual)
+}
+
+// mockSameElements indique si les deux listes contiennent les mêmes valeurs, quel que soit l'ordre
+func mockSameElements(expected, actual []any) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	used := make([]bool, len(actual))
+	for _, e := range expected {
+		found := false
+		for i, a := range actual {
+			if !used[i] && expectedValueMatches(e, a) {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT capture les erreurs signalées par AssertIsSatisfied
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func newMockTestContext(t *testing.T) *CamelContext {
+	t.Helper()
+	camel := newTestContext()
+	return camel
+}
+
+func TestMockEndpoint_Expectations(t *testing.T) {
+	camel := newMockTestContext(t)
+
+	route := camel.CreateRouteBuilder().
+		From("direct:start").
+		SetHeader("processed", "yes").
+		To("mock:result").
+		Build()
+
+	result, err := camel.GetMockEndpoint("mock:result")
+	require.NoError(t, err)
+	result.ExpectedBodiesReceived("a", "b")
+	result.ExpectedHeaderReceived("processed", "yes")
+	result.MessageN(0).Body("a").Simple("${header.index == 1}")
+	result.MessageN(1).Predicate(func(e *Exchange) bool {
+		body, _ := e.GetIn().GetBodyAsString()
+		return body == "b"
+	})
+
+	for i, body := range []string{"a", "b"} {
+		exchange := NewExchange(context.Background())
+		exchange.GetIn().SetBody(body)
+		exchange.GetIn().SetHeader("index", i+1)
+		require.NoError(t, route.Process(exchange))
+	}
+
+	result.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, 2, result.ReceivedCounter())
+	assert.Equal(t, []any{"a", "b"}, result.ReceivedBodies())
+
+	// Le même endpoint est retourné quelles que soient les options
+	same, err := camel.GetMockEndpoint("mock:result?retainFirst=1")
+	require.NoError(t, err)
+	assert.Same(t, result, same)
+}
+
+func TestMockEndpoint_ReportsFailures(t *testing.T) {
+	camel := newMockTestContext(t)
+	result, err := camel.GetMockEndpoint("mock:result")
+	require.NoError(t, err)
+
+	producer, _ := result.CreateProducer()
+	for _, body := range []string{"a", "c"} {
+		exchange := NewExchange(context.Background())
+		exchange.GetIn().SetBody(body)
+		exchange.GetIn().SetHeader("type", "silver")
+		require.NoError(t, producer.Send(exchange))
+	}
+
+	result.ExpectedBodiesReceived("a", "b", "c")
+	result.ExpectedHeaderReceived("type", "gold")
+	result.MessageN(1).Simple("${body == 'b'}")
+
+	rt := &recordingT{}
+	assert.False(t, result.AssertIsSatisfied(rt, 50*time.Millisecond))
+
+	report := strings.Join(rt.errors, "\n")
+	assert.Contains(t, report, "mock:result: expected 3 message(s) but received 2")
+	assert.Contains(t, report, "message 1: expected body b but was c")
+	assert.Contains(t, report, "message 0: expected header type=gold but was silver")
+	assert.Contains(t, report, "message 1: expected simple ${body == 'b'}")
+}
+
+func TestMockEndpoint_AnyOrderAndAsync(t *testing.T) {
+	camel := newMockTestContext(t)
+	result, err := camel.GetMockEndpoint("mock:async")
+	require.NoError(t, err)
+	result.ExpectedBodiesReceivedInAnyOrder("x", "42", "z")
+
+	producer, _ := result.CreateProducer()
+	go func() {
+		for _, body := range []any{[]byte("z"), "x", 42} {
+			time.Sleep(10 * time.Millisecond)
+			exchange := NewExchange(context.Background())
+			exchange.GetIn().SetBody(body)
+			producer.Send(exchange)
+		}
+	}()
+
+	assert.False(t, result.AssertIsSatisfied(&recordingT{}, 0), "expected messages not yet received")
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestMockEndpoint_ScriptedReplies(t *testing.T) {
+	camel := newMockTestContext(t)
+
+	var replies []string
+	route := camel.CreateRouteBuilder().
+		From("direct:start").
+		To("mock:backend").
+		ProcessFunc(func(e *Exchange) error {
+			body, _ := e.GetIn().GetBodyAsString()
+			replies = append(replies, body)
+			return nil
+		}).
+		Build()
+
+	backend, err := camel.GetMockEndpoint("mock:backend")
+	require.NoError(t, err)
+	backend.ReturnReplyBody("pong")
+	backend.WhenExchangeReceived(1, ProcessorFunc(func(e *Exchange) error {
+		return errors.New("backend unavailable")
+	}))
+
+	send := func(body string) error {
+		exchange := NewExchange(context.Background())
+		exchange.GetIn().SetBody(body)
+		return route.Process(exchange)
+	}
+
+	require.NoError(t, send("ping"))
+	assert.EqualError(t, send("ping"), "backend unavailable")
+
+	backend.ReturnError(errors.New("injected"))
+	assert.EqualError(t, send("ping"), "injected")
+
+	backend.ExpectedMessageCount(3)
+	backend.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, []string{"pong"}, replies)
+
+	// Reset efface les échanges, les attentes et les réponses programmées
+	backend.Reset()
+	assert.Equal(t, 0, backend.ReceivedCounter())
+	require.NoError(t, send("ping"))
+	assert.Equal(t, []string{"pong", "ping"}, replies)
+}
+
+func TestMockEndpoint_NoConsumer(t *testing.T) {
+	camel := newMockTestContext(t)
+	endpoint, err := camel.CreateEndpoint("mock:result")
+	require.NoError(t, err)
+
+	_, err = endpoint.CreateConsumer(ProcessorFunc(func(*Exchange) error { return nil }))
+	assert.Error(t, err)
+
+	camel.AddComponent("direct", NewDirectComponent())
+	_, err = camel.GetMockEndpoint("direct:start")
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package gocamel
+
+import (
+	"sync"
+	"time"
+)
+
+// NotifyBuilder permet d'attendre la fin du traitement d'échanges, typiquement dans les tests
+// de routes asynchrones (timer, cron, file...), au lieu d'utiliser time.Sleep.
+// Les conditions sont cumulatives ; seuls les échanges terminés par leur route d'entrée
+// sont pris en compte (pas les appels imbriqués via direct:).
+//
+//	notify := NewNotifyBuilder(ctx).FromRoute("orders").WhenDone(5).WhenFailed(1).Create()
+//	defer notify.Stop()
+//	...
+//	if !notify.MatchesWaitTime(5 * time.Second) { t.Fatal("timeout") }
+type NotifyBuilder struct {
+	context *CamelContext
+
+	mu        sync.Mutex
+	fromRoute func(string) bool
+	from      func(string) bool
+
+	whenDone      int
+	whenCompleted int
+	whenFailed    int
+	whenBodies    []any
+
+	done       int
+	completed  int
+	failed     int
+	doneBodies []any
+
+	matched   chan struct{}
+	isMatched bool
+	created   bool
+}
+
+// NewNotifyBuilder crée un NotifyBuilder pour le contexte donné
+func NewNotifyBuilder(context *CamelContext) *NotifyBuilder {
+	return &NotifyBuilder{
+		context: context,
+		matched: make(chan struct{}),
+	}
+}
+
+// FromRoute ne prend en compte que les échanges des routes dont l'ID correspond au motif
+// (ID exact, jokers "*" ou expression régulière)
+func (n *NotifyBuilder) FromRoute(pattern string) *NotifyBuilder {
+	n.fromRoute = newEndpointMatcher(pattern)
+	return n
+}
+
+// From ne prend en compte que les échanges des routes dont l'endpoint source correspond au motif
+func (n *NotifyBuilder) From(uriPattern string) *NotifyBuilder {
+	n.from = newEndpointMatcher(uriPattern)
+	return n
+}
+
+// WhenDone est satisfait lorsqu'au moins count échanges sont terminés, avec ou sans erreur
+func (n *NotifyBuilder) WhenDone(count int) *NotifyBuilder {
+	n.whenDone = count
+	return n
+}
+
+// WhenCompleted est satisfait lorsqu'au moins count échanges sont terminés avec succès
+func (n *NotifyBuilder) WhenCompleted(count int) *NotifyBuilder {
+	n.whenCompleted = count
+	return n
+}
+
+// WhenFailed est satisfait lorsqu'au moins count échanges ont échoué
+func (n *NotifyBuilder) WhenFailed(count int) *NotifyBuilder {
+	n.whenFailed = count
+	return n
+}
+
+// WhenBodiesDone est satisfait lorsque les premiers échanges terminés ont ces corps, dans cet ordre.
+// Le corps pris en compte est celui de la réponse (message Out s'il est défini, sinon In).
+func (n *NotifyBuilder) WhenBodiesDone(bodies ...any) *NotifyBuilder {
+	n.whenBodies = bodies
+	return n
+}
+
+// Create enregistre le NotifyBuilder auprès du contexte ; les échanges terminés
+// auparavant ne sont pas comptés
+func (n *NotifyBuilder) Create() *NotifyBuilder {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.created {
+		n.created = true
+		n.context.AddEventNotifier(n)
+	}
+	return n
+}
+
+// Stop désenregistre le NotifyBuilder du contexte
+func (n *NotifyBuilder) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.created {
+		n.created = false
+		n.context.RemoveEventNotifier(n)
+	}
+}
+
+// Matches indique si toutes les conditions sont satisfaites
+func (n *NotifyBuilder) Matches() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.isMatched
+}
+
+// MatchesWaitTime attend au plus timeout que toutes les conditions soient satisfaites
+func (n *NotifyBuilder) MatchesWaitTime(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-n.matched:
+		return true
+	case <-timer.C:
+		return n.Matches()
+	}
+}
+
+// Notify implémente EventNotifier
+func (n *NotifyBuilder) Notify(event ExchangeEvent) {
+	if event.Nested {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.fromRoute != nil && !n.fromRoute(event.RouteID) {
+		return
+	}
+	if n.from != nil {
+		route := n.context.GetRoute(event.RouteID)
+		if route == nil || route.from == nil || !n.from(route.from.URI()) {
+			return
+		}
+	}
+
+	n.done++
+	if event.Type == ExchangeFailedEvent {
+		n.failed++
+	} else {
+		n.completed++
+	}
+	if n.whenBodies != nil && event.Exchange != nil {
+		n.doneBodies = append(n.doneBodies, event.Exchange.GetResponse().GetBody())
+	}
+
+	if !n.isMatched && n.matches() {
+		n.isMatched = true
+		close(n.matched)
+	}
+}
+
+// matches évalue les conditions ; sans condition, le premier échange terminé suffit
+func (n *NotifyBuilder) matches() bool {
+	if n.whenDone == 0 && n.whenCompleted == 0 && n.whenFailed == 0 && n.whenBodies == nil {
+		return n.done > 0
+	}
+	if n.done < n.whenDone || n.completed < n.whenCompleted || n.failed < n.whenFailed {
+		return false
+	}
+	if n.whenBodies != nil {
+		if len(n.doneBodies) < len(n.whenBodies) {
+			return false
+		}
+		for i, expected := range n.whenBodies {
+			if !expectedValueMatches(expected, n.doneBodies[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
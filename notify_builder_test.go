@@ -0,0 +1,86 @@
+package gocamel
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyBuilder_TimerRoute(t *testing.T) {
+	camel := NewCamelContext()
+	camel.AddComponent("timer", NewTimerComponent())
+
+	camel.CreateRouteBuilder().
+		From("timer:tick?delay=1&period=5&repeatCount=3").
+		SetBody("tick").
+		Build().SetID("ticker")
+
+	notify := NewNotifyBuilder(camel).FromRoute("ticker").WhenCompleted(3).Create()
+	defer notify.Stop()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	assert.True(t, notify.MatchesWaitTime(2*time.Second))
+}
+
+func TestNotifyBuilder_Conditions(t *testing.T) {
+	camel := NewCamelContext()
+	camel.AddComponent("direct", NewDirectComponent())
+
+	camel.CreateRouteBuilder().
+		From("direct:orders").
+		ProcessFunc(func(e *Exchange) error {
+			if body, _ := e.GetIn().GetBodyAsString(); body == "bad" {
+				return errors.New("invalid order")
+			}
+			return nil
+		}).
+		To("direct:audit").
+		Build().SetID("orders")
+	camel.CreateRouteBuilder().
+		From("direct:audit").
+		SetBody("audited").
+		Build().SetID("audit")
+
+	all := NewNotifyBuilder(camel).FromRoute("orders").WhenDone(3).WhenFailed(1).Create()
+	defer all.Stop()
+	bodies := NewNotifyBuilder(camel).From("direct:order*").WhenBodiesDone("audited", "audited").Create()
+	defer bodies.Stop()
+	// audit n'est appelée que de manière imbriquée : elle n'est jamais comptée
+	audit := NewNotifyBuilder(camel).FromRoute("audit").Create()
+	defer audit.Stop()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:orders", "a"))
+	assert.False(t, all.Matches())
+	assert.False(t, bodies.Matches())
+
+	assert.Error(t, template.SendBody("direct:orders", "bad"))
+	assert.False(t, bodies.MatchesWaitTime(10*time.Millisecond), "failed exchange body breaks the sequence")
+
+	require.NoError(t, template.SendBody("direct:orders", "b"))
+	assert.True(t, all.MatchesWaitTime(time.Second))
+	assert.False(t, audit.MatchesWaitTime(10*time.Millisecond))
+}
+
+func TestNotifyBuilder_Stop(t *testing.T) {
+	camel := NewCamelContext()
+	camel.AddComponent("direct", NewDirectComponent())
+	camel.CreateRouteBuilder().From("direct:start").Build()
+
+	notify := NewNotifyBuilder(camel).Create()
+	notify.Stop()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "x"))
+
+	assert.False(t, notify.Matches())
+}
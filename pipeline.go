@@ -21,16 +21,20 @@ func (p *Pipeline) AddProcessor(processor Processor) {
 // Process exécute tous les processeurs du pipeline
 func (p *Pipeline) Process(exchange *Exchange) error {
 	for _, processor := range p.processors {
-		// Propagation de la sortie vers l'entrée si une modification a eu lieu
-		if exchange.HasOut() {
-			exchange.In.SetBody(exchange.Out.GetBody())
-			exchange.In.SetHeaders(exchange.Out.GetHeaders())
-			exchange.Out = NewMessage() // Reset Out pour le prochain processeur
-		}
-
+		promoteOut(exchange)
 		if err := processor.Process(exchange); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// promoteOut propage la sortie vers l'entrée si une modification a eu lieu, puis réinitialise
+// le message de sortie pour le processeur suivant
+func promoteOut(exchange *Exchange) {
+	if exchange.HasOut() {
+		exchange.In.SetBody(exchange.Out.GetBody())
+		exchange.In.SetHeaders(exchange.Out.GetHeaders())
+		exchange.Out = NewMessage()
+	}
+}
@@ -0,0 +1,146 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrPollingConsumerStopped est retournée par Receive lorsque le consommateur est arrêté
+var ErrPollingConsumerStopped = errors.New("polling consumer arrêté")
+
+// CreatePollingConsumer crée un PollingConsumer pour l'endpoint. Les endpoints qui implémentent
+// PollingConsumerEndpoint fournissent leur propre implémentation ; les autres (ftp, sftp, smb,
+// imap, timer...) sont adaptés à partir de leur consommateur classique.
+func CreatePollingConsumer(endpoint Endpoint) (PollingConsumer, error) {
+	if pce, ok := endpoint.(PollingConsumerEndpoint); ok {
+		return pce.CreatePollingConsumer()
+	}
+	return NewEventDrivenPollingConsumer(endpoint), nil
+}
+
+// EventDrivenPollingConsumer adapte un consommateur classique en PollingConsumer.
+// Le consommateur sous-jacent continue d'interroger sa source à son rythme ; chaque message
+// qu'il produit attend d'être récupéré par Receive avant que le consommateur ne poursuive.
+// Le message est considéré comme traité (suppression, déplacement...) dès qu'il est récupéré.
+type EventDrivenPollingConsumer struct {
+	endpoint  Endpoint
+	consumer  Consumer
+	exchanges chan *Exchange
+	done      chan struct{}
+	mu        sync.Mutex
+	started   bool
+}
+
+// NewEventDrivenPollingConsumer crée un PollingConsumer au-dessus du consommateur de l'endpoint
+func NewEventDrivenPollingConsumer(endpoint Endpoint) *EventDrivenPollingConsumer {
+	return &EventDrivenPollingConsumer{
+		endpoint:  endpoint,
+		exchanges: make(chan *Exchange),
+	}
+}
+
+// Start démarre le consommateur sous-jacent
+func (c *EventDrivenPollingConsumer) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.started {
+		return nil
+	}
+	c.done = make(chan struct{})
+	consumer, err := c.endpoint.CreateConsumer(ProcessorFunc(c.handOff))
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création du consommateur %s: %w", c.endpoint.URI(), err)
+	}
+	if err := consumer.Start(ctx); err != nil {
+		return fmt.Errorf("erreur lors du démarrage du consommateur %s: %w", c.endpoint.URI(), err)
+	}
+	c.consumer = consumer
+	c.started = true
+	return nil
+}
+
+// Stop arrête le consommateur sous-jacent et débloque les appels Receive en cours
+func (c *EventDrivenPollingConsumer) Stop() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.started {
+		return nil
+	}
+	close(c.done)
+	c.started = false
+	return c.consumer.Stop()
+}
+
+// handOff remet une copie de l'échange à un appelant de Receive. La copie ne porte pas les
+// synchronisations du consommateur, qui restent gérées par celui-ci.
+func (c *EventDrivenPollingConsumer) handOff(exchange *Exchange) error {
+	c.mu.Lock()
+	done := c.done
+	c.mu.Unlock()
+
+	select {
+	case c.exchanges <- exchange.Copy():
+		return nil
+	case <-done:
+		return ErrPollingConsumerStopped
+	}
+}
+
+// Receive attend le prochain message
+func (c *EventDrivenPollingConsumer) Receive() (*Exchange, error) {
+	done, err := c.doneChannel()
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case exchange := <-c.exchanges:
+		return exchange, nil
+	case <-done:
+		return nil, ErrPollingConsumerStopped
+	}
+}
+
+// ReceiveNoWait retourne le message en attente, ou nil
+func (c *EventDrivenPollingConsumer) ReceiveNoWait() (*Exchange, error) {
+	if _, err := c.doneChannel(); err != nil {
+		return nil, err
+	}
+	select {
+	case exchange := <-c.exchanges:
+		return exchange, nil
+	default:
+		return nil, nil
+	}
+}
+
+// ReceiveTimeout attend le prochain message au plus pendant la durée donnée
+func (c *EventDrivenPollingConsumer) ReceiveTimeout(timeout time.Duration) (*Exchange, error) {
+	done, err := c.doneChannel()
+	if err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case exchange := <-c.exchanges:
+		return exchange, nil
+	case <-timer.C:
+		return nil, nil
+	case <-done:
+		return nil, ErrPollingConsumerStopped
+	}
+}
+
+func (c *EventDrivenPollingConsumer) doneChannel() (chan struct{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.started {
+		return nil, ErrPollingConsumerStopped
+	}
+	return c.done, nil
+}
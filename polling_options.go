@@ -29,7 +29,7 @@ type PollingOptions struct {
 	Delay time.Duration
 	// InitialDelay before the first poll (default: 1s).
 	InitialDelay time.Duration
-	// MaxMessagesPerPoll limits the number of files processed per cycle; 0 = unlimited.
+	// MaxMessagesPerPoll limits the number of files polled per cycle; 0 = unlimited.
 	MaxMessagesPerPoll int
 	// Noop prevents any post-processing action (delete/move) on the file.
 	Noop bool
@@ -102,3 +102,52 @@ func parseConnectTimeout(u *url.URL) time.Duration {
 	}
 	return 10 * time.Second
 }
+
+// Exchange properties set by the batch consumers (FTP, SFTP, SMB) on each exchange of a poll
+const (
+	CamelBatchIndex    = "CamelBatchIndex"    // Index of the exchange in the batch, from 0
+	CamelBatchSize     = "CamelBatchSize"     // Number of exchanges in the batch
+	CamelBatchComplete = "CamelBatchComplete" // true for the last exchange of the batch
+)
+
+// setBatchProperties sets the batch properties of an exchange produced by a batch consumer.
+// last marks the exchange of the last file polled, when the batch ends earlier than expected.
+func setBatchProperties(exchange *Exchange, index, size int, last bool) {
+	exchange.SetProperty(CamelBatchIndex, index)
+	exchange.SetProperty(CamelBatchSize, size)
+	exchange.SetProperty(CamelBatchComplete, last || index == size-1)
+}
+
+// batchSize returns the number of files processed by a poll among the files listed.
+func (o *PollingOptions) batchSize(files int) int {
+	if o.MaxMessagesPerPoll > 0 && files > o.MaxMessagesPerPoll {
+		return o.MaxMessagesPerPoll
+	}
+	return files
+}
+
+// processBatch processes the exchanges of a poll of size files. create returns the exchange of
+// the file at the given index, or nil when the file can't be read. Each exchange is created
+// before the previous one is processed, so that CamelBatchComplete is set on the last exchange
+// actually processed, even when the last files of the batch can't be read.
+func processBatch(size int, create func(index int) *Exchange, processor Processor) {
+	var pending *Exchange
+	pendingIndex := 0
+	flush := func(last bool) {
+		setBatchProperties(pending, pendingIndex, size, last)
+		pending.Done(processor.Process(pending))
+	}
+	for i := 0; i < size; i++ {
+		exchange := create(i)
+		if exchange == nil {
+			continue
+		}
+		if pending != nil {
+			flush(false)
+		}
+		pending, pendingIndex = exchange, i
+	}
+	if pending != nil {
+		flush(true)
+	}
+}
@@ -0,0 +1,41 @@
+package gocamel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessBatch_LastFileFails(t *testing.T) {
+	files := []string{"a", "b", "c", "d"}
+	opts := PollingOptions{MaxMessagesPerPoll: 3}
+
+	var received []*Exchange
+	processor := ProcessorFunc(func(exchange *Exchange) error {
+		received = append(received, exchange)
+		return nil
+	})
+
+	// Le dernier fichier du lot ("c") ne peut pas être lu et "d" dépasse maxMessagesPerPoll
+	processBatch(opts.batchSize(len(files)), func(i int) *Exchange {
+		if files[i] == "c" {
+			return nil
+		}
+		exchange := NewExchange(context.Background())
+		exchange.SetBody(files[i])
+		return exchange
+	}, processor)
+
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, "a", received[0].GetIn().GetBody())
+		assert.Equal(t, 0, received[0].GetPropertyOrDefault(CamelBatchIndex, nil))
+		assert.Equal(t, 3, received[0].GetPropertyOrDefault(CamelBatchSize, nil))
+		assert.Equal(t, false, received[0].GetPropertyOrDefault(CamelBatchComplete, nil))
+
+		// Le dernier échange effectivement traité termine le lot
+		assert.Equal(t, "b", received[1].GetIn().GetBody())
+		assert.Equal(t, 1, received[1].GetPropertyOrDefault(CamelBatchIndex, nil))
+		assert.Equal(t, true, received[1].GetPropertyOrDefault(CamelBatchComplete, nil))
+	}
+}
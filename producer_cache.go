@@ -0,0 +1,132 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// producerCache conserve les producteurs démarrés, indexés par URI, afin d'éviter
+// de recréer un endpoint et un producteur à chaque envoi.
+type producerCache struct {
+	context   *CamelContext
+	producers map[string]Producer
+	mu        sync.Mutex
+}
+
+// newProducerCache crée un nouveau cache de producteurs pour le contexte donné
+func newProducerCache(context *CamelContext) *producerCache {
+	return &producerCache{
+		context:   context,
+		producers: make(map[string]Producer),
+	}
+}
+
+// newRouteProducerCache crée un cache de producteurs pour un EIP d'une route ; ses producteurs
+// sont arrêtés avec le contexte, après l'arrêt des routes
+func newRouteProducerCache(context *CamelContext) *producerCache {
+	cache := newProducerCache(context)
+	context.addCleanupHook(func() {
+		if err := cache.stop(); err != nil {
+			log.Printf("Erreur lors de l'arrêt des producteurs en cache: %v", err)
+		}
+	})
+	return cache
+}
+
+// invalidEndpointError signale une URI dont l'endpoint ne peut pas être créé
+type invalidEndpointError struct {
+	uri string
+	err error
+}
+
+func (e *invalidEndpointError) Error() string {
+	return fmt.Sprintf("erreur lors de la création de l'endpoint %s: %v", e.uri, e.err)
+}
+
+func (e *invalidEndpointError) Unwrap() error {
+	return e.err
+}
+
+// acquire retourne le producteur associé à l'URI, en le créant et en le démarrant si nécessaire.
+// Le verrou n'est pas conservé pendant le démarrage, qui peut être long (connexion...).
+func (c *producerCache) acquire(ctx context.Context, uri string) (Producer, error) {
+	c.mu.Lock()
+	producer, exists := c.producers[uri]
+	c.mu.Unlock()
+	if exists {
+		return producer, nil
+	}
+
+	endpoint, err := c.context.CreateEndpoint(uri)
+	if err != nil {
+		return nil, &invalidEndpointError{uri: uri, err: err}
+	}
+	producer, err = endpoint.CreateProducer()
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la création du producteur %s: %w", uri, err)
+	}
+	if ctx == nil {
+		ctx = c.context.GetContext()
+	}
+	if err := producer.Start(ctx); err != nil {
+		return nil, fmt.Errorf("erreur lors du démarrage du producteur %s: %w", uri, err)
+	}
+
+	// Un autre envoi a pu démarrer un producteur pour la même URI entre-temps
+	c.mu.Lock()
+	existing, exists := c.producers[uri]
+	if !exists {
+		c.producers[uri] = producer
+	}
+	c.mu.Unlock()
+	if exists {
+		producer.Stop()
+		return existing, nil
+	}
+	return producer, nil
+}
+
+// send envoie l'échange à l'URI avec le producteur en cache, en appliquant les intercepteurs
+// d'envoi. Si ignoreInvalid est vrai, une URI dont l'endpoint ne peut pas être créé est ignorée
+// et le booléen retourné est faux ; les autres erreurs (démarrage du producteur...) sont retournées.
+func (c *producerCache) send(exchange *Exchange, uri string, ignoreInvalid bool) (bool, error) {
+	ignored := false
+	err := interceptSend(c.context, exchange, uri, func() error {
+		producer, err := c.acquire(nil, uri)
+		if err != nil {
+			var invalid *invalidEndpointError
+			if ignoreInvalid && errors.As(err, &invalid) {
+				ignored = true
+				return nil
+			}
+			return err
+		}
+		return producer.Send(exchange)
+	})
+	return !ignored, err
+}
+
+// size retourne le nombre de producteurs en cache
+func (c *producerCache) size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.producers)
+}
+
+// stop arrête et retire tous les producteurs du cache
+func (c *producerCache) stop() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for uri, producer := range c.producers {
+		if err := producer.Stop(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("erreur lors de l'arrêt du producteur %s: %w", uri, err)
+		}
+		delete(c.producers, uri)
+	}
+	return firstErr
+}
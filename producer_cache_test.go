@@ -0,0 +1,89 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trackingComponent compte ses producteurs démarrés ; le producteur de "tracking:fail" ne démarre pas
+type trackingComponent struct {
+	started atomic.Int32
+}
+
+func (c *trackingComponent) CreateEndpoint(uri string) (Endpoint, error) {
+	return &trackingEndpoint{component: c, uri: uri}, nil
+}
+
+type trackingEndpoint struct {
+	component *trackingComponent
+	uri       string
+}
+
+func (e *trackingEndpoint) URI() string { return e.uri }
+func (e *trackingEndpoint) CreateProducer() (Producer, error) {
+	return &trackingProducer{component: e.component, fail: e.uri == "tracking:fail"}, nil
+}
+func (e *trackingEndpoint) CreateConsumer(p Processor) (Consumer, error) {
+	return nil, fmt.Errorf("consumer not supported")
+}
+
+type trackingProducer struct {
+	component *trackingComponent
+	fail      bool
+}
+
+func (p *trackingProducer) Start(ctx context.Context) error {
+	if p.fail {
+		return errors.New("connection refused")
+	}
+	p.component.started.Add(1)
+	return nil
+}
+func (p *trackingProducer) Stop() error {
+	p.component.started.Add(-1)
+	return nil
+}
+func (p *trackingProducer) Send(e *Exchange) error { return nil }
+
+func TestProducerCache_IgnoreInvalidEndpoints(t *testing.T) {
+	camel := NewCamelContext()
+	camel.AddComponent("tracking", &trackingComponent{})
+	cache := newProducerCache(camel)
+	defer cache.stop()
+
+	// Seule une URI dont l'endpoint ne peut pas être créé est ignorée
+	sent, err := cache.send(NewExchange(context.Background()), "unknown:a", true)
+	require.NoError(t, err)
+	assert.False(t, sent)
+
+	_, err = cache.send(NewExchange(context.Background()), "tracking:fail", true)
+	assert.ErrorContains(t, err, "connection refused")
+
+	sent, err = cache.send(NewExchange(context.Background()), "tracking:a", true)
+	require.NoError(t, err)
+	assert.True(t, sent)
+}
+
+func TestProducerCache_StoppedWithContext(t *testing.T) {
+	camel := NewCamelContext()
+	camel.AddComponent("direct", NewDirectComponent())
+	tracking := &trackingComponent{}
+	camel.AddComponent("tracking", tracking)
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		RecipientList("tracking:a,tracking:b", "").
+		Build()
+
+	require.NoError(t, camel.Start())
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "hello"))
+	assert.Equal(t, int32(2), tracking.started.Load())
+
+	require.NoError(t, camel.Stop())
+	assert.Equal(t, int32(0), tracking.started.Load())
+}
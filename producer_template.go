@@ -0,0 +1,60 @@
+package gocamel
+
+// ProducerTemplate permet d'envoyer des messages vers n'importe quel endpoint depuis du code Go,
+// en dehors de toute route. Les producteurs sont créés à la demande et mis en cache par URI.
+type ProducerTemplate struct {
+	context *CamelContext
+	cache   *producerCache
+}
+
+// NewProducerTemplate crée une nouvelle instance de ProducerTemplate
+func NewProducerTemplate(context *CamelContext) *ProducerTemplate {
+	return &ProducerTemplate{
+		context: context,
+		cache:   newProducerCache(context),
+	}
+}
+
+// Send envoie un échange existant vers l'endpoint désigné par l'URI. L'appelant reste
+// responsable de l'échange : c'est son appel à Exchange.Done qui déclenche les synchronisations
+// (ex: enregistrement de la clé d'un idempotent consumer non eager).
+func (t *ProducerTemplate) Send(uri string, exchange *Exchange) error {
+	producer, err := t.cache.acquire(exchange.Context, uri)
+	if err != nil {
+		return err
+	}
+	return producer.Send(exchange)
+}
+
+// SendBody envoie un message contenant le corps donné
+func (t *ProducerTemplate) SendBody(uri string, body any) error {
+	_, err := t.Request(uri, body, nil)
+	return err
+}
+
+// SendBodyAndHeaders envoie un message contenant le corps et les en-têtes donnés
+func (t *ProducerTemplate) SendBodyAndHeaders(uri string, body any, headers map[string]any) error {
+	_, err := t.Request(uri, body, headers)
+	return err
+}
+
+// Request envoie un message et retourne l'échange résultant, dont GetResponse()
+// fournit la réponse éventuelle de l'endpoint. L'échange étant créé par le template, il est
+// terminé (Exchange.Done) avant d'être retourné.
+func (t *ProducerTemplate) Request(uri string, body any, headers map[string]any) (*Exchange, error) {
+	exchange := NewExchange(t.context.GetContext())
+	exchange.GetIn().SetBody(body)
+	exchange.GetIn().SetHeaders(headers)
+
+	err := t.Send(uri, exchange)
+	exchange.Done(err)
+	if err != nil {
+		return exchange, err
+	}
+	return exchange, nil
+}
+
+// Stop arrête tous les producteurs créés par le template
+func (t *ProducerTemplate) Stop() error {
+	return t.cache.stop()
+}
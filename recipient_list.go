@@ -0,0 +1,333 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CamelRecipientListEndpoint est la propriété contenant l'URI du destinataire sur chaque copie
+const CamelRecipientListEndpoint = "CamelRecipientListEndpoint"
+
+// DefaultRecipientListDelimiter est le séparateur utilisé lorsque l'expression retourne une chaîne
+const DefaultRecipientListDelimiter = ","
+
+// RecipientList est un Processor qui implémente le Recipient List EIP.
+// Les destinataires sont calculés pour chaque échange à partir d'une expression, puis chacun
+// reçoit une copie de l'échange. Les producteurs sont mis en cache par URI.
+type RecipientList struct {
+	context    *CamelContext
+	Expression func(*Exchange) (any, error)
+	Delimiter  string
+	cache      *producerCache
+
+	AggregationStrategy AggregationStrategy
+	ParallelProcessing  bool
+	// StopOnException arrête l'envoi aux destinataires suivants dès la première erreur
+	StopOnException bool
+	// Timeout borne la durée totale d'un envoi parallèle ; les réponses arrivées après ne sont pas agrégées
+	Timeout time.Duration
+	// IgnoreInvalidEndpoints ignore les destinataires dont l'endpoint ne peut pas être créé
+	IgnoreInvalidEndpoints bool
+}
+
+// NewRecipientList crée un nouveau RecipientList
+func NewRecipientList(context *CamelContext, expression func(*Exchange) (any, error), delimiter string) *RecipientList {
+	if delimiter == "" {
+		delimiter = DefaultRecipientListDelimiter
+	}
+	return &RecipientList{
+		context:    context,
+		Expression: expression,
+		Delimiter:  delimiter,
+		cache:      newRouteProducerCache(context),
+	}
+}
+
+// SetAggregationStrategy définit la stratégie d'agrégation des réponses
+func (r *RecipientList) SetAggregationStrategy(strategy AggregationStrategy) *RecipientList {
+	r.AggregationStrategy = strategy
+	return r
+}
+
+// SetParallelProcessing active ou désactive l'envoi parallèle
+func (r *RecipientList) SetParallelProcessing(parallel bool) *RecipientList {
+	r.ParallelProcessing = parallel
+	return r
+}
+
+// SetStopOnException active ou désactive l'arrêt à la première erreur
+func (r *RecipientList) SetStopOnException(stop bool) *RecipientList {
+	r.StopOnException = stop
+	return r
+}
+
+// SetTimeout définit la durée maximale d'un envoi parallèle
+func (r *RecipientList) SetTimeout(timeout time.Duration) *RecipientList {
+	r.Timeout = timeout
+	return r
+}
+
+// SetIgnoreInvalidEndpoints active ou désactive l'ignorance des endpoints invalides
+func (r *RecipientList) SetIgnoreInvalidEndpoints(ignore bool) *RecipientList {
+	r.IgnoreInvalidEndpoints = ignore
+	return r
+}
+
+// Process implémente l'interface Processor
+func (r *RecipientList) Process(exchange *Exchange) error {
+	propagateOut(exchange)
+
+	value, err := r.Expression(exchange)
+	if err != nil {
+		return fmt.Errorf("recipient list expression error: %w", err)
+	}
+	recipients := splitEndpointURIs(value, r.Delimiter)
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	if r.ParallelProcessing {
+		return r.processParallel(exchange, recipients)
+	}
+	return r.processSequential(exchange, recipients)
+}
+
+// splitEndpointURIs convertit le résultat d'une expression (URI, chaîne délimitée ou liste)
+// en liste d'URIs
+func splitEndpointURIs(value any, delimiter string) []string {
+	if value == nil {
+		return nil
+	}
+
+	var values []any
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		if b, ok := value.([]byte); ok {
+			values = []any{string(b)}
+		} else {
+			for i := 0; i < v.Len(); i++ {
+				values = append(values, v.Index(i).Interface())
+			}
+		}
+	} else {
+		values = []any{value}
+	}
+
+	recipients := make([]string, 0, len(values))
+	for _, item := range values {
+		for _, uri := range strings.Split(fmt.Sprintf("%v", item), delimiter) {
+			if uri = strings.TrimSpace(uri); uri != "" {
+				recipients = append(recipients, uri)
+			}
+		}
+	}
+	return recipients
+}
+
+// newRecipientExchange crée la copie de l'échange destinée à un destinataire
+func (r *RecipientList) newRecipientExchange(exchange *Exchange, uri string, index, size int) *Exchange {
+	recipientExchange := exchange.Copy()
+	recipientExchange.SetProperty(CamelRecipientListEndpoint, uri)
+	recipientExchange.SetProperty("CamelMulticastIndex", index)
+	recipientExchange.SetProperty("CamelMulticastSize", size)
+	recipientExchange.SetProperty("CamelMulticastComplete", index == size-1)
+	return recipientExchange
+}
+
+// send envoie la copie au destinataire. Le booléen retourné est faux si le destinataire a été
+// ignoré car son endpoint est invalide.
+func (r *RecipientList) send(exchange *Exchange, uri string) (bool, error) {
+	sent, err := r.cache.send(exchange, uri, r.IgnoreInvalidEndpoints)
+	if err != nil && errors.Is(err, ErrStopRouting) {
+		err = nil
+	}
+	return sent, err
+}
+
+func (r *RecipientList) processSequential(exchange *Exchange, recipients []string) error {
+	var aggregatedExchange *Exchange
+	var firstErr error
+
+	for i, uri := range recipients {
+		recipientExchange := r.newRecipientExchange(exchange, uri, i, len(recipients))
+
+		sent, err := r.send(recipientExchange, uri)
+		if err != nil {
+			if r.StopOnException {
+				return fmt.Errorf("recipient list error on %s: %w", uri, err)
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("recipient list error on %s: %w", uri, err)
+			}
+			continue
+		}
+		if !sent {
+			continue
+		}
+
+		if r.AggregationStrategy != nil {
+			aggregatedExchange = r.AggregationStrategy.Aggregate(aggregatedExchange, recipientExchange)
+		}
+	}
+
+	r.applyAggregation(exchange, aggregatedExchange)
+	return firstErr
+}
+
+func (r *RecipientList) processParallel(exchange *Exchange, recipients []string) error {
+	var (
+		wg                 sync.WaitGroup
+		mu                 sync.Mutex
+		aggregatedExchange *Exchange
+		firstErr           error
+		finished           bool
+	)
+
+	ctx := exchange.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var cancel context.CancelFunc
+	if r.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	for i, uri := range recipients {
+		recipientExchange := r.newRecipientExchange(exchange, uri, i, len(recipients))
+		recipientExchange.Context = ctx
+
+		wg.Add(1)
+		go func(recipientExchange *Exchange, uri string) {
+			defer wg.Done()
+
+			// Avec StopOnException, les destinataires pas encore servis sont abandonnés
+			if ctx.Err() != nil {
+				return
+			}
+			sent, err := r.send(recipientExchange, uri)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if finished {
+				return
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("recipient list error on %s: %w", uri, err)
+				}
+				if r.StopOnException {
+					cancel()
+				}
+				return
+			}
+			if sent && r.AggregationStrategy != nil {
+				aggregatedExchange = r.AggregationStrategy.Aggregate(aggregatedExchange, recipientExchange)
+			}
+		}(recipientExchange, uri)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// Délai dépassé (ou arrêt sur erreur) : on continue avec ce qui a été reçu
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	finished = true
+	// Comme en séquentiel, les réponses reçues sont agrégées malgré l'erreur, sauf avec StopOnException
+	if firstErr != nil && r.StopOnException {
+		return firstErr
+	}
+	r.applyAggregation(exchange, aggregatedExchange)
+	return firstErr
+}
+
+func (r *RecipientList) applyAggregation(exchange *Exchange, aggregatedExchange *Exchange) {
+	if r.AggregationStrategy != nil && aggregatedExchange != nil {
+		exchange.In = aggregatedExchange.In
+		exchange.Out = aggregatedExchange.Out
+		exchange.Properties = aggregatedExchange.Properties
+	}
+}
+
+// simpleValueExpression retourne une expression évaluant un template Simple. Un template réduit
+// à une seule variable (ex: ${header.recipients}) retourne la valeur brute, ce qui permet
+// d'utiliser directement un en-tête contenant une liste.
+func simpleValueExpression(template *SimpleTemplate) func(*Exchange) (any, error) {
+	if len(template.parts) == 1 && template.parts[0].isVariable {
+		content := template.parts[0].content
+		return func(exchange *Exchange) (any, error) {
+			return evaluateVariable(content, exchange)
+		}
+	}
+	return template.Evaluate
+}
+
+// RecipientList ajoute un Recipient List dont les destinataires sont calculés par une
+// expression Simple. Une chaîne est découpée selon le délimiteur ("," par défaut).
+func (b *RouteBuilder) RecipientList(expression string, delimiter string) *RecipientListDefinition {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for recipientList: %v", err))
+	}
+	return b.RecipientListFunc(simpleValueExpression(template), delimiter)
+}
+
+// RecipientListFunc ajoute un Recipient List dont les destinataires sont calculés par une
+// fonction Go retournant une URI, une chaîne délimitée ou une liste
+func (b *RouteBuilder) RecipientListFunc(expression func(*Exchange) (any, error), delimiter string) *RecipientListDefinition {
+	r := NewRecipientList(b.context, expression, delimiter)
+	b.container.AddProcessor(r)
+	return &RecipientListDefinition{RouteBuilder: b, recipientList: r}
+}
+
+// RecipientListDefinition permet de configurer le Recipient List EIP. Les autres méthodes
+// continuent la route.
+type RecipientListDefinition struct {
+	*RouteBuilder
+	recipientList *RecipientList
+}
+
+// AggregationStrategy définit la stratégie d'agrégation des réponses
+func (d *RecipientListDefinition) AggregationStrategy(strategy AggregationStrategy) *RecipientListDefinition {
+	d.recipientList.SetAggregationStrategy(strategy)
+	return d
+}
+
+// ParallelProcessing active l'envoi parallèle aux destinataires
+func (d *RecipientListDefinition) ParallelProcessing() *RecipientListDefinition {
+	d.recipientList.SetParallelProcessing(true)
+	return d
+}
+
+// StopOnException arrête l'envoi aux destinataires suivants dès la première erreur
+func (d *RecipientListDefinition) StopOnException() *RecipientListDefinition {
+	d.recipientList.SetStopOnException(true)
+	return d
+}
+
+// Timeout définit la durée maximale d'un envoi parallèle
+func (d *RecipientListDefinition) Timeout(timeout time.Duration) *RecipientListDefinition {
+	d.recipientList.SetTimeout(timeout)
+	return d
+}
+
+// IgnoreInvalidEndpoints ignore les destinataires dont l'endpoint ne peut pas être créé
+func (d *RecipientListDefinition) IgnoreInvalidEndpoints() *RecipientListDefinition {
+	d.recipientList.SetIgnoreInvalidEndpoints(true)
+	return d
+}
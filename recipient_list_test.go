@@ -0,0 +1,188 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecipientList_HeaderWithAggregation(t *testing.T) {
+	camel := newTestContext()
+
+	definition := camel.CreateRouteBuilder().
+		From("direct:start").
+		RecipientList("${header.recipients}", ";").
+		AggregationStrategy(&StringConcatStrategy{})
+	definition.To("mock:result").Build()
+
+	a, _ := camel.GetMockEndpoint("mock:a")
+	a.ReturnReplyBody("A")
+	a.ExpectedMessageCount(2)
+	a.MessageN(0).Predicate(func(e *Exchange) bool {
+		uri, _ := e.GetPropertyAsString(CamelRecipientListEndpoint)
+		return uri == "mock:a"
+	})
+	b, _ := camel.GetMockEndpoint("mock:b")
+	b.ReturnReplyBody("B")
+	b.ExpectedMessageCount(2)
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("A,B", "A,B")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	headers := map[string]any{"recipients": "mock:a; mock:b"}
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "order", headers))
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "order", headers))
+
+	a.AssertIsSatisfied(t, time.Second)
+	b.AssertIsSatisfied(t, time.Second)
+	result.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, 2, definition.recipientList.cache.size(), "producers are cached by URI")
+}
+
+func TestRecipientList_FuncReturningList(t *testing.T) {
+	camel := newTestContext()
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		RecipientListFunc(func(e *Exchange) (any, error) {
+			return []string{"mock:a", "mock:b,mock:c"}, nil
+		}, "").
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "x"))
+	for _, uri := range []string{"mock:a", "mock:b", "mock:c"} {
+		endpoint, _ := camel.GetMockEndpoint(uri)
+		endpoint.ExpectedBodiesReceived("x")
+		endpoint.AssertIsSatisfied(t, time.Second)
+	}
+}
+
+func TestRecipientList_ParallelTimeout(t *testing.T) {
+	camel := newTestContext()
+
+	release := make(chan struct{})
+	defer close(release)
+	slow, _ := camel.GetMockEndpoint("mock:slow")
+	slow.WhenAnyExchangeReceived(ProcessorFunc(func(e *Exchange) error {
+		<-release
+		e.GetIn().SetBody("slow")
+		return nil
+	}))
+	fast, _ := camel.GetMockEndpoint("mock:fast")
+	fast.ReturnReplyBody("fast")
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		RecipientList("mock:slow,mock:fast", "").
+		ParallelProcessing().
+		Timeout(50 * time.Millisecond).
+		AggregationStrategy(&StringConcatStrategy{}).
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	start := time.Now()
+	reply, err := camel.CreateProducerTemplate().Request("direct:start", "x", nil)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	body, _ := reply.GetIn().GetBodyAsString()
+	assert.Equal(t, "fast", body)
+}
+
+func TestRecipientList_StopOnException(t *testing.T) {
+	boom := errors.New("boom")
+
+	for _, stop := range []bool{false, true} {
+		camel := newTestContext()
+		failing, _ := camel.GetMockEndpoint("mock:failing")
+		failing.ReturnError(boom)
+
+		definition := camel.CreateRouteBuilder().
+			From("direct:start").
+			RecipientList("mock:failing,mock:next", "")
+		if stop {
+			definition.StopOnException()
+		}
+		definition.Build()
+
+		require.NoError(t, camel.Start())
+
+		err := camel.CreateProducerTemplate().SendBody("direct:start", "x")
+		assert.ErrorIs(t, err, boom)
+		next, _ := camel.GetMockEndpoint("mock:next")
+		if stop {
+			assert.Equal(t, 0, next.ReceivedCounter())
+		} else {
+			assert.Equal(t, 1, next.ReceivedCounter())
+		}
+		camel.Stop()
+	}
+}
+
+func TestRecipientList_AggregatesDespiteError(t *testing.T) {
+	boom := errors.New("boom")
+
+	// Sans StopOnException, les modes séquentiel et parallèle agrègent les réponses reçues
+	for _, parallel := range []bool{false, true} {
+		camel := newTestContext()
+		failing, _ := camel.GetMockEndpoint("mock:failing")
+		failing.ReturnError(boom)
+		next, _ := camel.GetMockEndpoint("mock:next")
+		next.ReturnReplyBody("next")
+
+		definition := camel.CreateRouteBuilder().
+			From("direct:start").
+			RecipientList("mock:failing,mock:next", "").
+			AggregationStrategy(&StringConcatStrategy{})
+		if parallel {
+			definition.ParallelProcessing()
+		}
+		definition.Build()
+
+		require.NoError(t, camel.Start())
+
+		exchange := NewExchange(context.Background())
+		exchange.GetIn().SetBody("x")
+		err := camel.CreateProducerTemplate().Send("direct:start", exchange)
+		assert.ErrorIs(t, err, boom)
+		body, _ := exchange.GetIn().GetBodyAsString()
+		assert.Equal(t, "next", body, "parallel=%v", parallel)
+		camel.Stop()
+	}
+}
+
+func TestRecipientList_IgnoreInvalidEndpoints(t *testing.T) {
+	camel := newTestContext()
+
+	camel.CreateRouteBuilder().
+		From("direct:strict").
+		RecipientList("unknown:a,mock:a", "").
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:lenient").
+		RecipientList("unknown:a,mock:a", "").
+		IgnoreInvalidEndpoints().
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	assert.Error(t, template.SendBody("direct:strict", "strict"))
+	require.NoError(t, template.SendBody("direct:lenient", "lenient"))
+
+	a, _ := camel.GetMockEndpoint("mock:a")
+	a.ExpectedBodiesReceived("strict", "lenient")
+	a.AssertIsSatisfied(t, time.Second)
+}
@@ -0,0 +1,535 @@
+package gocamel
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResequencerMode est le mode de fonctionnement d'un resequencer
+type ResequencerMode string
+
+const (
+	// ResequenceBatch collecte les échanges par lots et les émet triés
+	ResequenceBatch ResequencerMode = "batch"
+	// ResequenceStream émet les échanges au fil de l'eau selon leur numéro de séquence
+	ResequenceStream ResequencerMode = "stream"
+)
+
+// ResequencerRejectedError est retournée en mode stream avec RejectOld lorsqu'un échange
+// arrive avec un numéro de séquence déjà dépassé
+type ResequencerRejectedError struct {
+	Sequence      int64
+	LastDelivered int64
+}
+
+func (e *ResequencerRejectedError) Error() string {
+	return fmt.Sprintf("resequencer rejected exchange: sequence %d is not after last delivered sequence %d", e.Sequence, e.LastDelivered)
+}
+
+// Resequencer implémente le Resequencer EIP : les échanges sont remis en ordre selon la valeur
+// de l'expression avant de traverser le bloc.
+//
+// En mode batch, les échanges sont collectés jusqu'à BatchSize échanges ou pendant BatchTimeout,
+// puis émis triés. En mode stream, l'expression donne un numéro de séquence entier : un échange
+// est émis dès que son prédécesseur l'a été ; un trou dans la séquence est ignoré lorsque
+// l'échange suivant a attendu Timeout, ou lorsque Capacity échanges sont en attente.
+//
+// Les échanges sont transférés au resequencer avec leurs synchronisations : l'appelant reçoit
+// ErrStopRouting et les synchronisations ne sont déclenchées qu'à la fin du traitement du bloc.
+// Les échanges en attente échouent avec l'annulation du CamelContext.
+type Resequencer struct {
+	context *CamelContext
+	// Expression calcule la valeur de tri de l'échange
+	Expression func(*Exchange) (any, error)
+	Mode       ResequencerMode
+
+	// BatchSize est le nombre d'échanges d'un lot (mode batch)
+	BatchSize int
+	// BatchTimeout est la durée de collecte d'un lot, à partir de son premier échange (mode batch)
+	BatchTimeout time.Duration
+	// AllowDuplicates conserve les échanges de même valeur dans un lot (mode batch)
+	AllowDuplicates bool
+	// Reverse émet les lots dans l'ordre décroissant (mode batch)
+	Reverse bool
+
+	// Capacity est le nombre maximal d'échanges en attente (mode stream)
+	Capacity int
+	// Timeout est la durée d'attente d'un échange bloqué par un trou dans la séquence (mode stream)
+	Timeout time.Duration
+	// RejectOld rejette les échanges dont le numéro est déjà dépassé au lieu de les émettre (mode stream)
+	RejectOld bool
+
+	processors []Processor
+
+	// mu protège l'état et sérialise l'émission, pour que le bloc reçoive les échanges dans l'ordre
+	mu         sync.Mutex
+	batch      []*sequencedExchange
+	batchStart time.Time
+	buffer     map[int64]*sequencedExchange
+	last       int64
+	delivered  bool
+	timer      *time.Timer
+	watchStart sync.Once
+}
+
+// sequencedExchange est un échange en attente avec sa valeur de tri
+type sequencedExchange struct {
+	exchange *Exchange
+	value    any
+	sequence int64
+	deadline time.Time
+}
+
+// NewResequencer crée un resequencer en mode batch (lots de 100 échanges ou d'une seconde)
+func NewResequencer(context *CamelContext, expression func(*Exchange) (any, error)) *Resequencer {
+	return &Resequencer{
+		context:      context,
+		Expression:   expression,
+		Mode:         ResequenceBatch,
+		BatchSize:    100,
+		BatchTimeout: time.Second,
+		Capacity:     1000,
+		Timeout:      time.Second,
+		processors:   make([]Processor, 0),
+		buffer:       make(map[int64]*sequencedExchange),
+	}
+}
+
+// AddProcessor ajoute un processeur recevant les échanges remis en ordre
+func (r *Resequencer) AddProcessor(processor Processor) {
+	r.processors = append(r.processors, processor)
+}
+
+// Process implémente l'interface Processor
+func (r *Resequencer) Process(exchange *Exchange) error {
+	value, err := r.Expression(exchange)
+	if err != nil {
+		return fmt.Errorf("resequencer expression error: %w", err)
+	}
+	if err := r.context.GetContext().Err(); err != nil {
+		return err
+	}
+	r.watchStart.Do(r.watch)
+
+	if r.Mode == ResequenceStream {
+		sequence, err := toSequenceNumber(value)
+		if err != nil {
+			return fmt.Errorf("resequencer expression error: %w", err)
+		}
+		return r.processStream(exchange, sequence)
+	}
+	return r.processBatch(exchange, value)
+}
+
+func (r *Resequencer) processBatch(exchange *Exchange, value any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batch = append(r.batch, &sequencedExchange{
+		exchange: exchange.handover(r.context.GetContext()),
+		value:    value,
+	})
+	if len(r.batch) >= r.BatchSize {
+		r.emitBatchLocked()
+	} else if len(r.batch) == 1 {
+		r.batchStart = time.Now()
+		r.timer = time.AfterFunc(r.BatchTimeout, r.batchTimeout)
+	}
+	return ErrStopRouting
+}
+
+func (r *Resequencer) batchTimeout() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Le lot a pu être émis par taille pendant que le timer attendait le verrou
+	if len(r.batch) == 0 || time.Since(r.batchStart) < r.BatchTimeout {
+		return
+	}
+	r.emitBatchLocked()
+}
+
+// emitBatchLocked trie le lot en cours et le fait traverser le bloc
+func (r *Resequencer) emitBatchLocked() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	batch := r.batch
+	r.batch = nil
+
+	sort.SliceStable(batch, func(i, j int) bool {
+		if r.Reverse {
+			return compareSequence(batch[i].value, batch[j].value) > 0
+		}
+		return compareSequence(batch[i].value, batch[j].value) < 0
+	})
+	for i, item := range batch {
+		if !r.AllowDuplicates && i > 0 && compareSequence(batch[i-1].value, item.value) == 0 {
+			item.exchange.Done(nil)
+			continue
+		}
+		r.deliver(item.exchange)
+	}
+}
+
+func (r *Resequencer) processStream(exchange *Exchange, sequence int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, pending := r.buffer[sequence]; pending || (r.delivered && sequence <= r.last) {
+		if r.RejectOld {
+			return &ResequencerRejectedError{Sequence: sequence, LastDelivered: r.last}
+		}
+		// Échange en retard : il est émis immédiatement, hors séquence
+		r.deliver(exchange.handover(r.context.GetContext()))
+		return ErrStopRouting
+	}
+
+	r.buffer[sequence] = &sequencedExchange{
+		exchange: exchange.handover(r.context.GetContext()),
+		sequence: sequence,
+		deadline: time.Now().Add(r.Timeout),
+	}
+	r.emitReadyLocked()
+	if len(r.buffer) >= r.Capacity {
+		// Capacité atteinte : le trou avant le plus petit numéro en attente est ignoré
+		r.emitUntilLocked(r.lowestLocked())
+	}
+	r.scheduleLocked()
+	return ErrStopRouting
+}
+
+func (r *Resequencer) streamTimeout() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	expired, found := int64(0), false
+	for sequence, item := range r.buffer {
+		if !item.deadline.After(now) && (!found || sequence > expired) {
+			expired, found = sequence, true
+		}
+	}
+	if found {
+		r.emitUntilLocked(expired)
+	}
+	r.scheduleLocked()
+}
+
+// emitReadyLocked émet les échanges qui suivent directement le dernier échange émis
+func (r *Resequencer) emitReadyLocked() {
+	if !r.delivered {
+		return
+	}
+	for {
+		item, ok := r.buffer[r.last+1]
+		if !ok {
+			return
+		}
+		delete(r.buffer, item.sequence)
+		r.last = item.sequence
+		r.deliver(item.exchange)
+	}
+}
+
+// emitUntilLocked émet dans l'ordre les échanges en attente jusqu'au numéro donné, en ignorant
+// les trous, puis ceux qui le suivent directement
+func (r *Resequencer) emitUntilLocked(sequence int64) {
+	pending := make([]int64, 0, len(r.buffer))
+	for s := range r.buffer {
+		if s <= sequence {
+			pending = append(pending, s)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i] < pending[j] })
+	for _, s := range pending {
+		item := r.buffer[s]
+		delete(r.buffer, s)
+		r.deliver(item.exchange)
+	}
+	r.last, r.delivered = sequence, true
+	r.emitReadyLocked()
+}
+
+func (r *Resequencer) lowestLocked() int64 {
+	lowest := int64(math.MaxInt64)
+	for s := range r.buffer {
+		lowest = min(lowest, s)
+	}
+	return lowest
+}
+
+// scheduleLocked arme le timer sur la plus proche échéance des échanges en attente
+func (r *Resequencer) scheduleLocked() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	var next time.Time
+	for _, item := range r.buffer {
+		if next.IsZero() || item.deadline.Before(next) {
+			next = item.deadline
+		}
+	}
+	if !next.IsZero() {
+		r.timer = time.AfterFunc(time.Until(next), r.streamTimeout)
+	}
+}
+
+// deliver fait traverser le bloc à l'échange et déclenche ses synchronisations
+func (r *Resequencer) deliver(exchange *Exchange) {
+	var err error
+	for _, p := range r.processors {
+		if err = p.Process(exchange); err != nil {
+			break
+		}
+	}
+	if err != nil && !errors.Is(err, ErrStopRouting) {
+		log.Printf("Resequencer: échec du traitement de l'échange %s: %v", exchange.ID, err)
+	}
+	exchange.Done(err)
+}
+
+// watch fait échouer les échanges en attente à l'arrêt du CamelContext
+func (r *Resequencer) watch() {
+	ctx := r.context.GetContext()
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.timer != nil {
+			r.timer.Stop()
+			r.timer = nil
+		}
+		for _, item := range r.batch {
+			item.exchange.Done(ctx.Err())
+		}
+		r.batch = nil
+		for s, item := range r.buffer {
+			item.exchange.Done(ctx.Err())
+			delete(r.buffer, s)
+		}
+	}()
+}
+
+// toSequenceNumber convertit la valeur d'une expression en numéro de séquence
+func toSequenceNumber(value any) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int64(v), nil
+		}
+	case []byte:
+		return toSequenceNumber(string(v))
+	case string:
+		if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid sequence number: %v", value)
+}
+
+// compareSequence compare deux valeurs de tri : numériquement si les deux sont des nombres,
+// chronologiquement pour des instants, et sinon selon leur représentation textuelle
+func compareSequence(a, b any) int {
+	if x, ok := sequenceFloat(a); ok {
+		if y, ok := sequenceFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func sequenceFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Resequence commence un bloc Resequencer EIP triant les échanges selon une expression Simple
+// (ex: "${header.seqnum}"). Le mode batch est utilisé par défaut ; Stream() active le mode stream.
+func (b *RouteBuilder) Resequence(expression string) *ResequenceDefinition {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for resequence: %v", err))
+	}
+	return b.ResequenceFunc(simpleValueExpression(template))
+}
+
+// ResequenceFunc commence un bloc Resequencer EIP dont la valeur de tri est calculée par une fonction Go
+func (b *RouteBuilder) ResequenceFunc(expression func(*Exchange) (any, error)) *ResequenceDefinition {
+	r := NewResequencer(b.context, expression)
+	b.container.AddProcessor(r)
+
+	return &ResequenceDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: r,
+		},
+		parent:      b,
+		resequencer: r,
+	}
+}
+
+// ResequenceDefinition permet de configurer le Resequencer et les processeurs recevant les échanges remis en ordre
+type ResequenceDefinition struct {
+	*RouteBuilder
+	parent      *RouteBuilder
+	resequencer *Resequencer
+}
+
+func (d *ResequenceDefinition) requireMode(mode ResequencerMode, option string) {
+	if d.resequencer.Mode != mode {
+		panic(fmt.Sprintf("%s is only supported in %s mode", option, mode))
+	}
+}
+
+// Batch active le mode batch (par défaut)
+func (d *ResequenceDefinition) Batch() *ResequenceDefinition {
+	d.resequencer.Mode = ResequenceBatch
+	return d
+}
+
+// BatchSize définit le nombre d'échanges d'un lot
+func (d *ResequenceDefinition) BatchSize(size int) *ResequenceDefinition {
+	d.requireMode(ResequenceBatch, "BatchSize")
+	d.resequencer.BatchSize = max(size, 1)
+	return d
+}
+
+// BatchTimeout définit la durée de collecte d'un lot
+func (d *ResequenceDefinition) BatchTimeout(timeout time.Duration) *ResequenceDefinition {
+	d.requireMode(ResequenceBatch, "BatchTimeout")
+	d.resequencer.BatchTimeout = timeout
+	return d
+}
+
+// AllowDuplicates conserve les échanges de même valeur dans un lot
+func (d *ResequenceDefinition) AllowDuplicates() *ResequenceDefinition {
+	d.requireMode(ResequenceBatch, "AllowDuplicates")
+	d.resequencer.AllowDuplicates = true
+	return d
+}
+
+// Reverse émet les lots dans l'ordre décroissant
+func (d *ResequenceDefinition) Reverse() *ResequenceDefinition {
+	d.requireMode(ResequenceBatch, "Reverse")
+	d.resequencer.Reverse = true
+	return d
+}
+
+// Stream active le mode stream : l'expression doit donner un numéro de séquence entier
+func (d *ResequenceDefinition) Stream() *ResequenceDefinition {
+	d.resequencer.Mode = ResequenceStream
+	return d
+}
+
+// Capacity définit le nombre maximal d'échanges en attente en mode stream
+func (d *ResequenceDefinition) Capacity(capacity int) *ResequenceDefinition {
+	d.requireMode(ResequenceStream, "Capacity")
+	d.resequencer.Capacity = max(capacity, 1)
+	return d
+}
+
+// Timeout définit la durée d'attente d'un échange bloqué par un trou dans la séquence
+func (d *ResequenceDefinition) Timeout(timeout time.Duration) *ResequenceDefinition {
+	d.requireMode(ResequenceStream, "Timeout")
+	d.resequencer.Timeout = timeout
+	return d
+}
+
+// RejectOld rejette avec une ResequencerRejectedError les échanges dont le numéro est déjà dépassé
+func (d *ResequenceDefinition) RejectOld() *ResequenceDefinition {
+	d.requireMode(ResequenceStream, "RejectOld")
+	d.resequencer.RejectOld = true
+	return d
+}
+
+// Process ajoute un processeur et reste dans le contexte du resequencer
+func (d *ResequenceDefinition) Process(processor Processor) *ResequenceDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte du resequencer
+func (d *ResequenceDefinition) ProcessFunc(f func(*Exchange) error) *ResequenceDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte du resequencer
+func (d *ResequenceDefinition) To(uris ...string) *ResequenceDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD ajoute un ou plusieurs endpoints dynamiques et reste dans le contexte du resequencer
+func (d *ResequenceDefinition) ToD(uriTemplates ...string) *ResequenceDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody définit le corps du message de sortie et reste dans le contexte du resequencer
+func (d *ResequenceDefinition) SetBody(body interface{}) *ResequenceDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte du resequencer
+func (d *ResequenceDefinition) SetHeader(key string, value interface{}) *ResequenceDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetProperty définit une propriété de l'échange et reste dans le contexte du resequencer
+func (d *ResequenceDefinition) SetProperty(key string, value any) *ResequenceDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte du resequencer
+func (d *ResequenceDefinition) Log(message string) *ResequenceDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End termine le bloc Resequence et revient au builder parent
+func (d *ResequenceDefinition) End() *RouteBuilder {
+	return d.parent
+}
@@ -0,0 +1,189 @@
+package gocamel
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendSequence envoie un échange portant le numéro de séquence donné dans l'en-tête seqnum
+func sendSequence(t *testing.T, template *ProducerTemplate, uri string, seqnum int) {
+	err := template.SendBodyAndHeaders(uri, fmt.Sprint(seqnum), map[string]any{"seqnum": seqnum})
+	assert.ErrorIs(t, err, ErrStopRouting)
+}
+
+func TestResequence_Batch(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:size").
+		Resequence("${header.seqnum}").
+		BatchSize(5).
+		BatchTimeout(time.Minute).
+		To("mock:size").
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:timeout").
+		Resequence("${header.seqnum}").
+		BatchTimeout(50 * time.Millisecond).
+		Reverse().
+		AllowDuplicates().
+		To("mock:timeout").
+		End().
+		Build()
+
+	size, _ := camel.GetMockEndpoint("mock:size")
+	size.ExpectedBodiesReceived("1", "2", "3", "10")
+	timeout, _ := camel.GetMockEndpoint("mock:timeout")
+	timeout.ExpectedBodiesReceived("3", "2", "2", "1")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	// Le doublon 2 est écarté ; 10 est trié numériquement
+	for _, seqnum := range []int{10, 2, 1, 2, 3} {
+		sendSequence(t, template, "direct:size", seqnum)
+	}
+	for _, seqnum := range []int{2, 1, 3, 2} {
+		sendSequence(t, template, "direct:timeout", seqnum)
+	}
+	assert.Equal(t, 0, timeout.ReceivedCounter())
+
+	size.AssertIsSatisfied(t, time.Second)
+	timeout.AssertIsSatisfied(t, time.Second)
+}
+
+func TestResequence_StreamParallelProducers(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Resequence("${header.seqnum}").
+		Stream().
+		Timeout(200 * time.Millisecond).
+		To("mock:result").
+		End().
+		Build()
+
+	expected := make([]any, 0, 20)
+	for i := 1; i <= 20; i++ {
+		expected = append(expected, fmt.Sprint(i))
+	}
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived(expected...)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	var wg sync.WaitGroup
+	for _, seqnum := range rand.Perm(20) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sendSequence(t, template, "direct:start", seqnum+1)
+		}()
+	}
+	wg.Wait()
+
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestResequence_StreamGapsAndCapacity(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:gap").
+		Resequence("${header.seqnum}").
+		Stream().
+		Timeout(50 * time.Millisecond).
+		To("mock:gap").
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:capacity").
+		Resequence("${header.seqnum}").
+		Stream().
+		Capacity(2).
+		Timeout(time.Minute).
+		RejectOld().
+		To("mock:capacity").
+		End().
+		Build()
+
+	gap, _ := camel.GetMockEndpoint("mock:gap")
+	gap.ExpectedBodiesReceived("1", "2", "4", "3")
+	capacity, _ := camel.GetMockEndpoint("mock:capacity")
+	capacity.ExpectedBodiesReceived("5", "6", "7")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	sendSequence(t, template, "direct:gap", 2)
+	sendSequence(t, template, "direct:gap", 1)
+	sendSequence(t, template, "direct:gap", 4)
+	// 3 manque : 4 est émis une fois son délai d'attente écoulé, puis 3 arrive en retard
+	assert.Eventually(t, func() bool { return gap.ReceivedCounter() == 3 }, time.Second, 10*time.Millisecond)
+	sendSequence(t, template, "direct:gap", 3)
+
+	// La capacité atteinte force l'émission de 5 sans attendre le délai
+	sendSequence(t, template, "direct:capacity", 7)
+	sendSequence(t, template, "direct:capacity", 5)
+	assert.Equal(t, 1, capacity.ReceivedCounter())
+	sendSequence(t, template, "direct:capacity", 6)
+
+	var rejected *ResequencerRejectedError
+	assert.ErrorAs(t, template.SendBodyAndHeaders("direct:capacity", "4", map[string]any{"seqnum": 4}), &rejected)
+	assert.Equal(t, int64(7), rejected.LastDelivered)
+	assert.ErrorContains(t, template.SendBodyAndHeaders("direct:capacity", "x", map[string]any{"seqnum": "x"}), "invalid sequence number")
+
+	gap.AssertIsSatisfied(t, time.Second)
+	capacity.AssertIsSatisfied(t, time.Second)
+}
+
+func TestResequence_StopFailsPendingExchanges(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Resequence("${header.seqnum}").
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedMessageCount(0)
+
+	require.NoError(t, camel.Start())
+
+	sync := &countingSynchronization{}
+	exchange := NewExchange(context.Background())
+	exchange.GetIn().SetHeader("seqnum", 1)
+	exchange.AddSynchronization(sync)
+	err := camel.CreateProducerTemplate().Send("direct:start", exchange)
+	assert.ErrorIs(t, err, ErrStopRouting)
+	exchange.Done(err)
+	assert.Equal(t, int32(0), sync.completed.Load()+sync.failed.Load())
+
+	require.NoError(t, camel.Stop())
+	assert.Eventually(t, func() bool { return sync.failed.Load() == 1 }, time.Second, 10*time.Millisecond)
+	result.AssertIsSatisfied(t, 0)
+
+	assert.Panics(t, func() {
+		camel.CreateRouteBuilder().From("direct:other").Resequence("${header.seqnum}").Capacity(10)
+	})
+}
+
+func TestCompareSequence(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, -1, compareSequence(2, "10"))
+	assert.Equal(t, 1, compareSequence(int64(10), 9.5))
+	assert.Equal(t, 0, compareSequence("3", 3))
+	assert.Equal(t, -1, compareSequence(now, now.Add(time.Second)))
+	assert.Equal(t, -1, compareSequence("abc", "abd"))
+}
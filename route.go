@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // ErrStopRouting is a special error used to stop
@@ -41,8 +42,15 @@ type Route struct {
 	from        Endpoint
 	consumer    Consumer
 	processors  []Processor
+	nodeIDs     []string
+	nodeCounter int
 	started     bool
 	startLock   sync.Mutex
+
+	// intercepteurs propres à la route (Intercept, InterceptFrom, InterceptSendToEndpoint)
+	interceptors     []*interceptor
+	fromInterceptors []*interceptor
+	sendInterceptors []*sendInterceptor
 }
 
 // NewRoute crée une nouvelle instance de Route
@@ -68,9 +76,70 @@ func (r *Route) From(uri string) *Route {
 	return r
 }
 
-// AddProcessor ajoute un processeur à la route
+// AddProcessor ajoute un processeur à la route.
+// Le nœud reçoit un identifiant automatique de la forme node-N, modifiable via RouteBuilder.NodeID.
 func (r *Route) AddProcessor(processor Processor) {
 	r.processors = append(r.processors, processor)
+	r.nodeIDs = append(r.nodeIDs, r.nextNodeID())
+}
+
+// NodeIDs retourne les identifiants des nœuds de premier niveau de la route, dans l'ordre
+func (r *Route) NodeIDs() []string {
+	return append([]string(nil), r.nodeIDs...)
+}
+
+// nextNodeID génère un identifiant de nœud automatique
+func (r *Route) nextNodeID() string {
+	r.nodeCounter++
+	return fmt.Sprintf("node-%d", r.nodeCounter)
+}
+
+// nodeIndex retourne la position du nœud portant cet identifiant, ou -1
+func (r *Route) nodeIndex(id string) int {
+	for i, nodeID := range r.nodeIDs {
+		if nodeID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// insertNode insère un processeur à la position donnée et retourne son identifiant
+func (r *Route) insertNode(index int, processor Processor) string {
+	id := r.nextNodeID()
+	r.processors = append(r.processors[:index], append([]Processor{processor}, r.processors[index:]...)...)
+	r.nodeIDs = append(r.nodeIDs[:index], append([]string{id}, r.nodeIDs[index:]...)...)
+	return id
+}
+
+// removeNode supprime le nœud à la position donnée
+func (r *Route) removeNode(index int) {
+	r.processors = append(r.processors[:index], r.processors[index+1:]...)
+	r.nodeIDs = append(r.nodeIDs[:index], r.nodeIDs[index+1:]...)
+}
+
+// topLevelNodes retourne les nœuds de premier niveau de la route du type T, indexés par
+// identifiant de nœud (utilisé par l'API de management)
+func topLevelNodes[T Processor](r *Route) map[string]T {
+	nodes := make(map[string]T)
+	for i, processor := range r.processors {
+		if node, ok := processor.(T); ok {
+			nodes[r.nodeIDs[i]] = node
+		}
+	}
+	return nodes
+}
+
+// setNodeID renomme le dernier nœud de premier niveau de la route
+func (r *Route) setNodeID(id string) error {
+	if len(r.nodeIDs) == 0 {
+		return fmt.Errorf("aucun nœud à nommer %s", id)
+	}
+	if index := r.nodeIndex(id); index >= 0 && index != len(r.nodeIDs)-1 {
+		return fmt.Errorf("l'identifiant de nœud %s est déjà utilisé", id)
+	}
+	r.nodeIDs[len(r.nodeIDs)-1] = id
+	return nil
 }
 
 // ProcessFunc ajoute une fonction de traitement à la route
@@ -81,7 +150,49 @@ func (r *Route) ProcessFunc(f func(*Exchange) error) *Route {
 
 // Process implémente l'interface Processor
 func (r *Route) Process(exchange *Exchange) error {
-	for _, processor := range r.processors {
+	previousRoute := exchange.route
+	exchange.route = r
+	defer func() { exchange.route = previousRoute }()
+
+	if r.context == nil {
+		return r.processNodes(exchange, nil)
+	}
+
+	started := time.Now()
+	restore, nested := r.context.inflight.add(exchange, r.ID)
+	err := r.processNodes(exchange, r.context.inflight)
+	restore()
+
+	event := ExchangeEvent{
+		Type:      ExchangeCompletedEvent,
+		Exchange:  exchange,
+		RouteID:   r.ID,
+		Elapsed:   time.Since(started),
+		Timestamp: time.Now(),
+		Nested:    nested,
+	}
+	if err != nil && !errors.Is(err, ErrStopRouting) {
+		event.Type = ExchangeFailedEvent
+		event.Error = err
+	}
+	r.context.notify(event)
+
+	return err
+}
+
+// processNodes applique les intercepteurs d'entrée puis exécute séquentiellement
+// les processeurs de la route, chacun précédé des intercepteurs de nœud
+func (r *Route) processNodes(exchange *Exchange, inflight *InflightRepository) error {
+	if err := r.interceptRoute(exchange); err != nil {
+		return err
+	}
+	for i, processor := range r.processors {
+		if inflight != nil {
+			inflight.setNode(exchange, r.nodeIDs[i])
+		}
+		if err := r.interceptNode(exchange); err != nil {
+			return err
+		}
 		if err := processor.Process(exchange); err != nil {
 			return err
 		}
@@ -263,6 +374,17 @@ func (r *Route) ToD(uriTemplates ...string) *Route {
 	return r
 }
 
+// propagateOut recopie le corps et les en-têtes du message de sortie vers l'entrée
+// avant un envoi, si une modification a eu lieu
+func propagateOut(exchange *Exchange) {
+	if outBody := exchange.GetOut().GetBody(); outBody != nil {
+		exchange.GetIn().SetBody(outBody)
+	}
+	for k, v := range exchange.GetOut().GetHeaders() {
+		exchange.GetIn().SetHeader(k, v)
+	}
+}
+
 func createToProcessor(context *CamelContext, uri string) Processor {
 	var (
 		once     sync.Once
@@ -270,36 +392,33 @@ func createToProcessor(context *CamelContext, uri string) Processor {
 		initErr  error
 	)
 	return ProcessorFunc(func(exchange *Exchange) error {
-		once.Do(func() {
-			endpoint, err := context.CreateEndpoint(uri)
-			if err != nil {
-				initErr = err
-				return
+		propagateOut(exchange)
+
+		return interceptSend(context, exchange, uri, func() error {
+			// Le producteur n'est créé qu'au premier envoi effectif, ce qui évite
+			// de se connecter à un endpoint dont l'envoi est toujours intercepté
+			once.Do(func() {
+				endpoint, err := context.CreateEndpoint(uri)
+				if err != nil {
+					initErr = err
+					return
+				}
+				p, err := endpoint.CreateProducer()
+				if err != nil {
+					initErr = err
+					return
+				}
+				if err := p.Start(exchange.Context); err != nil {
+					initErr = err
+					return
+				}
+				producer = p
+			})
+			if initErr != nil {
+				return initErr
 			}
-			p, err := endpoint.CreateProducer()
-			if err != nil {
-				initErr = err
-				return
-			}
-			if err := p.Start(exchange.Context); err != nil {
-				initErr = err
-				return
-			}
-			producer = p
+			return producer.Send(exchange)
 		})
-		if initErr != nil {
-			return initErr
-		}
-
-		// Propagation de la sortie vers l'entrée si une modification a eu lieu
-		if outBody := exchange.GetOut().GetBody(); outBody != nil {
-			exchange.GetIn().SetBody(outBody)
-		}
-		for k, v := range exchange.GetOut().GetHeaders() {
-			exchange.GetIn().SetHeader(k, v)
-		}
-
-		return producer.Send(exchange)
 	})
 }
 
@@ -308,34 +427,30 @@ func createToDProcessor(context *CamelContext, uriTemplate string) Processor {
 		// Résolution de l'URI dynamique
 		uri := Interpolate(uriTemplate, exchange)
 
-		// Création de l'endpoint et du producer à chaque fois (pour ToD)
-		// TODO: Optimiser avec un cache de producers si nécessaire
-		endpoint, err := context.CreateEndpoint(uri)
-		if err != nil {
-			return fmt.Errorf("toD dynamic endpoint creation error: %w", err)
-		}
+		propagateOut(exchange)
 
-		producer, err := endpoint.CreateProducer()
-		if err != nil {
-			return fmt.Errorf("toD producer creation error: %w", err)
-		}
+		return interceptSend(context, exchange, uri, func() error {
+			// Création de l'endpoint et du producer à chaque fois (pour ToD)
+			// TODO: Optimiser avec un cache de producers si nécessaire
+			endpoint, err := context.CreateEndpoint(uri)
+			if err != nil {
+				return fmt.Errorf("toD dynamic endpoint creation error: %w", err)
+			}
 
-		if err := producer.Start(exchange.Context); err != nil {
-			return fmt.Errorf("toD producer start error: %w", err)
-		}
-		// On s'assure que le producer est arrêté à la fin (ou on laisse le GC s'en charger si l'endpoint est éphémère ?)
-		// Normalement dans Camel, les producteurs dynamiques sont mis en cache.
-		// Si on ne met pas en cache, on devrait probablement arrêter le producteur après l'envoi.
-		defer producer.Stop()
-
-		// Propagation de la sortie vers l'entrée si une modification a eu lieu
-		if outBody := exchange.GetOut().GetBody(); outBody != nil {
-			exchange.GetIn().SetBody(outBody)
-		}
-		for k, v := range exchange.GetOut().GetHeaders() {
-			exchange.GetIn().SetHeader(k, v)
-		}
+			producer, err := endpoint.CreateProducer()
+			if err != nil {
+				return fmt.Errorf("toD producer creation error: %w", err)
+			}
+
+			if err := producer.Start(exchange.Context); err != nil {
+				return fmt.Errorf("toD producer start error: %w", err)
+			}
+			// On s'assure que le producer est arrêté à la fin (ou on laisse le GC s'en charger si l'endpoint est éphémère ?)
+			// Normalement dans Camel, les producteurs dynamiques sont mis en cache.
+			// Si on ne met pas en cache, on devrait probablement arrêter le producteur après l'envoi.
+			defer producer.Stop()
 
-		return producer.Send(exchange)
+			return producer.Send(exchange)
+		})
 	})
 }
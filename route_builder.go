@@ -28,6 +28,16 @@ func (b *RouteBuilder) From(uri string) *RouteBuilder {
 	return b
 }
 
+// NodeID nomme le dernier nœud de premier niveau de la route, afin de pouvoir le cibler
+// ensuite (AdviceWith, suivi des échanges en cours). Dans un bloc imbriqué (Split, Multicast...),
+// c'est le bloc lui-même qui est nommé.
+func (b *RouteBuilder) NodeID(id string) *RouteBuilder {
+	if err := b.route.setNodeID(id); err != nil {
+		panic(err.Error())
+	}
+	return b
+}
+
 // Process ajoute un processeur au conteneur actuel
 func (b *RouteBuilder) Process(processor Processor) *RouteBuilder {
 	b.container.AddProcessor(processor)
@@ -165,12 +175,6 @@ func (b *RouteBuilder) Transacted() *RouteBuilder {
 	return b
 }
 
-// Aggregate ajoute un processeur Aggregator au conteneur actuel
-func (b *RouteBuilder) Aggregate(aggregator *Aggregator) *RouteBuilder {
-	b.container.AddProcessor(aggregator)
-	return b
-}
-
 // Split commence un bloc Split EIP
 func (b *RouteBuilder) Split(expression func(*Exchange) (any, error)) *SplitDefinition {
 	s := NewSplitter(expression)
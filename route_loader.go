@@ -0,0 +1,343 @@
+package gocamel
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRoutesYAMLFile charge les routes définies dans un fichier YAML et les ajoute au contexte.
+func LoadRoutesYAMLFile(context *CamelContext, path string) ([]*Route, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la lecture du fichier de routes %s: %w", path, err)
+	}
+	routes, err := LoadRoutesYAML(context, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return routes, nil
+}
+
+// LoadRoutesYAML construit des routes à partir d'une définition YAML et les ajoute au contexte.
+// Les routes ne sont ajoutées que si toutes sont valides ; elles ne sont pas démarrées.
+//
+// Format supporté (proche du DSL YAML d'Apache Camel) :
+//
+//	# routes.yaml
+//	- route:
+//	    id: hello
+//	    group: demo
+//	    description: Says hello
+//	    from:
+//	      uri: timer:tick?period=5000
+//	      steps:
+//	        - setBody:
+//	            simple: "Hello at ${date:now}"
+//	        - setHeader:
+//	            name: X-Source
+//	            constant: gocamel
+//	        - log: "${body}"
+//	        - choice:
+//	            when:
+//	              - simple: "${header.X-Source == 'gocamel'}"
+//	                steps:
+//	                  - to: direct:gocamel
+//	            otherwise:
+//	              steps:
+//	                - stop: {}
+//
+// Étapes disponibles : to, toD, log, setBody, setHeader, setProperty,
+// removeHeader, removeProperty, process (référence du registre), stop et choice.
+func LoadRoutesYAML(context *CamelContext, data []byte) ([]*Route, error) {
+	var definitions []map[string]any
+	if err := yaml.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("YAML invalide: %w", err)
+	}
+
+	routes := make([]*Route, 0, len(definitions))
+	for i, definition := range definitions {
+		route, err := buildYAMLRoute(context, definition)
+		if err != nil {
+			name := fmt.Sprintf("#%d", i+1)
+			if route != nil && route.ID != "" {
+				name = route.ID
+			}
+			return nil, fmt.Errorf("route %s: %w", name, err)
+		}
+		routes = append(routes, route)
+	}
+
+	for _, route := range routes {
+		context.AddRoute(route)
+	}
+	return routes, nil
+}
+
+// buildYAMLRoute construit une route à partir d'une entrée "route:" ou "from:"
+func buildYAMLRoute(context *CamelContext, definition map[string]any) (*Route, error) {
+	route := NewRoute()
+	route.context = context
+
+	var spec map[string]any
+	switch {
+	case definition["route"] != nil:
+		m, ok := definition["route"].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("l'entrée route doit être un objet")
+		}
+		spec = m
+	case definition["from"] != nil:
+		spec = map[string]any{"from": definition["from"]}
+	default:
+		return nil, fmt.Errorf("entrée inconnue %v, attendu route ou from", sortedKeys(definition))
+	}
+
+	route.ID = yamlString(spec["id"])
+	route.Description = yamlString(spec["description"])
+	route.Group = yamlString(spec["group"])
+
+	from, ok := spec["from"].(map[string]any)
+	if !ok {
+		return route, fmt.Errorf("from est obligatoire")
+	}
+	uri := yamlString(from["uri"])
+	if uri == "" {
+		return route, fmt.Errorf("from.uri est obligatoire")
+	}
+	endpoint, err := context.CreateEndpoint(uri)
+	if err != nil {
+		return route, fmt.Errorf("erreur lors de la création de l'endpoint %s: %w", uri, err)
+	}
+	route.from = endpoint
+
+	builder := &RouteBuilder{context: context, route: route, container: route}
+	if err := addYAMLSteps(builder, from["steps"]); err != nil {
+		return route, err
+	}
+	return route, nil
+}
+
+// addYAMLSteps ajoute une liste d'étapes YAML au conteneur du builder
+func addYAMLSteps(b *RouteBuilder, value any) error {
+	if value == nil {
+		return nil
+	}
+	steps, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("steps doit être une liste")
+	}
+
+	for i, raw := range steps {
+		step, ok := raw.(map[string]any)
+		if !ok || len(step) != 1 {
+			return fmt.Errorf("étape %d: une étape doit contenir exactement une clé", i+1)
+		}
+		for name, args := range step {
+			if err := addYAMLStep(b, name, args); err != nil {
+				return fmt.Errorf("étape %d (%s): %w", i+1, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// addYAMLStep traduit une étape YAML en processeur
+func addYAMLStep(b *RouteBuilder, name string, args any) error {
+	switch name {
+	case "to", "toD":
+		uri := yamlString(args)
+		if m, ok := args.(map[string]any); ok {
+			uri = yamlString(m["uri"])
+		}
+		if uri == "" {
+			return fmt.Errorf("uri est obligatoire")
+		}
+		if name == "toD" {
+			b.ToD(uri)
+			return nil
+		}
+		// On valide l'URI dès le chargement plutôt qu'au premier envoi
+		if _, err := b.context.CreateEndpoint(uri); err != nil {
+			return fmt.Errorf("erreur lors de la création de l'endpoint %s: %w", uri, err)
+		}
+		b.To(uri)
+
+	case "log":
+		message := yamlString(args)
+		if m, ok := args.(map[string]any); ok {
+			message = yamlString(m["message"])
+		}
+		template, err := ParseSimpleTemplate(message)
+		if err != nil {
+			return err
+		}
+		b.ProcessFunc(func(exchange *Exchange) error {
+			result, err := template.EvaluateAsString(exchange)
+			if err != nil {
+				log.Printf("Error evaluating log expression: %v", err)
+				return nil
+			}
+			log.Println(result)
+			return nil
+		})
+
+	case "setBody":
+		expression, err := yamlExpression(args)
+		if err != nil {
+			return err
+		}
+		b.ProcessFunc(func(exchange *Exchange) error {
+			result, err := expression.Evaluate(exchange)
+			if err != nil {
+				return err
+			}
+			exchange.GetOut().SetBody(result)
+			exchange.GetOut().SetHeaders(exchange.GetIn().GetHeaders())
+			return nil
+		})
+
+	case "setHeader", "setProperty":
+		m, _ := args.(map[string]any)
+		key := yamlString(m["name"])
+		if key == "" {
+			return fmt.Errorf("name est obligatoire")
+		}
+		expression, err := yamlExpression(args)
+		if err != nil {
+			return err
+		}
+		isHeader := name == "setHeader"
+		b.ProcessFunc(func(exchange *Exchange) error {
+			result, err := expression.Evaluate(exchange)
+			if err != nil {
+				return err
+			}
+			if isHeader {
+				exchange.GetOut().SetHeader(key, result)
+			} else {
+				exchange.SetProperty(key, result)
+			}
+			return nil
+		})
+
+	case "removeHeader", "removeProperty":
+		key := yamlString(args)
+		if m, ok := args.(map[string]any); ok {
+			key = yamlString(m["name"])
+		}
+		if key == "" {
+			return fmt.Errorf("name est obligatoire")
+		}
+		if name == "removeHeader" {
+			b.RemoveHeader(key)
+		} else {
+			b.RemoveProperty(key)
+		}
+
+	case "process":
+		ref := yamlString(args)
+		if m, ok := args.(map[string]any); ok {
+			ref = yamlString(m["ref"])
+		}
+		if ref == "" {
+			return fmt.Errorf("ref est obligatoire")
+		}
+		b.ProcessRef(ref)
+
+	case "stop":
+		b.Stop()
+
+	case "choice":
+		return addYAMLChoice(b, args)
+
+	default:
+		return fmt.Errorf("étape inconnue")
+	}
+	return nil
+}
+
+// addYAMLChoice construit un ChoiceProcessor à partir des clauses when/otherwise
+func addYAMLChoice(b *RouteBuilder, args any) error {
+	m, ok := args.(map[string]any)
+	if !ok {
+		return fmt.Errorf("choice doit être un objet")
+	}
+
+	choice := NewChoiceProcessor()
+	whens, _ := m["when"].([]any)
+	if len(whens) == 0 {
+		return fmt.Errorf("au moins une clause when est requise")
+	}
+	for i, raw := range whens {
+		when, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("when %d: la clause doit être un objet", i+1)
+		}
+		expression := yamlString(when["simple"])
+		if expression == "" {
+			return fmt.Errorf("when %d: simple est obligatoire", i+1)
+		}
+		pipeline := NewPipeline()
+		if err := addYAMLSteps(&RouteBuilder{context: b.context, route: b.route, container: pipeline}, when["steps"]); err != nil {
+			return fmt.Errorf("when %d: %w", i+1, err)
+		}
+		choice.When(expression, pipeline)
+	}
+
+	if otherwise, ok := m["otherwise"].(map[string]any); ok {
+		pipeline := NewPipeline()
+		if err := addYAMLSteps(&RouteBuilder{context: b.context, route: b.route, container: pipeline}, otherwise["steps"]); err != nil {
+			return fmt.Errorf("otherwise: %w", err)
+		}
+		choice.Otherwise(pipeline)
+	}
+
+	b.container.AddProcessor(choice)
+	return nil
+}
+
+// yamlExpression retourne l'expression d'une étape : "simple" ou "constant"
+func yamlExpression(args any) (Expression, error) {
+	m, ok := args.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("simple ou constant est obligatoire")
+	}
+	if simple, ok := m["simple"]; ok {
+		return ParseSimpleExpression(yamlString(simple))
+	}
+	if constant, ok := m["constant"]; ok {
+		return ExpressionFunc(func(*Exchange) (interface{}, error) {
+			return constant, nil
+		}), nil
+	}
+	return nil, fmt.Errorf("simple ou constant est obligatoire")
+}
+
+// yamlString convertit une valeur YAML scalaire en chaîne
+func yamlString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any, []any:
+		return ""
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// sortedKeys retourne les clés d'une map triées, pour des messages d'erreur stables
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
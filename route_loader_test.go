@@ -0,0 +1,91 @@
+package gocamel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRoutesYAML(t *testing.T) {
+	ctx := NewCamelContext()
+	ctx.AddComponent("direct", NewDirectComponent())
+
+	var received []string
+	ctx.GetComponentRegistry().Bind("collector", ProcessorFunc(func(e *Exchange) error {
+		body, _ := e.GetIn().GetBodyAsString()
+		received = append(received, body)
+		return nil
+	}))
+
+	routes, err := LoadRoutesYAML(ctx, []byte(`
+- route:
+    id: dispatch
+    group: tests
+    description: Routes by priority
+    from:
+      uri: direct:start
+      steps:
+        - setProperty:
+            name: seen
+            constant: true
+        - choice:
+            when:
+              - simple: "${header.priority == 'high'}"
+                steps:
+                  - setBody:
+                      simple: "urgent ${body}"
+                  - to: direct:collect
+            otherwise:
+              steps:
+                - to: direct:collect
+- from:
+    uri: direct:collect
+    steps:
+      - process: collector
+`))
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Equal(t, "dispatch", routes[0].ID)
+	assert.Equal(t, "tests", routes[0].Group)
+	assert.Equal(t, "Routes by priority", routes[0].Description)
+	assert.NotEmpty(t, routes[1].ID)
+	assert.Equal(t, 2, ctx.GetRouteCount())
+
+	require.NoError(t, ctx.Start())
+	defer ctx.Stop()
+
+	template := ctx.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "order", map[string]any{"priority": "high"}))
+	require.NoError(t, template.SendBody("direct:start", "newsletter"))
+
+	assert.Equal(t, []string{"urgent order", "newsletter"}, received)
+}
+
+func TestLoadRoutesYAML_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{"invalid yaml", "- route: [", "YAML invalide"},
+		{"missing from", "- route:\n    id: a\n", "from est obligatoire"},
+		{"unknown component", "- from:\n    uri: nope:x\n", "nope:x"},
+		{"unknown step", "- from:\n    uri: direct:a\n    steps:\n      - teleport: x\n", "teleport"},
+		{"invalid to", "- from:\n    uri: direct:a\n    steps:\n      - to: nope:y\n", "nope:y"},
+		{"missing header name", "- from:\n    uri: direct:a\n    steps:\n      - setHeader:\n          constant: 1\n", "name est obligatoire"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewCamelContext()
+			ctx.AddComponent("direct", NewDirectComponent())
+
+			_, err := LoadRoutesYAML(ctx, []byte(tt.yaml))
+			require.Error(t, err)
+			assert.True(t, strings.Contains(err.Error(), tt.expected), "unexpected error: %v", err)
+			assert.Equal(t, 0, ctx.GetRouteCount(), "no route should be added on error")
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package gocamel
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CamelSlipEndpoint est la propriété contenant l'URI de l'endpoint en cours de visite
+// par un Routing Slip ou un Dynamic Router
+const CamelSlipEndpoint = "CamelSlipEndpoint"
+
+// CamelSlipVisits est la propriété contenant le nombre d'endpoints déjà visités
+const CamelSlipVisits = "CamelSlipVisits"
+
+// DefaultRoutingSlipDelimiter est le séparateur des URIs d'un Routing Slip
+const DefaultRoutingSlipDelimiter = ","
+
+// DefaultDynamicRouterMaxIterations est le nombre maximal d'appels de la fonction de routage par défaut
+const DefaultDynamicRouterMaxIterations = 10000
+
+// ErrDynamicRouterMaxIterationsExceeded est retournée lorsque la fonction de routage ne termine
+// pas le routage après le nombre maximal d'appels
+var ErrDynamicRouterMaxIterationsExceeded = errors.New("dynamic router exceeded maximum iterations")
+
+// RoutingSlip est un Processor qui implémente le Routing Slip EIP.
+// L'échange traverse séquentiellement une liste d'URIs calculée à partir d'un en-tête ou d'une
+// expression ; la sortie de chaque étape devient l'entrée de la suivante.
+type RoutingSlip struct {
+	Expression             func(*Exchange) (any, error)
+	Delimiter              string
+	IgnoreInvalidEndpoints bool
+	cache                  *producerCache
+}
+
+// NewRoutingSlip crée un nouveau RoutingSlip
+func NewRoutingSlip(context *CamelContext, expression func(*Exchange) (any, error)) *RoutingSlip {
+	return &RoutingSlip{
+		Expression: expression,
+		Delimiter:  DefaultRoutingSlipDelimiter,
+		cache:      newRouteProducerCache(context),
+	}
+}
+
+// Process implémente l'interface Processor
+func (s *RoutingSlip) Process(exchange *Exchange) error {
+	propagateOut(exchange)
+
+	value, err := s.Expression(exchange)
+	if err != nil {
+		return fmt.Errorf("routing slip expression error: %w", err)
+	}
+
+	for i, uri := range splitEndpointURIs(value, s.Delimiter) {
+		exchange.SetProperty(CamelSlipEndpoint, uri)
+		if _, err := s.cache.send(exchange, uri, s.IgnoreInvalidEndpoints); err != nil {
+			return err
+		}
+		propagateOut(exchange)
+		exchange.SetProperty(CamelSlipVisits, i+1)
+	}
+	return nil
+}
+
+// DynamicRouter est un Processor qui implémente le Dynamic Router EIP.
+// La fonction de routage est appelée de manière répétée pour calculer le prochain endpoint,
+// jusqu'à ce qu'elle retourne une chaîne vide. Les propriétés CamelSlipEndpoint (dernier
+// endpoint visité) et CamelSlipVisits (nombre de visites) lui permettent de suivre l'avancement.
+type DynamicRouter struct {
+	Router                 func(*Exchange) (string, error)
+	IgnoreInvalidEndpoints bool
+	// MaxIterations protège contre une fonction de routage qui ne retourne jamais de chaîne
+	// vide ; 0 désactive la limite
+	MaxIterations int
+	cache         *producerCache
+}
+
+// NewDynamicRouter crée un nouveau DynamicRouter
+func NewDynamicRouter(context *CamelContext, router func(*Exchange) (string, error)) *DynamicRouter {
+	return &DynamicRouter{
+		Router:        router,
+		MaxIterations: DefaultDynamicRouterMaxIterations,
+		cache:         newRouteProducerCache(context),
+	}
+}
+
+// Process implémente l'interface Processor
+func (d *DynamicRouter) Process(exchange *Exchange) error {
+	propagateOut(exchange)
+	visits := 0
+	exchange.SetProperty(CamelSlipVisits, visits)
+
+	for iteration := 0; ; iteration++ {
+		if d.MaxIterations > 0 && iteration >= d.MaxIterations {
+			return fmt.Errorf("%w: %d", ErrDynamicRouterMaxIterationsExceeded, d.MaxIterations)
+		}
+		next, err := d.Router(exchange)
+		if err != nil {
+			return fmt.Errorf("dynamic router error: %w", err)
+		}
+		// Plusieurs endpoints peuvent être retournés en une fois, séparés par des virgules
+		uris := splitEndpointURIs(next, DefaultRoutingSlipDelimiter)
+		if len(uris) == 0 {
+			return nil
+		}
+		for _, uri := range uris {
+			exchange.SetProperty(CamelSlipEndpoint, uri)
+			if _, err := d.cache.send(exchange, uri, d.IgnoreInvalidEndpoints); err != nil {
+				return err
+			}
+			propagateOut(exchange)
+			visits++
+			exchange.SetProperty(CamelSlipVisits, visits)
+		}
+	}
+}
+
+// RoutingSlip ajoute un Routing Slip. Le paramètre est soit le nom d'un en-tête contenant les
+// URIs, soit une expression Simple (ex: "${header.slip}").
+func (b *RouteBuilder) RoutingSlip(headerOrExpression string) *RoutingSlipDefinition {
+	var expression func(*Exchange) (any, error)
+	if strings.Contains(headerOrExpression, "${") {
+		template, err := ParseSimpleTemplate(headerOrExpression)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse simple expression for routingSlip: %v", err))
+		}
+		expression = simpleValueExpression(template)
+	} else {
+		expression = func(exchange *Exchange) (any, error) {
+			value, _ := exchange.GetIn().GetHeader(headerOrExpression)
+			return value, nil
+		}
+	}
+
+	s := NewRoutingSlip(b.context, expression)
+	b.container.AddProcessor(s)
+	return &RoutingSlipDefinition{RouteBuilder: b, routingSlip: s}
+}
+
+// RoutingSlipDefinition permet de configurer le Routing Slip EIP. Les autres méthodes
+// continuent la route.
+type RoutingSlipDefinition struct {
+	*RouteBuilder
+	routingSlip *RoutingSlip
+}
+
+// Delimiter définit le séparateur des URIs ("," par défaut)
+func (d *RoutingSlipDefinition) Delimiter(delimiter string) *RoutingSlipDefinition {
+	if delimiter != "" {
+		d.routingSlip.Delimiter = delimiter
+	}
+	return d
+}
+
+// IgnoreInvalidEndpoints ignore les étapes dont l'endpoint ne peut pas être créé
+func (d *RoutingSlipDefinition) IgnoreInvalidEndpoints() *RoutingSlipDefinition {
+	d.routingSlip.IgnoreInvalidEndpoints = true
+	return d
+}
+
+// DynamicRouter ajoute un Dynamic Router dont la fonction est appelée après chaque étape
+// pour calculer le prochain endpoint ; une chaîne vide termine le routage.
+func (b *RouteBuilder) DynamicRouter(router func(*Exchange) (string, error)) *DynamicRouterDefinition {
+	d := NewDynamicRouter(b.context, router)
+	b.container.AddProcessor(d)
+	return &DynamicRouterDefinition{RouteBuilder: b, dynamicRouter: d}
+}
+
+// DynamicRouterDefinition permet de configurer le Dynamic Router EIP. Les autres méthodes
+// continuent la route.
+type DynamicRouterDefinition struct {
+	*RouteBuilder
+	dynamicRouter *DynamicRouter
+}
+
+// MaxIterations définit le nombre maximal d'appels de la fonction de routage ; au-delà, le
+// routage échoue avec ErrDynamicRouterMaxIterationsExceeded. 0 désactive la limite.
+func (d *DynamicRouterDefinition) MaxIterations(maximum int) *DynamicRouterDefinition {
+	d.dynamicRouter.MaxIterations = maximum
+	return d
+}
+
+// IgnoreInvalidEndpoints ignore les étapes dont l'endpoint ne peut pas être créé
+func (d *DynamicRouterDefinition) IgnoreInvalidEndpoints() *DynamicRouterDefinition {
+	d.dynamicRouter.IgnoreInvalidEndpoints = true
+	return d
+}
@@ -0,0 +1,199 @@
+package gocamel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDocumentStepsContext(t *testing.T) *CamelContext {
+	t.Helper()
+	camel := newTestContext()
+
+	// Chaque étape ajoute son nom au corps, via la sortie du message
+	for _, step := range []string{"scan", "ocr", "index"} {
+		name := step
+		camel.CreateRouteBuilder().
+			From("direct:" + name).
+			ProcessFunc(func(e *Exchange) error {
+				body, _ := e.GetIn().GetBodyAsString()
+				e.GetOut().SetBody(body + ">" + name)
+				return nil
+			}).
+			Build()
+	}
+	return camel
+}
+
+func TestRoutingSlip_Header(t *testing.T) {
+	camel := newDocumentStepsContext(t)
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		RoutingSlip("slip").
+		To("mock:result").
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("doc>scan>ocr>index")
+	result.MessageN(0).Predicate(func(e *Exchange) bool {
+		endpoint, _ := e.GetPropertyAsString(CamelSlipEndpoint)
+		visits, _ := e.GetProperty(CamelSlipVisits)
+		return endpoint == "direct:index" && visits == 3
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	require.NoError(t, camel.CreateProducerTemplate().SendBodyAndHeaders("direct:start", "doc",
+		map[string]any{"slip": "direct:scan, direct:ocr,direct:index"}))
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestRoutingSlip_ExpressionAndDelimiter(t *testing.T) {
+	camel := newDocumentStepsContext(t)
+
+	definition := camel.CreateRouteBuilder().
+		From("direct:start").
+		RoutingSlip("${header.steps}").Delimiter("|").IgnoreInvalidEndpoints()
+	definition.To("mock:result").Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("doc>ocr>ocr")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "doc",
+		map[string]any{"steps": "direct:ocr|unknown:step|direct:ocr"}))
+	result.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, 1, definition.routingSlip.cache.size(), "repeated step reuses its producer")
+}
+
+func TestRoutingSlip_StopsOnError(t *testing.T) {
+	camel := newDocumentStepsContext(t)
+	boom := errors.New("boom")
+	failing, _ := camel.GetMockEndpoint("mock:failing")
+	failing.ReturnError(boom)
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		RoutingSlip("slip").
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	err := camel.CreateProducerTemplate().SendBodyAndHeaders("direct:start", "doc",
+		map[string]any{"slip": []string{"mock:failing", "mock:after"}})
+	assert.ErrorIs(t, err, boom)
+	after, _ := camel.GetMockEndpoint("mock:after")
+	assert.Equal(t, 0, after.ReceivedCounter())
+}
+
+func TestDynamicRouter(t *testing.T) {
+	camel := newDocumentStepsContext(t)
+
+	var visited []string
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		DynamicRouter(func(e *Exchange) (string, error) {
+			visits, _ := e.GetProperty(CamelSlipVisits)
+			previous, _ := e.GetPropertyAsString(CamelSlipEndpoint)
+			visited = append(visited, previous)
+
+			body, _ := e.GetIn().GetBodyAsString()
+			switch {
+			case visits == 0:
+				return "direct:scan", nil
+			case !strings.Contains(body, "ocr"):
+				return "direct:ocr", nil
+			case visits == 2:
+				return "direct:index", nil
+			}
+			return "", nil
+		}).
+		To("mock:result").
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("doc>scan>ocr>index")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "doc"))
+	result.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, []string{"", "direct:scan", "direct:ocr", "direct:index"}, visited)
+}
+
+func TestDynamicRouter_Error(t *testing.T) {
+	camel := newDocumentStepsContext(t)
+	boom := errors.New("no route")
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		DynamicRouter(func(e *Exchange) (string, error) { return "", boom }).
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	assert.ErrorIs(t, camel.CreateProducerTemplate().SendBody("direct:start", "doc"), boom)
+}
+
+func TestDynamicRouter_MaxIterations(t *testing.T) {
+	camel := newDocumentStepsContext(t)
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		DynamicRouter(func(e *Exchange) (string, error) {
+			return "mock:again", nil // ne termine jamais
+		}).
+		MaxIterations(5).
+		To("mock:result").
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	err := camel.CreateProducerTemplate().SendBody("direct:start", "doc")
+	assert.ErrorIs(t, err, ErrDynamicRouterMaxIterationsExceeded)
+	again, _ := camel.GetMockEndpoint("mock:again")
+	assert.Equal(t, 5, again.ReceivedCounter())
+	result, _ := camel.GetMockEndpoint("mock:result")
+	assert.Equal(t, 0, result.ReceivedCounter())
+}
+
+func TestRoutingSlip_ProducersStoppedWithContext(t *testing.T) {
+	camel := NewCamelContext()
+	camel.AddComponent("direct", NewDirectComponent())
+	tracking := &trackingComponent{}
+	camel.AddComponent("tracking", tracking)
+	camel.CreateRouteBuilder().
+		From("direct:slip").
+		RoutingSlip("slip").
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:router").
+		DynamicRouter(func(e *Exchange) (string, error) {
+			if visits, _ := e.GetPropertyAsInt(CamelSlipVisits); visits > 0 {
+				return "", nil
+			}
+			return "tracking:c", nil
+		}).
+		Build()
+
+	require.NoError(t, camel.Start())
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:slip", "doc", map[string]any{"slip": "tracking:a,tracking:b"}))
+	require.NoError(t, template.SendBody("direct:router", "doc"))
+	assert.Equal(t, int32(3), tracking.started.Load())
+
+	require.NoError(t, camel.Stop())
+	assert.Equal(t, int32(0), tracking.started.Load())
+}
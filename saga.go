@@ -0,0 +1,299 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// SagaLongRunningAction est l'en-tête contenant l'identifiant de la saga courante ; il est
+// transmis aux routes participantes et aux endpoints de complétion et de compensation
+const SagaLongRunningAction = "Long-Running-Action"
+
+// SagaPropagation définit comment un bloc Saga se comporte vis-à-vis d'une saga existante
+type SagaPropagation string
+
+const (
+	// SagaRequired rejoint la saga existante, ou en crée une nouvelle s'il n'y en a pas
+	SagaRequired SagaPropagation = "REQUIRED"
+	// SagaRequiresNew crée toujours une nouvelle saga, la saga existante étant suspendue le temps du bloc
+	SagaRequiresNew SagaPropagation = "REQUIRES_NEW"
+	// SagaMandatory rejoint la saga existante et échoue s'il n'y en a pas
+	SagaMandatory SagaPropagation = "MANDATORY"
+	// SagaSupports rejoint la saga existante, ou exécute le bloc hors saga s'il n'y en a pas
+	SagaSupports SagaPropagation = "SUPPORTS"
+)
+
+// ErrSagaRequired est retournée par un bloc Saga MANDATORY exécuté hors d'une saga
+var ErrSagaRequired = errors.New("saga required")
+
+// SagaProcessor implémente le Saga EIP : les étapes d'un traitement distribué (appels SQL,
+// MongoDB, HTTP...) qui ne peuvent pas partager une transaction sont coordonnées par une saga.
+// Chaque bloc participant enregistre ses endpoints de compensation et de complétion auprès du
+// coordinateur ; la saga est complétée si le bloc qui l'a créée se termine avec succès, et
+// compensée (dans l'ordre inverse des étapes) s'il échoue ou si son délai expire.
+//
+// L'identifiant de la saga circule dans l'en-tête Long-Running-Action, ce qui permet aux routes
+// appelées depuis le bloc (ex: "direct:") de rejoindre la saga avec leur propre bloc Saga.
+type SagaProcessor struct {
+	context *CamelContext
+	// Propagation définit le comportement vis-à-vis d'une saga existante (REQUIRED par défaut)
+	Propagation SagaPropagation
+	// Timeout déclenche la compensation d'une saga créée par ce bloc ; 0 désactive le délai
+	Timeout time.Duration
+	// Compensation est l'endpoint appelé lorsque la saga est compensée
+	Compensation string
+	// Completion est l'endpoint appelé lorsque la saga est complétée
+	Completion string
+	// Coordinator coordonne les sagas ; nil utilise celui du CamelContext
+	Coordinator SagaCoordinator
+	options     []sagaOption
+	processors  []Processor
+}
+
+// sagaOption est une valeur évaluée à l'entrée du bloc et transmise en en-tête aux endpoints de l'étape
+type sagaOption struct {
+	name       string
+	expression func(*Exchange) (any, error)
+}
+
+// NewSagaProcessor crée un bloc Saga avec la propagation REQUIRED
+func NewSagaProcessor(context *CamelContext) *SagaProcessor {
+	return &SagaProcessor{
+		context:     context,
+		Propagation: SagaRequired,
+		processors:  make([]Processor, 0),
+	}
+}
+
+// AddOption ajoute une option dont la valeur, calculée à l'entrée du bloc, est transmise en
+// en-tête aux endpoints de compensation et de complétion
+func (s *SagaProcessor) AddOption(name string, expression func(*Exchange) (any, error)) {
+	s.options = append(s.options, sagaOption{name: name, expression: expression})
+}
+
+// AddProcessor ajoute un processeur exécuté dans la saga
+func (s *SagaProcessor) AddProcessor(processor Processor) {
+	s.processors = append(s.processors, processor)
+}
+
+// Process implémente l'interface Processor
+func (s *SagaProcessor) Process(exchange *Exchange) error {
+	coordinator := s.Coordinator
+	if coordinator == nil {
+		coordinator = s.context.GetSagaCoordinator()
+	}
+	ctx := exchangeContext(exchange)
+
+	promoteOut(exchange)
+	previous, _ := exchange.GetIn().GetHeader(SagaLongRunningAction)
+	sagaID, _ := previous.(string)
+
+	create := false
+	switch s.Propagation {
+	case SagaRequired, "":
+		create = sagaID == ""
+	case SagaRequiresNew:
+		create = true
+	case SagaMandatory:
+		if sagaID == "" {
+			return fmt.Errorf("%w: no saga in progress for exchange %s", ErrSagaRequired, exchange.ID)
+		}
+	case SagaSupports:
+		if sagaID == "" {
+			return s.processBlock(exchange)
+		}
+	default:
+		return fmt.Errorf("unsupported saga propagation: %s", s.Propagation)
+	}
+
+	if create {
+		var err error
+		if sagaID, err = coordinator.NewSaga(ctx, s.Timeout); err != nil {
+			return err
+		}
+		exchange.GetIn().SetHeader(SagaLongRunningAction, sagaID)
+	}
+
+	err := s.addStep(ctx, coordinator, sagaID, exchange)
+	if err == nil {
+		err = s.processBlock(exchange)
+	}
+	if !create {
+		return err
+	}
+
+	// La saga se termine même si l'échange a été annulé entre-temps
+	finishCtx := context.WithoutCancel(ctx)
+	if err != nil && !errors.Is(err, ErrStopRouting) {
+		if cerr := coordinator.Compensate(finishCtx, sagaID); cerr != nil && !errors.Is(cerr, ErrSagaNotRunning) {
+			log.Printf("Saga: échec de la compensation de la saga %s: %v", sagaID, cerr)
+		}
+	} else if cerr := coordinator.Complete(finishCtx, sagaID); cerr != nil {
+		err = cerr
+	}
+
+	// La saga englobante éventuelle redevient la saga courante
+	promoteOut(exchange)
+	if previous == nil {
+		exchange.GetIn().RemoveHeader(SagaLongRunningAction)
+	} else {
+		exchange.GetIn().SetHeader(SagaLongRunningAction, previous)
+	}
+	return err
+}
+
+// addStep enregistre les endpoints du bloc auprès de la saga
+func (s *SagaProcessor) addStep(ctx context.Context, coordinator SagaCoordinator, sagaID string, exchange *Exchange) error {
+	if s.Compensation == "" && s.Completion == "" {
+		return nil
+	}
+	step := SagaStep{Compensation: s.Compensation, Completion: s.Completion}
+	if len(s.options) > 0 {
+		step.Options = make(map[string]any, len(s.options))
+		for _, option := range s.options {
+			value, err := option.expression(exchange)
+			if err != nil {
+				return fmt.Errorf("saga option %s expression error: %w", option.name, err)
+			}
+			step.Options[option.name] = value
+		}
+	}
+	return coordinator.AddStep(ctx, sagaID, step)
+}
+
+func (s *SagaProcessor) processBlock(exchange *Exchange) error {
+	for _, p := range s.processors {
+		if err := p.Process(exchange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Saga commence un bloc Saga EIP ; les processeurs du bloc participent à la saga courante ou à
+// une nouvelle saga selon la propagation (REQUIRED par défaut)
+func (b *RouteBuilder) Saga() *SagaDefinition {
+	s := NewSagaProcessor(b.context)
+	b.container.AddProcessor(s)
+
+	return &SagaDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: s,
+		},
+		parent: b,
+		saga:   s,
+	}
+}
+
+// SagaDefinition permet de configurer la saga et les processeurs qui y participent
+type SagaDefinition struct {
+	*RouteBuilder
+	parent *RouteBuilder
+	saga   *SagaProcessor
+}
+
+// Propagation définit le comportement vis-à-vis d'une saga existante
+func (d *SagaDefinition) Propagation(propagation SagaPropagation) *SagaDefinition {
+	d.saga.Propagation = propagation
+	return d
+}
+
+// Timeout définit le délai au-delà duquel une saga créée par ce bloc est compensée
+func (d *SagaDefinition) Timeout(timeout time.Duration) *SagaDefinition {
+	d.saga.Timeout = timeout
+	return d
+}
+
+// Compensation définit l'endpoint appelé lorsque la saga est compensée (ex: "direct:cancelOrder")
+func (d *SagaDefinition) Compensation(uri string) *SagaDefinition {
+	d.saga.Compensation = uri
+	return d
+}
+
+// Completion définit l'endpoint appelé lorsque la saga est complétée (ex: "direct:confirmOrder")
+func (d *SagaDefinition) Completion(uri string) *SagaDefinition {
+	d.saga.Completion = uri
+	return d
+}
+
+// Option ajoute une option transmise en en-tête aux endpoints de compensation et de complétion.
+// La valeur est une constante ou une expression Simple (ex: "${header.orderId}") évaluée à
+// l'entrée du bloc.
+func (d *SagaDefinition) Option(name string, expression string) *SagaDefinition {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for saga option: %v", err))
+	}
+	d.saga.AddOption(name, simpleValueExpression(template))
+	return d
+}
+
+// Coordinator définit le coordinateur de la saga (celui du CamelContext par défaut)
+func (d *SagaDefinition) Coordinator(coordinator SagaCoordinator) *SagaDefinition {
+	d.saga.Coordinator = coordinator
+	return d
+}
+
+// Process ajoute un processeur et reste dans le contexte de la saga
+func (d *SagaDefinition) Process(processor Processor) *SagaDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte de la saga
+func (d *SagaDefinition) ProcessFunc(f func(*Exchange) error) *SagaDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte de la saga
+func (d *SagaDefinition) To(uris ...string) *SagaDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD ajoute un ou plusieurs endpoints dynamiques et reste dans le contexte de la saga
+func (d *SagaDefinition) ToD(uriTemplates ...string) *SagaDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody définit le corps du message de sortie et reste dans le contexte de la saga
+func (d *SagaDefinition) SetBody(body interface{}) *SagaDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte de la saga
+func (d *SagaDefinition) SetHeader(key string, value interface{}) *SagaDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetProperty définit une propriété de l'échange et reste dans le contexte de la saga
+func (d *SagaDefinition) SetProperty(key string, value any) *SagaDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// RemoveHeader supprime un en-tête du message entrant et reste dans le contexte de la saga
+func (d *SagaDefinition) RemoveHeader(name string) *SagaDefinition {
+	d.RouteBuilder.RemoveHeader(name)
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte de la saga
+func (d *SagaDefinition) Log(message string) *SagaDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End termine le bloc Saga et revient au builder parent
+func (d *SagaDefinition) End() *RouteBuilder {
+	return d.parent
+}
@@ -0,0 +1,425 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// SagaStatus is the status of a saga.
+type SagaStatus string
+
+const (
+	// SagaRunning means the saga accepts new steps and can be completed or compensated.
+	SagaRunning SagaStatus = "RUNNING"
+	// SagaCompleting means the completion actions are being executed.
+	SagaCompleting SagaStatus = "COMPLETING"
+	// SagaCompleted means all the completion actions succeeded.
+	SagaCompleted SagaStatus = "COMPLETED"
+	// SagaCompensating means the compensation actions are being executed.
+	SagaCompensating SagaStatus = "COMPENSATING"
+	// SagaCompensated means all the compensation actions succeeded.
+	SagaCompensated SagaStatus = "COMPENSATED"
+	// SagaFailed means at least one completion or compensation action failed.
+	SagaFailed SagaStatus = "FAILED"
+)
+
+var (
+	// ErrSagaNotFound is returned when the saga doesn't exist in the coordinator.
+	ErrSagaNotFound = errors.New("saga not found")
+	// ErrSagaNotRunning is returned when a step is added to, or a saga is completed or compensated
+	// after it has already been completed or compensated (for example after its timeout).
+	ErrSagaNotRunning = errors.New("saga is not running")
+)
+
+// SagaStep is the participation of a route in a saga: the endpoints called when the saga is
+// compensated or completed, with the options sent to them as headers.
+type SagaStep struct {
+	Compensation string         `json:"compensation,omitempty"`
+	Completion   string         `json:"completion,omitempty"`
+	Options      map[string]any `json:"options,omitempty"`
+}
+
+// SagaCoordinator defines the interface of the coordinators keeping track of the sagas and of
+// their steps, and calling the completion or compensation endpoints of the steps.
+type SagaCoordinator interface {
+	// NewSaga creates a running saga and returns its identifier. When timeout is positive, the
+	// saga is compensated if it is neither completed nor compensated in time.
+	NewSaga(ctx context.Context, timeout time.Duration) (string, error)
+
+	// AddStep adds a step to a running saga.
+	AddStep(ctx context.Context, sagaID string, step SagaStep) error
+
+	// Complete calls the completion endpoints of the steps, in the order they were added.
+	Complete(ctx context.Context, sagaID string) error
+
+	// Compensate calls the compensation endpoints of the steps, in the reverse order.
+	Compensate(ctx context.Context, sagaID string) error
+
+	// Status returns the status of the saga.
+	Status(ctx context.Context, sagaID string) (SagaStatus, error)
+}
+
+// sagaStore keeps the state of the sagas for a sagaCoordinator.
+type sagaStore interface {
+	create(ctx context.Context, sagaID string, deadline time.Time) error
+	addStep(ctx context.Context, sagaID string, step SagaStep) error
+	steps(ctx context.Context, sagaID string) ([]SagaStep, error)
+	status(ctx context.Context, sagaID string) (SagaStatus, error)
+	// transition changes the status of the saga if it is the expected one
+	transition(ctx context.Context, sagaID string, from, to SagaStatus) (bool, error)
+	expired(ctx context.Context, now time.Time) ([]string, error)
+	// deadlines returns the deadline of each running saga having a timeout
+	deadlines(ctx context.Context) (map[string]time.Time, error)
+	// finishing returns the status of each saga being completed or compensated
+	finishing(ctx context.Context) (map[string]SagaStatus, error)
+}
+
+// sagaCoordinator implements SagaCoordinator on top of a sagaStore: it schedules the timeouts
+// and sends the completion and compensation exchanges. The timeouts are scheduled again from
+// the stored deadlines when the context starts, and cancelled when it stops.
+type sagaCoordinator struct {
+	context  *CamelContext
+	store    sagaStore
+	template *ProducerTemplate
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func newSagaCoordinator(context *CamelContext, store sagaStore) *sagaCoordinator {
+	c := &sagaCoordinator{
+		context:  context,
+		store:    store,
+		template: NewProducerTemplate(context),
+		timers:   make(map[string]*time.Timer),
+	}
+	context.addStartHook(c.start)
+	// After the routes, whose exchanges may still complete or compensate sagas
+	context.addCleanupHook(c.stop)
+	return c
+}
+
+// start schedules the timeouts of the running sagas, compensating right away those which
+// expired while no timer was armed (for example while the application was stopped). The sagas
+// left COMPLETING or COMPENSATING by a stop in the middle of their actions are driven again to
+// their final status: their completion or compensation endpoints may be called twice.
+func (c *sagaCoordinator) start() {
+	deadlines, err := c.store.deadlines(context.Background())
+	if err != nil {
+		log.Printf("Saga: échec de la lecture des délais des sagas en cours: %v", err)
+	}
+	for sagaID, deadline := range deadlines {
+		c.schedule(sagaID, time.Until(deadline))
+	}
+
+	finishing, err := c.store.finishing(context.Background())
+	if err != nil {
+		log.Printf("Saga: échec de la lecture des sagas en cours de terminaison: %v", err)
+	}
+	for sagaID, status := range finishing {
+		go func(sagaID string, running SagaStatus) {
+			done := SagaCompleted
+			if running == SagaCompensating {
+				done = SagaCompensated
+			}
+			if err := c.callAndSettle(context.Background(), sagaID, running, done); err != nil {
+				log.Printf("Saga: échec de la reprise de la saga %s: %v", sagaID, err)
+			}
+		}(sagaID, status)
+	}
+}
+
+// stop cancels the pending timeouts and stops the producers of the completion and
+// compensation endpoints.
+func (c *sagaCoordinator) stop() {
+	c.mu.Lock()
+	for sagaID, timer := range c.timers {
+		timer.Stop()
+		delete(c.timers, sagaID)
+	}
+	c.mu.Unlock()
+
+	if err := c.template.Stop(); err != nil {
+		log.Printf("Saga: échec de l'arrêt des producteurs: %v", err)
+	}
+}
+
+// schedule compensates the saga after the given delay, replacing its previous timer.
+func (c *sagaCoordinator) schedule(sagaID string, delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if timer, exists := c.timers[sagaID]; exists {
+		timer.Stop()
+	}
+	c.timers[sagaID] = time.AfterFunc(delay, func() { c.timeout(sagaID) })
+}
+
+// NewSaga creates a running saga and returns its identifier.
+func (c *sagaCoordinator) NewSaga(ctx context.Context, timeout time.Duration) (string, error) {
+	sagaID := generateUUID()
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := c.store.create(ctx, sagaID, deadline); err != nil {
+		return "", fmt.Errorf("failed to create saga: %w", err)
+	}
+
+	if timeout > 0 {
+		c.schedule(sagaID, timeout)
+	}
+	return sagaID, nil
+}
+
+// AddStep adds a step to a running saga.
+func (c *sagaCoordinator) AddStep(ctx context.Context, sagaID string, step SagaStep) error {
+	return c.store.addStep(ctx, sagaID, step)
+}
+
+// Complete calls the completion endpoints of the steps, in the order they were added.
+func (c *sagaCoordinator) Complete(ctx context.Context, sagaID string) error {
+	return c.finish(ctx, sagaID, SagaCompleting, SagaCompleted)
+}
+
+// Compensate calls the compensation endpoints of the steps, in the reverse order.
+func (c *sagaCoordinator) Compensate(ctx context.Context, sagaID string) error {
+	return c.finish(ctx, sagaID, SagaCompensating, SagaCompensated)
+}
+
+// Status returns the status of the saga.
+func (c *sagaCoordinator) Status(ctx context.Context, sagaID string) (SagaStatus, error) {
+	return c.store.status(ctx, sagaID)
+}
+
+// CompensateExpired compensates the running sagas whose timeout has expired, for example while
+// the application was stopped. It returns the number of compensated sagas.
+func (c *sagaCoordinator) CompensateExpired(ctx context.Context) (int, error) {
+	expired, err := c.store.expired(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired sagas: %w", err)
+	}
+	count := 0
+	var errs []error
+	for _, sagaID := range expired {
+		err := c.Compensate(ctx, sagaID)
+		switch {
+		case err == nil:
+			count++
+		case !errors.Is(err, ErrSagaNotRunning):
+			errs = append(errs, err)
+		}
+	}
+	return count, errors.Join(errs...)
+}
+
+func (c *sagaCoordinator) timeout(sagaID string) {
+	err := c.Compensate(context.Background(), sagaID)
+	if err == nil {
+		log.Printf("Saga: saga %s compensée après expiration de son délai", sagaID)
+	} else if !errors.Is(err, ErrSagaNotRunning) {
+		log.Printf("Saga: échec de la compensation de la saga %s expirée: %v", sagaID, err)
+	}
+}
+
+// finish moves a running saga to its final status, calling the completion or compensation
+// endpoints of its steps. The saga is FAILED if one of them fails.
+func (c *sagaCoordinator) finish(ctx context.Context, sagaID string, running, done SagaStatus) error {
+	ok, err := c.store.transition(ctx, sagaID, SagaRunning, running)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		status, err := c.store.status(ctx, sagaID)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: saga %s is %s", ErrSagaNotRunning, sagaID, status)
+	}
+
+	c.mu.Lock()
+	if timer, exists := c.timers[sagaID]; exists {
+		timer.Stop()
+		delete(c.timers, sagaID)
+	}
+	c.mu.Unlock()
+
+	return c.callAndSettle(ctx, sagaID, running, done)
+}
+
+// callAndSettle calls the completion or compensation endpoints of a saga in the running status,
+// then moves it to its final status, or to FAILED if one of them fails.
+func (c *sagaCoordinator) callAndSettle(ctx context.Context, sagaID string, running, done SagaStatus) error {
+	steps, err := c.store.steps(ctx, sagaID)
+	if err == nil {
+		err = c.callSteps(sagaID, steps, running == SagaCompensating)
+	}
+
+	status := done
+	if err != nil {
+		status = SagaFailed
+	}
+	if _, terr := c.store.transition(ctx, sagaID, running, status); terr != nil {
+		err = errors.Join(err, terr)
+	}
+	if err != nil {
+		return fmt.Errorf("saga %s failed: %w", sagaID, err)
+	}
+	return nil
+}
+
+// callSteps sends an exchange to the completion endpoints of the steps, or to their
+// compensation endpoints in the reverse order. All the endpoints are called even if one fails.
+func (c *sagaCoordinator) callSteps(sagaID string, steps []SagaStep, compensate bool) error {
+	var errs []error
+	for i := range steps {
+		step := steps[i]
+		uri := step.Completion
+		if compensate {
+			step = steps[len(steps)-1-i]
+			uri = step.Compensation
+		}
+		if uri == "" {
+			continue
+		}
+
+		exchange := NewExchange(c.context.GetContext())
+		exchange.GetIn().SetHeaders(step.Options)
+		exchange.GetIn().SetHeader(SagaLongRunningAction, sagaID)
+		if err := c.template.Send(uri, exchange); err != nil && !errors.Is(err, ErrStopRouting) {
+			errs = append(errs, fmt.Errorf("%s: %w", uri, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MemorySagaCoordinator is an in-memory SagaCoordinator. The sagas are lost when the
+// application stops; finished sagas are forgotten after memorySagaRetention.
+type MemorySagaCoordinator struct {
+	*sagaCoordinator
+}
+
+// NewMemorySagaCoordinator creates a new MemorySagaCoordinator sending the completion and
+// compensation exchanges through the given CamelContext.
+func NewMemorySagaCoordinator(context *CamelContext) *MemorySagaCoordinator {
+	return &MemorySagaCoordinator{
+		sagaCoordinator: newSagaCoordinator(context, &memorySagaStore{sagas: make(map[string]*memorySaga)}),
+	}
+}
+
+// memorySagaRetention is how long a finished saga is kept, so that its status can still be read
+const memorySagaRetention = 10 * time.Minute
+
+type memorySaga struct {
+	status   SagaStatus
+	deadline time.Time
+	finished time.Time
+	steps    []SagaStep
+}
+
+type memorySagaStore struct {
+	mu    sync.Mutex
+	sagas map[string]*memorySaga
+}
+
+func (s *memorySagaStore) create(ctx context.Context, sagaID string, deadline time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, saga := range s.sagas {
+		if !saga.finished.IsZero() && time.Since(saga.finished) > memorySagaRetention {
+			delete(s.sagas, id)
+		}
+	}
+	s.sagas[sagaID] = &memorySaga{status: SagaRunning, deadline: deadline}
+	return nil
+}
+
+func (s *memorySagaStore) addStep(ctx context.Context, sagaID string, step SagaStep) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saga, exists := s.sagas[sagaID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSagaNotFound, sagaID)
+	}
+	if saga.status != SagaRunning {
+		return fmt.Errorf("%w: saga %s is %s", ErrSagaNotRunning, sagaID, saga.status)
+	}
+	saga.steps = append(saga.steps, step)
+	return nil
+}
+
+func (s *memorySagaStore) steps(ctx context.Context, sagaID string) ([]SagaStep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saga, exists := s.sagas[sagaID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrSagaNotFound, sagaID)
+	}
+	return append([]SagaStep(nil), saga.steps...), nil
+}
+
+func (s *memorySagaStore) status(ctx context.Context, sagaID string) (SagaStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saga, exists := s.sagas[sagaID]
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrSagaNotFound, sagaID)
+	}
+	return saga.status, nil
+}
+
+func (s *memorySagaStore) transition(ctx context.Context, sagaID string, from, to SagaStatus) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saga, exists := s.sagas[sagaID]
+	if !exists {
+		return false, fmt.Errorf("%w: %s", ErrSagaNotFound, sagaID)
+	}
+	if saga.status != from {
+		return false, nil
+	}
+	saga.status = to
+	if to == SagaCompleted || to == SagaCompensated || to == SagaFailed {
+		saga.finished = time.Now()
+	}
+	return true, nil
+}
+
+func (s *memorySagaStore) deadlines(ctx context.Context) (map[string]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deadlines := make(map[string]time.Time)
+	for sagaID, saga := range s.sagas {
+		if saga.status == SagaRunning && !saga.deadline.IsZero() {
+			deadlines[sagaID] = saga.deadline
+		}
+	}
+	return deadlines, nil
+}
+
+func (s *memorySagaStore) finishing(ctx context.Context) (map[string]SagaStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	finishing := make(map[string]SagaStatus)
+	for sagaID, saga := range s.sagas {
+		if saga.status == SagaCompleting || saga.status == SagaCompensating {
+			finishing[sagaID] = saga.status
+		}
+	}
+	return finishing, nil
+}
+
+func (s *memorySagaStore) expired(ctx context.Context, now time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []string
+	for sagaID, saga := range s.sagas {
+		if saga.status == SagaRunning && !saga.deadline.IsZero() && !saga.deadline.After(now) {
+			expired = append(expired, sagaID)
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,164 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedSagaSteps retourne l'option "step" des échanges reçus par le mock
+func receivedSagaSteps(mock *MockEndpoint) []any {
+	var steps []any
+	for _, exchange := range mock.ReceivedExchanges() {
+		step, _ := exchange.GetIn().GetHeader("step")
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// testSagaCoordinator vérifie le contrat commun des SagaCoordinator
+func testSagaCoordinator(t *testing.T, newCoordinator func(camel *CamelContext) SagaCoordinator) {
+	ctx := context.Background()
+	camel := NewCamelContext()
+	camel.AddComponent("mock", NewMockComponent())
+	coordinator := newCoordinator(camel)
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	completed, _ := camel.GetMockEndpoint("mock:completed")
+	compensated, _ := camel.GetMockEndpoint("mock:compensated")
+	failing, _ := camel.GetMockEndpoint("mock:failing")
+	failing.ReturnError(errors.New("service unavailable"))
+	addSteps := func(sagaID string) {
+		for _, step := range []string{"1", "2"} {
+			require.NoError(t, coordinator.AddStep(ctx, sagaID, SagaStep{
+				Compensation: "mock:compensated",
+				Completion:   "mock:completed",
+				Options:      map[string]any{"step": step},
+			}))
+		}
+	}
+
+	_, err := coordinator.Status(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrSagaNotFound)
+
+	t.Run("complete", func(t *testing.T) {
+		sagaID, err := coordinator.NewSaga(ctx, 0)
+		require.NoError(t, err)
+		addSteps(sagaID)
+		status, _ := coordinator.Status(ctx, sagaID)
+		assert.Equal(t, SagaRunning, status)
+
+		require.NoError(t, coordinator.Complete(ctx, sagaID))
+		assert.Equal(t, []any{"1", "2"}, receivedSagaSteps(completed))
+		header, _ := completed.ReceivedExchanges()[0].GetIn().GetHeader(SagaLongRunningAction)
+		assert.Equal(t, sagaID, header)
+		status, _ = coordinator.Status(ctx, sagaID)
+		assert.Equal(t, SagaCompleted, status)
+
+		// Une saga terminée n'accepte plus d'étape et ne peut plus être compensée
+		assert.ErrorIs(t, coordinator.AddStep(ctx, sagaID, SagaStep{Compensation: "mock:compensated"}), ErrSagaNotRunning)
+		assert.ErrorIs(t, coordinator.Compensate(ctx, sagaID), ErrSagaNotRunning)
+		assert.Equal(t, 0, compensated.ReceivedCounter())
+		completed.Reset()
+	})
+
+	t.Run("compensate in reverse order", func(t *testing.T) {
+		sagaID, err := coordinator.NewSaga(ctx, 0)
+		require.NoError(t, err)
+		addSteps(sagaID)
+
+		require.NoError(t, coordinator.Compensate(ctx, sagaID))
+		assert.Equal(t, []any{"2", "1"}, receivedSagaSteps(compensated))
+		assert.Equal(t, 0, completed.ReceivedCounter())
+		status, _ := coordinator.Status(ctx, sagaID)
+		assert.Equal(t, SagaCompensated, status)
+		compensated.Reset()
+	})
+
+	t.Run("failed action", func(t *testing.T) {
+		sagaID, err := coordinator.NewSaga(ctx, 0)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddStep(ctx, sagaID, SagaStep{Compensation: "mock:failing"}))
+		addSteps(sagaID)
+
+		// Les autres actions sont exécutées malgré l'échec
+		assert.Error(t, coordinator.Compensate(ctx, sagaID))
+		assert.Equal(t, []any{"2", "1"}, receivedSagaSteps(compensated))
+		assert.Equal(t, 1, failing.ReceivedCounter())
+		status, _ := coordinator.Status(ctx, sagaID)
+		assert.Equal(t, SagaFailed, status)
+		compensated.Reset()
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		sagaID, err := coordinator.NewSaga(ctx, 50*time.Millisecond)
+		require.NoError(t, err)
+		addSteps(sagaID)
+
+		assert.Eventually(t, func() bool {
+			status, _ := coordinator.Status(ctx, sagaID)
+			return status == SagaCompensated
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []any{"2", "1"}, receivedSagaSteps(compensated))
+		assert.ErrorIs(t, coordinator.Complete(ctx, sagaID), ErrSagaNotRunning)
+		compensated.Reset()
+	})
+}
+
+func TestMemorySagaCoordinator(t *testing.T) {
+	testSagaCoordinator(t, func(camel *CamelContext) SagaCoordinator {
+		return NewMemorySagaCoordinator(camel)
+	})
+}
+
+func TestSagaCoordinator_CompensateExpired(t *testing.T) {
+	ctx := context.Background()
+	camel := NewCamelContext()
+	camel.AddComponent("mock", NewMockComponent())
+	coordinator := NewMemorySagaCoordinator(camel)
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// Saga dont le délai a expiré alors qu'aucun timer n'était armé (ex: pendant un redémarrage)
+	require.NoError(t, coordinator.store.create(ctx, "expired", time.Now().Add(-time.Second)))
+	require.NoError(t, coordinator.AddStep(ctx, "expired", SagaStep{Compensation: "mock:compensated"}))
+	running, err := coordinator.NewSaga(ctx, time.Hour)
+	require.NoError(t, err)
+
+	count, err := coordinator.CompensateExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	compensated, _ := camel.GetMockEndpoint("mock:compensated")
+	assert.Equal(t, 1, compensated.ReceivedCounter())
+	status, _ := coordinator.Status(ctx, running)
+	assert.Equal(t, SagaRunning, status)
+}
+
+func TestSagaCoordinator_StopCancelsTimers(t *testing.T) {
+	ctx := context.Background()
+	camel := NewCamelContext()
+	camel.AddComponent("mock", NewMockComponent())
+	coordinator := NewMemorySagaCoordinator(camel)
+	require.NoError(t, camel.Start())
+
+	sagaID, err := coordinator.NewSaga(ctx, 100*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, coordinator.AddStep(ctx, sagaID, SagaStep{Compensation: "mock:compensated"}))
+	compensated, _ := camel.GetMockEndpoint("mock:compensated")
+
+	// Les délais en attente sont annulés à l'arrêt du contexte
+	require.NoError(t, camel.Stop())
+	coordinator.mu.Lock()
+	assert.Empty(t, coordinator.timers)
+	coordinator.mu.Unlock()
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 0, compensated.ReceivedCounter())
+	status, _ := coordinator.Status(ctx, sagaID)
+	assert.Equal(t, SagaRunning, status)
+}
@@ -0,0 +1,197 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedSagaID retourne la saga de l'échange reçu par le mock
+func receivedSagaID(mock *MockEndpoint, index int) string {
+	sagaID, _ := mock.ReceivedExchanges()[index].GetIn().GetHeader(SagaLongRunningAction)
+	id, _ := sagaID.(string)
+	return id
+}
+
+func TestSaga_OrderFlow(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:order").
+		Saga().
+		Compensation("mock:cancelOrder").
+		Completion("mock:confirmOrder").
+		Option("orderId", "${header.orderId}").
+		To("direct:payment").
+		To("direct:shipping").
+		End().
+		To("mock:result").
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:payment").
+		Saga().
+		Compensation("mock:refund").
+		To("mock:payment").
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:shipping").
+		Saga().
+		Propagation(SagaMandatory).
+		Compensation("mock:cancelShipping").
+		ProcessFunc(func(e *Exchange) error {
+			if fail, _ := e.GetIn().GetHeader("fail"); fail == true {
+				return errors.New("no carrier available")
+			}
+			return nil
+		}).
+		End().
+		Build()
+
+	confirmOrder, _ := camel.GetMockEndpoint("mock:confirmOrder")
+	confirmOrder.ExpectedMessageCount(1)
+	confirmOrder.ExpectedHeaderReceived("orderId", "1")
+	cancelOrder, _ := camel.GetMockEndpoint("mock:cancelOrder")
+	cancelOrder.ExpectedMessageCount(1)
+	cancelOrder.ExpectedHeaderReceived("orderId", "2")
+	refund, _ := camel.GetMockEndpoint("mock:refund")
+	refund.ExpectedMessageCount(1)
+	cancelShipping, _ := camel.GetMockEndpoint("mock:cancelShipping")
+	cancelShipping.ExpectedMessageCount(1)
+	payment, _ := camel.GetMockEndpoint("mock:payment")
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("order 1")
+	result.MessageN(0).Predicate(func(e *Exchange) bool {
+		_, exists := e.GetIn().GetHeader(SagaLongRunningAction)
+		return !exists
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:order", "order 1", map[string]any{"orderId": "1"}))
+	err := template.SendBodyAndHeaders("direct:order", "order 2", map[string]any{"orderId": "2", "fail": true})
+	assert.EqualError(t, err, "no carrier available")
+
+	confirmOrder.AssertIsSatisfied(t, time.Second)
+	cancelOrder.AssertIsSatisfied(t, time.Second)
+	refund.AssertIsSatisfied(t, time.Second)
+	cancelShipping.AssertIsSatisfied(t, time.Second)
+	result.AssertIsSatisfied(t, time.Second)
+
+	// Les participants ont rejoint la saga de la route principale
+	require.Equal(t, 2, payment.ReceivedCounter())
+	coordinator := camel.GetSagaCoordinator()
+	ctx := context.Background()
+	status, err := coordinator.Status(ctx, receivedSagaID(payment, 0))
+	require.NoError(t, err)
+	assert.Equal(t, SagaCompleted, status)
+	assert.Equal(t, receivedSagaID(payment, 1), receivedSagaID(refund, 0))
+	status, err = coordinator.Status(ctx, receivedSagaID(payment, 1))
+	require.NoError(t, err)
+	assert.Equal(t, SagaCompensated, status)
+}
+
+func TestSaga_Propagation(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:outer").
+		Saga().
+		Compensation("mock:compensateOuter").
+		To("mock:outer").
+		To("direct:inner").
+		To("mock:afterInner").
+		ProcessFunc(func(e *Exchange) error {
+			return errors.New("outer failure")
+		}).
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:inner").
+		Saga().
+		Propagation(SagaRequiresNew).
+		Compensation("mock:compensateInner").
+		To("mock:inner").
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:mandatory").
+		Saga().
+		Propagation(SagaMandatory).
+		To("mock:mandatory").
+		End().
+		Build()
+	camel.CreateRouteBuilder().
+		From("direct:supports").
+		Saga().
+		Propagation(SagaSupports).
+		Compensation("mock:compensateSupports").
+		To("mock:supports").
+		End().
+		Build()
+
+	outer, _ := camel.GetMockEndpoint("mock:outer")
+	inner, _ := camel.GetMockEndpoint("mock:inner")
+	afterInner, _ := camel.GetMockEndpoint("mock:afterInner")
+	compensateOuter, _ := camel.GetMockEndpoint("mock:compensateOuter")
+	compensateOuter.ExpectedMessageCount(1)
+	compensateInner, _ := camel.GetMockEndpoint("mock:compensateInner")
+	mandatory, _ := camel.GetMockEndpoint("mock:mandatory")
+	supports, _ := camel.GetMockEndpoint("mock:supports")
+	supports.ExpectedMessageCount(1)
+	supports.MessageN(0).Predicate(func(e *Exchange) bool {
+		_, exists := e.GetIn().GetHeader(SagaLongRunningAction)
+		return !exists
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	assert.EqualError(t, template.SendBody("direct:outer", "order"), "outer failure")
+	assert.ErrorIs(t, template.SendBody("direct:mandatory", "order"), ErrSagaRequired)
+	require.NoError(t, template.SendBody("direct:supports", "order"))
+
+	// La saga interne est complétée ; la saga englobante est restaurée puis compensée
+	compensateOuter.AssertIsSatisfied(t, time.Second)
+	supports.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, 0, compensateInner.ReceivedCounter())
+	assert.Equal(t, 0, mandatory.ReceivedCounter())
+	assert.NotEqual(t, receivedSagaID(outer, 0), receivedSagaID(inner, 0))
+	assert.Equal(t, receivedSagaID(outer, 0), receivedSagaID(afterInner, 0))
+	assert.Equal(t, receivedSagaID(outer, 0), receivedSagaID(compensateOuter, 0))
+}
+
+func TestSaga_Timeout(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:slow").
+		Saga().
+		Timeout(50 * time.Millisecond).
+		Compensation("mock:compensate").
+		Completion("mock:complete").
+		To("mock:reserve").
+		ProcessFunc(func(e *Exchange) error {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		}).
+		End().
+		Build()
+
+	compensate, _ := camel.GetMockEndpoint("mock:compensate")
+	compensate.ExpectedMessageCount(1)
+	complete, _ := camel.GetMockEndpoint("mock:complete")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// La saga est compensée à l'expiration de son délai et ne peut plus être complétée
+	err := camel.CreateProducerTemplate().SendBody("direct:slow", "order")
+	assert.ErrorIs(t, err, ErrSagaNotRunning)
+	compensate.AssertIsSatisfied(t, time.Second)
+	assert.Equal(t, 0, complete.ReceivedCounter())
+}
@@ -425,29 +425,25 @@ func (c *SFTPConsumer) doPoll(ctx context.Context) {
 		}
 	}
 
-	count := 0
-	for _, f := range files {
-		if c.opts.MaxMessagesPerPoll > 0 && count >= c.opts.MaxMessagesPerPoll {
-			break
-		}
-
+	batchSize := c.opts.batchSize(len(files))
+	processBatch(batchSize, func(i int) *Exchange {
+		f := files[i]
 		file, err := sftpClient.Open(f.path)
 		if err != nil {
 			fmt.Printf("Erreur lors de l'ouverture du fichier SFTP %s: %v\n", f.path, err)
-			continue
+			return nil
 		}
 		content, err := io.ReadAll(file)
 		file.Close()
 		if err != nil {
 			fmt.Printf("Erreur lors de la lecture du fichier SFTP %s: %v\n", f.path, err)
-			continue
+			return nil
 		}
 
 		exchange := NewExchange(ctx)
 		exchange.SetBody(content)
 		exchange.SetHeader(CamelFileName, f.name)
 		exchange.SetHeader(CamelFilePath, f.path)
-
 		exchange.AddSynchronization(&sftpSynchronization{
 			client:     sftpClient,
 			path:       f.path,
@@ -456,14 +452,8 @@ func (c *SFTPConsumer) doPoll(ctx context.Context) {
 			move:       c.opts.Move,
 			moveFailed: c.opts.MoveFailed,
 		})
-
-		procErr := c.processor.Process(exchange)
-		exchange.Done(procErr)
-
-		if procErr == nil || errors.Is(procErr, ErrStopRouting) {
-			count++
-		}
-	}
+		return exchange
+	}, c.processor)
 }
 
 func (c *SFTPConsumer) Stop() error {
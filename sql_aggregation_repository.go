@@ -4,22 +4,34 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"time"
 )
 
 // SQLAggregationRepository is a SQL-based implementation of AggregationRepository.
+// With OptimisticLocking, each row holds a version incremented by every update, and the
+// repository implements OptimisticLockingAggregationRepository.
+// It also implements RecoverableAggregationRepository: the completed exchanges are kept in a
+// second table until they are confirmed, and can be redelivered after a restart.
 type SQLAggregationRepository struct {
 	db             *sql.DB
 	tableName      string
+	completedTable string
 	// UseDollarParam uses dollar parameters (true for PostgreSQL $1, $2; false for MySQL/SQLite ?, ?)
 	UseDollarParam bool
+	// OptimisticLocking stores and checks the version column
+	OptimisticLocking bool
 }
 
 // SQLAggregationOptions contains the options for configuring SQLAggregationRepository.
 type SQLAggregationOptions struct {
-	TableName      string
-	UseDollarParam bool
+	TableName string
+	// CompletedTableName is the table of the completed exchanges (default: TableName + "_completed")
+	CompletedTableName string
+	UseDollarParam     bool
+	OptimisticLocking  bool
 }
 
 // ExchangeData is used to serialize the Exchange content to JSON.
@@ -37,38 +49,85 @@ func NewSQLAggregationRepository(db *sql.DB, opts SQLAggregationOptions) *SQLAgg
 	if tableName == "" {
 		tableName = "camel_aggregations"
 	}
+	completedTable := opts.CompletedTableName
+	if completedTable == "" {
+		completedTable = tableName + "_completed"
+	}
 	return &SQLAggregationRepository{
-		db:             db,
-		tableName:      tableName,
-		UseDollarParam: opts.UseDollarParam,
+		db:                db,
+		tableName:         tableName,
+		completedTable:    completedTable,
+		UseDollarParam:    opts.UseDollarParam,
+		OptimisticLocking: opts.OptimisticLocking,
 	}
 }
 
-// InitDB creates the table if it doesn't exist.
+// InitDB creates the tables if they don't exist, and adds the columns missing from tables
+// created by a previous version.
 func (r *SQLAggregationRepository) InitDB(ctx context.Context) error {
 	query := fmt.Sprintf(`
 		CREATE TABLE IF NOT EXISTS %s (
 			correlation_key VARCHAR(255) PRIMARY KEY,
 			exchange_data TEXT NOT NULL,
+			version BIGINT NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
 	`, r.tableName)
-	_, err := r.db.ExecContext(ctx, query)
-	return err
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if err := r.addMissingColumn(ctx, r.tableName, "version", "BIGINT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			exchange_id VARCHAR(255) PRIMARY KEY,
+			correlation_key VARCHAR(255) NOT NULL,
+			exchange_data TEXT NOT NULL,
+			redelivery_count INTEGER NOT NULL DEFAULT 0,
+			updated_at BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`, r.completedTable)
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if err := r.addMissingColumn(ctx, r.completedTable, "redelivery_count", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	// The time of the completion or of the last redelivery, in Unix milliseconds
+	return r.addMissingColumn(ctx, r.completedTable, "updated_at", "BIGINT NOT NULL DEFAULT 0")
+}
+
+// addMissingColumn adds a column to an existing table if it doesn't have it yet.
+func (r *SQLAggregationRepository) addMissingColumn(ctx context.Context, table, column, definition string) error {
+	// Selecting the column fails only if it doesn't exist
+	probe := fmt.Sprintf("SELECT %s FROM %s WHERE 1 = 0", column, table)
+	rows, err := r.db.QueryContext(ctx, probe)
+	if err == nil {
+		return rows.Close()
+	}
+	alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := r.db.ExecContext(ctx, alter); err != nil {
+		return fmt.Errorf("failed to add column %s to table %s: %w", column, table, err)
+	}
+	return nil
+}
+
+// param returns the placeholder for the nth parameter (1-based).
+func (r *SQLAggregationRepository) param(n int) string {
+	if r.UseDollarParam {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
 }
 
 // Add adds or updates an exchange in the repository.
 func (r *SQLAggregationRepository) Add(ctx context.Context, key string, exchange *Exchange) error {
-	data := ExchangeData{
-		Body:       exchange.GetIn().GetBody(),
-		Headers:    exchange.GetIn().GetHeaders(),
-		Properties: exchange.GetProperties(),
-		Created:    exchange.Created,
-		Modified:   exchange.Modified,
-	}
-	jsonData, err := json.Marshal(data)
+	jsonData, err := marshalExchangeData(exchange)
 	if err != nil {
-		return fmt.Errorf("failed to marshal exchange data: %w", err)
+		return err
 	}
 
 	var query string
@@ -88,41 +147,109 @@ func (r *SQLAggregationRepository) Add(ctx context.Context, key string, exchange
 		`, r.tableName)
 	}
 
-	_, err = r.db.ExecContext(ctx, query, key, string(jsonData))
+	_, err = r.db.ExecContext(ctx, query, key, jsonData)
 	return err
 }
 
+// AddIfUnchanged stores newExchange if the stored exchange still has the version of oldExchange,
+// or if it doesn't exist when oldExchange is nil. Requires OptimisticLocking.
+func (r *SQLAggregationRepository) AddIfUnchanged(ctx context.Context, key string, oldExchange, newExchange *Exchange) error {
+	if !r.OptimisticLocking {
+		return fmt.Errorf("optimistic locking is not enabled on aggregation repository %s", r.tableName)
+	}
+	jsonData, err := marshalExchangeData(newExchange)
+	if err != nil {
+		return err
+	}
+
+	if oldExchange == nil {
+		query := fmt.Sprintf("INSERT INTO %s (correlation_key, exchange_data, version) VALUES (%s, %s, 1)",
+			r.tableName, r.param(1), r.param(2))
+		if _, err := r.db.ExecContext(ctx, query, key, jsonData); err != nil {
+			// A primary key violation means that another aggregator created the group first
+			if existing, gerr := r.Get(ctx, key); gerr == nil && existing != nil {
+				return fmt.Errorf("%w: correlation key %s was added concurrently", ErrOptimisticLockingFailure, key)
+			}
+			return err
+		}
+		return nil
+	}
+
+	version, ok := oldExchange.GetPropertyAsInt(CamelAggregationVersion)
+	if !ok {
+		return fmt.Errorf("exchange for correlation key %s has no %s property", key, CamelAggregationVersion)
+	}
+	query := fmt.Sprintf("UPDATE %s SET exchange_data = %s, version = version + 1 WHERE correlation_key = %s AND version = %s",
+		r.tableName, r.param(1), r.param(2), r.param(3))
+	result, err := r.db.ExecContext(ctx, query, jsonData, key, version)
+	if err != nil {
+		return err
+	}
+	return r.checkVersion(result, key, version)
+}
+
+// RemoveIfUnchanged removes the stored exchange if it still has the version of exchange.
+// Requires OptimisticLocking.
+func (r *SQLAggregationRepository) RemoveIfUnchanged(ctx context.Context, key string, exchange *Exchange) error {
+	if !r.OptimisticLocking {
+		return fmt.Errorf("optimistic locking is not enabled on aggregation repository %s", r.tableName)
+	}
+	version, ok := exchange.GetPropertyAsInt(CamelAggregationVersion)
+	if !ok {
+		return fmt.Errorf("exchange for correlation key %s has no %s property", key, CamelAggregationVersion)
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE correlation_key = %s AND version = %s", r.tableName, r.param(1), r.param(2))
+	result, err := r.db.ExecContext(ctx, query, key, version)
+	if err != nil {
+		return err
+	}
+	return r.checkVersion(result, key, version)
+}
+
+// checkVersion returns ErrOptimisticLockingFailure if the statement matched no row.
+func (r *SQLAggregationRepository) checkVersion(result sql.Result, key string, version int) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: correlation key %s is no longer at version %d", ErrOptimisticLockingFailure, key, version)
+	}
+	return nil
+}
+
 // Get retrieves an exchange by its correlation key.
 func (r *SQLAggregationRepository) Get(ctx context.Context, key string) (*Exchange, error) {
-	var query string
-	if r.UseDollarParam {
-		query = fmt.Sprintf("SELECT exchange_data FROM %s WHERE correlation_key = $1", r.tableName)
-	} else {
-		query = fmt.Sprintf("SELECT exchange_data FROM %s WHERE correlation_key = ?", r.tableName)
+	columns := "exchange_data"
+	if r.OptimisticLocking {
+		columns += ", version"
 	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE correlation_key = %s", columns, r.tableName, r.param(1))
 
 	row := r.db.QueryRowContext(ctx, query, key)
-	var jsonData string
-	err := row.Scan(&jsonData)
+	var (
+		jsonData string
+		version  int64
+	)
+	dest := []any{&jsonData}
+	if r.OptimisticLocking {
+		dest = append(dest, &version)
+	}
+	err := row.Scan(dest...)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil // Not found
 		}
 		return nil, fmt.Errorf("failed to fetch exchange data: %w", err)
 	}
 
-	var data ExchangeData
-	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal exchange data: %w", err)
+	exchange, err := unmarshalExchangeData(ctx, jsonData)
+	if err != nil {
+		return nil, err
+	}
+	if r.OptimisticLocking {
+		exchange.SetProperty(CamelAggregationVersion, int(version))
 	}
-
-	// Reconstruct the exchange
-	exchange := NewExchange(ctx)
-	exchange.GetIn().SetBody(data.Body)
-	exchange.GetIn().SetHeaders(data.Headers)
-	exchange.SetProperties(data.Properties)
-	exchange.Created = data.Created
-	exchange.Modified = data.Modified
 
 	return exchange, nil
 }
@@ -139,3 +266,168 @@ func (r *SQLAggregationRepository) Remove(ctx context.Context, key string) error
 	_, err := r.db.ExecContext(ctx, query, key)
 	return err
 }
+
+// Complete removes the group of the correlation key and stores the aggregated exchange in the
+// completed table, in a single transaction.
+func (r *SQLAggregationRepository) Complete(ctx context.Context, key string, exchange *Exchange) error {
+	jsonData, err := marshalExchangeData(exchange)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !r.OptimisticLocking {
+		query := fmt.Sprintf("DELETE FROM %s WHERE correlation_key = %s", r.tableName, r.param(1))
+		if _, err := tx.ExecContext(ctx, query, key); err != nil {
+			return err
+		}
+	} else if version, ok := exchange.GetPropertyAsInt(CamelAggregationVersion); ok {
+		// Without version, the exchange was not read from the repository: there is no group to remove
+		query := fmt.Sprintf("DELETE FROM %s WHERE correlation_key = %s AND version = %s", r.tableName, r.param(1), r.param(2))
+		result, err := tx.ExecContext(ctx, query, key, version)
+		if err != nil {
+			return err
+		}
+		if err := r.checkVersion(result, key, version); err != nil {
+			return err
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (exchange_id, correlation_key, exchange_data, updated_at) VALUES (%s, %s, %s, %s)",
+		r.completedTable, r.param(1), r.param(2), r.param(3), r.param(4))
+	if _, err := tx.ExecContext(ctx, query, exchange.ID, key, jsonData, time.Now().UnixMilli()); err != nil {
+		return fmt.Errorf("failed to store completed exchange: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Confirm removes a completed exchange from the completed table.
+func (r *SQLAggregationRepository) Confirm(ctx context.Context, exchangeID string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE exchange_id = %s", r.completedTable, r.param(1))
+	_, err := r.db.ExecContext(ctx, query, exchangeID)
+	return err
+}
+
+// recoverableCondition returns the condition selecting the completed exchanges that can be
+// recovered with the options, with its arguments, starting at the nth parameter.
+func (r *SQLAggregationRepository) recoverableCondition(options RecoveryOptions, n int) (string, []any) {
+	condition := fmt.Sprintf("updated_at <= %s", r.param(n))
+	args := []any{time.Now().Add(-options.Lease).UnixMilli()}
+	if options.MaximumRedeliveries > 0 {
+		condition += fmt.Sprintf(" AND redelivery_count < %s", r.param(n+1))
+		args = append(args, options.MaximumRedeliveries)
+	}
+	return condition, args
+}
+
+// Scan returns the IDs of the completed exchanges not confirmed yet that can be recovered,
+// oldest first.
+func (r *SQLAggregationRepository) Scan(ctx context.Context, options RecoveryOptions) ([]string, error) {
+	condition, args := r.recoverableCondition(options, 1)
+	query := fmt.Sprintf("SELECT exchange_id FROM %s WHERE %s ORDER BY created_at", r.completedTable, condition)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan completed exchanges: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Recover retrieves a completed exchange, with its ID and its number of redeliveries,
+// incremented in the redelivery_count column. The conditional update claims the exchange:
+// when several aggregators share the table, only one of them recovers it until its lease
+// expires. Returns (nil, nil) if it doesn't exist or can't be recovered.
+func (r *SQLAggregationRepository) Recover(ctx context.Context, exchangeID string, options RecoveryOptions) (*Exchange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	condition, args := r.recoverableCondition(options, 3)
+	query := fmt.Sprintf("UPDATE %s SET redelivery_count = redelivery_count + 1, updated_at = %s WHERE exchange_id = %s AND %s",
+		r.completedTable, r.param(1), r.param(2), condition)
+	result, err := tx.ExecContext(ctx, query, append([]any{time.Now().UnixMilli(), exchangeID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count redelivery: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, nil
+	}
+
+	query = fmt.Sprintf("SELECT exchange_data, redelivery_count FROM %s WHERE exchange_id = %s",
+		r.completedTable, r.param(1))
+	var jsonData string
+	var redeliveries int
+	if err := tx.QueryRowContext(ctx, query, exchangeID).Scan(&jsonData, &redeliveries); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch completed exchange: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	exchange, err := unmarshalExchangeData(ctx, jsonData)
+	if err != nil {
+		return nil, err
+	}
+	exchange.ID = exchangeID
+	exchange.GetIn().SetHeader(CamelRedeliveryCounter, redeliveries)
+	return exchange, nil
+}
+
+// marshalExchangeData serializes the content of an exchange to JSON. The version is stored in
+// its own column.
+func marshalExchangeData(exchange *Exchange) (string, error) {
+	properties := exchange.GetProperties()
+	if _, exists := properties[CamelAggregationVersion]; exists {
+		properties = maps.Clone(properties)
+		delete(properties, CamelAggregationVersion)
+	}
+	data := ExchangeData{
+		Body:       exchange.GetIn().GetBody(),
+		Headers:    exchange.GetIn().GetHeaders(),
+		Properties: properties,
+		Created:    exchange.Created,
+		Modified:   exchange.Modified,
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal exchange data: %w", err)
+	}
+	return string(jsonData), nil
+}
+
+// unmarshalExchangeData reconstructs an exchange from its JSON serialization.
+func unmarshalExchangeData(ctx context.Context, jsonData string) (*Exchange, error) {
+	var data ExchangeData
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal exchange data: %w", err)
+	}
+
+	exchange := NewExchange(ctx)
+	exchange.GetIn().SetBody(data.Body)
+	exchange.GetIn().SetHeaders(data.Headers)
+	exchange.SetProperties(data.Properties)
+	exchange.Created = data.Created
+	exchange.Modified = data.Modified
+	return exchange, nil
+}
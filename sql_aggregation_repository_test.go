@@ -8,6 +8,7 @@ import (
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSQLAggregationRepository_AddGetRemove(t *testing.T) {
@@ -79,3 +80,137 @@ func TestSQLAggregationRepository_AddGetRemove(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, exchange)
 }
+
+func TestSQLAggregationRepository_OptimisticLocking(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	repo := NewSQLAggregationRepository(db, SQLAggregationOptions{TableName: "test_optimistic", OptimisticLocking: true})
+	ctx := context.Background()
+	require.NoError(t, repo.InitDB(ctx))
+
+	// Création du groupe : un second ajout sans état précédent est en conflit
+	first := NewExchange(ctx)
+	first.GetIn().SetBody("a1")
+	require.NoError(t, repo.AddIfUnchanged(ctx, "A", nil, first))
+	assert.ErrorIs(t, repo.AddIfUnchanged(ctx, "A", nil, first), ErrOptimisticLockingFailure)
+
+	// Deux nœuds lisent la même version : seule la première mise à jour réussit
+	node1, err := repo.Get(ctx, "A")
+	require.NoError(t, err)
+	node2, err := repo.Get(ctx, "A")
+	require.NoError(t, err)
+	version, _ := node1.GetPropertyAsInt(CamelAggregationVersion)
+	assert.Equal(t, 1, version)
+
+	update := NewExchange(ctx)
+	update.GetIn().SetBody("a1,a2")
+	require.NoError(t, repo.AddIfUnchanged(ctx, "A", node1, update))
+	assert.ErrorIs(t, repo.AddIfUnchanged(ctx, "A", node2, update), ErrOptimisticLockingFailure)
+	assert.ErrorIs(t, repo.RemoveIfUnchanged(ctx, "A", node2), ErrOptimisticLockingFailure)
+
+	current, err := repo.Get(ctx, "A")
+	require.NoError(t, err)
+	assert.Equal(t, "a1,a2", current.GetIn().GetBody())
+	version, _ = current.GetPropertyAsInt(CamelAggregationVersion)
+	assert.Equal(t, 2, version)
+
+	require.NoError(t, repo.RemoveIfUnchanged(ctx, "A", current))
+	removed, err := repo.Get(ctx, "A")
+	require.NoError(t, err)
+	assert.Nil(t, removed)
+
+	// Le mode optimiste doit être activé
+	plain := NewSQLAggregationRepository(db, SQLAggregationOptions{TableName: "test_optimistic"})
+	assert.Error(t, plain.AddIfUnchanged(ctx, "B", nil, first))
+}
+
+func TestSQLAggregationRepository_InitDBAddsVersionColumn(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	// Table créée par une version précédente, sans la colonne version
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE test_legacy (
+			correlation_key VARCHAR(255) PRIMARY KEY,
+			exchange_data TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	require.NoError(t, err)
+	plain := NewSQLAggregationRepository(db, SQLAggregationOptions{TableName: "test_legacy"})
+	legacy := NewExchange(ctx)
+	legacy.GetIn().SetBody("a1")
+	require.NoError(t, plain.Add(ctx, "A", legacy))
+
+	repo := NewSQLAggregationRepository(db, SQLAggregationOptions{TableName: "test_legacy", OptimisticLocking: true})
+	require.NoError(t, repo.InitDB(ctx))
+	// Une seconde initialisation ne modifie plus la table
+	require.NoError(t, repo.InitDB(ctx))
+
+	// Les groupes existants sont repris avec la version 0
+	current, err := repo.Get(ctx, "A")
+	require.NoError(t, err)
+	assert.Equal(t, "a1", current.GetIn().GetBody())
+	update := NewExchange(ctx)
+	update.GetIn().SetBody("a1,a2")
+	require.NoError(t, repo.AddIfUnchanged(ctx, "A", current, update))
+
+	created := NewExchange(ctx)
+	created.GetIn().SetBody("b1")
+	require.NoError(t, repo.AddIfUnchanged(ctx, "B", nil, created))
+}
+
+func TestSQLAggregationRepository_Recoverable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	repo := NewSQLAggregationRepository(db, SQLAggregationOptions{TableName: "test_recoverable"})
+	require.NoError(t, repo.InitDB(context.Background()))
+	testRecoverableAggregationRepository(t, repo)
+}
+
+func TestSQLAggregationRepository_RecoverableOptimisticLocking(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	repo := NewSQLAggregationRepository(db, SQLAggregationOptions{TableName: "test_recoverable", OptimisticLocking: true})
+	ctx := context.Background()
+	require.NoError(t, repo.InitDB(ctx))
+
+	first := NewExchange(ctx)
+	first.GetIn().SetBody("a1")
+	require.NoError(t, repo.AddIfUnchanged(ctx, "A", nil, first))
+	stale, err := repo.Get(ctx, "A")
+	require.NoError(t, err)
+	current, err := repo.Get(ctx, "A")
+	require.NoError(t, err)
+	require.NoError(t, repo.AddIfUnchanged(ctx, "A", current, current))
+
+	// La complétion d'une version périmée échoue sans conserver l'échange
+	assert.ErrorIs(t, repo.Complete(ctx, "A", stale), ErrOptimisticLockingFailure)
+	ids, err := repo.Scan(ctx, RecoveryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	current, err = repo.Get(ctx, "A")
+	require.NoError(t, err)
+	require.NoError(t, repo.Complete(ctx, "A", current))
+	ids, err = repo.Scan(ctx, RecoveryOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{current.ID}, ids)
+
+	// La version n'est pas conservée avec l'échange complété
+	recovered, err := repo.Recover(ctx, current.ID, RecoveryOptions{})
+	require.NoError(t, err)
+	assert.False(t, recovered.HasProperty(CamelAggregationVersion))
+}
@@ -0,0 +1,247 @@
+package gocamel
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SQLClaimCheckRepository is a SQL-based implementation of ClaimCheckRepository.
+// Messages stored with Add use position 0; stacked messages use increasing positions from 1.
+type SQLClaimCheckRepository struct {
+	db        *sql.DB
+	tableName string
+	// UseDollarParam uses dollar parameters (true for PostgreSQL $1, $2; false for MySQL/SQLite ?, ?)
+	UseDollarParam bool
+}
+
+// SQLClaimCheckOptions contains the options for configuring SQLClaimCheckRepository.
+type SQLClaimCheckOptions struct {
+	TableName      string
+	UseDollarParam bool
+}
+
+// claimCheckData is used to serialize a message to JSON. Byte slices (attachments, binary
+// bodies) are kept apart so that they are restored as []byte rather than base64 strings.
+type claimCheckData struct {
+	Body        any               `json:"body,omitempty"`
+	BodyBytes   []byte            `json:"bodyBytes,omitempty"`
+	Headers     map[string]any    `json:"headers,omitempty"`
+	HeaderBytes map[string][]byte `json:"headerBytes,omitempty"`
+}
+
+// NewSQLClaimCheckRepository creates a new SQLClaimCheckRepository instance.
+func NewSQLClaimCheckRepository(db *sql.DB, opts SQLClaimCheckOptions) *SQLClaimCheckRepository {
+	tableName := opts.TableName
+	if tableName == "" {
+		tableName = "camel_claimcheck"
+	}
+	return &SQLClaimCheckRepository{
+		db:             db,
+		tableName:      tableName,
+		UseDollarParam: opts.UseDollarParam,
+	}
+}
+
+// InitDB creates the table if it doesn't exist.
+func (r *SQLClaimCheckRepository) InitDB(ctx context.Context) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			claim_key VARCHAR(255) NOT NULL,
+			position INTEGER NOT NULL,
+			message_data TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (claim_key, position)
+		)
+	`, r.tableName)
+	_, err := r.db.ExecContext(ctx, query)
+	return err
+}
+
+// param returns the placeholder for the nth parameter (1-based).
+func (r *SQLClaimCheckRepository) param(n int) string {
+	if r.UseDollarParam {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Add adds or replaces the message stored under the key.
+func (r *SQLClaimCheckRepository) Add(ctx context.Context, key string, message *Message) error {
+	data, err := marshalClaimCheckMessage(message)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE claim_key = %s AND position = 0", r.tableName, r.param(1))
+	if _, err := tx.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to replace claim check message: %w", err)
+	}
+	query = fmt.Sprintf("INSERT INTO %s (claim_key, position, message_data) VALUES (%s, 0, %s)",
+		r.tableName, r.param(1), r.param(2))
+	if _, err := tx.ExecContext(ctx, query, key, data); err != nil {
+		return fmt.Errorf("failed to add claim check message: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Get retrieves the message stored under the key. Returns nil if it doesn't exist.
+func (r *SQLClaimCheckRepository) Get(ctx context.Context, key string) (*Message, error) {
+	query := fmt.Sprintf("SELECT message_data FROM %s WHERE claim_key = %s AND position = 0", r.tableName, r.param(1))
+	var data string
+	if err := r.db.QueryRowContext(ctx, query, key).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch claim check message: %w", err)
+	}
+	return unmarshalClaimCheckMessage(data)
+}
+
+// GetAndRemove retrieves and removes the message stored under the key in a single transaction.
+// The DELETE decides which caller wins: a concurrent caller that read the same row gets nil.
+func (r *SQLClaimCheckRepository) GetAndRemove(ctx context.Context, key string) (*Message, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("SELECT message_data FROM %s WHERE claim_key = %s AND position = 0", r.tableName, r.param(1))
+	var data string
+	if err := tx.QueryRowContext(ctx, query, key).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch claim check message: %w", err)
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE claim_key = %s AND position = 0", r.tableName, r.param(1))
+	result, err := tx.ExecContext(ctx, query, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove claim check message: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return unmarshalClaimCheckMessage(data)
+}
+
+// Remove removes the message stored under the key.
+func (r *SQLClaimCheckRepository) Remove(ctx context.Context, key string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE claim_key = %s AND position = 0", r.tableName, r.param(1))
+	_, err := r.db.ExecContext(ctx, query, key)
+	return err
+}
+
+// RemoveStack removes the stack identified by the key.
+func (r *SQLClaimCheckRepository) RemoveStack(ctx context.Context, key string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE claim_key = %s AND position > 0", r.tableName, r.param(1))
+	_, err := r.db.ExecContext(ctx, query, key)
+	return err
+}
+
+// Push pushes the message on the stack identified by the key.
+func (r *SQLClaimCheckRepository) Push(ctx context.Context, key string, message *Message) error {
+	data, err := marshalClaimCheckMessage(message)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (claim_key, position, message_data)
+		SELECT %s, COALESCE(MAX(position), 0) + 1, %s FROM %s WHERE claim_key = %s
+	`, r.tableName, r.param(1), r.param(2), r.tableName, r.param(3))
+	if _, err := r.db.ExecContext(ctx, query, key, data, key); err != nil {
+		return fmt.Errorf("failed to push claim check message: %w", err)
+	}
+	return nil
+}
+
+// Pop removes and returns the last message pushed on the stack. Returns nil if the stack is empty.
+func (r *SQLClaimCheckRepository) Pop(ctx context.Context, key string) (*Message, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		SELECT position, message_data FROM %s
+		WHERE claim_key = %s AND position > 0
+		ORDER BY position DESC LIMIT 1
+	`, r.tableName, r.param(1))
+	var (
+		position int
+		data     string
+	)
+	if err := tx.QueryRowContext(ctx, query, key).Scan(&position, &data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch claim check message: %w", err)
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE claim_key = %s AND position = %s", r.tableName, r.param(1), r.param(2))
+	if _, err := tx.ExecContext(ctx, query, key, position); err != nil {
+		return nil, fmt.Errorf("failed to pop claim check message: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return unmarshalClaimCheckMessage(data)
+}
+
+func marshalClaimCheckMessage(message *Message) (string, error) {
+	data := claimCheckData{Headers: make(map[string]any)}
+	if body, ok := message.GetBody().([]byte); ok {
+		data.BodyBytes = body
+	} else {
+		data.Body = message.GetBody()
+	}
+	for name, value := range message.GetHeaders() {
+		if b, ok := value.([]byte); ok {
+			if data.HeaderBytes == nil {
+				data.HeaderBytes = make(map[string][]byte)
+			}
+			data.HeaderBytes[name] = b
+		} else {
+			data.Headers[name] = value
+		}
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal claim check message: %w", err)
+	}
+	return string(jsonData), nil
+}
+
+func unmarshalClaimCheckMessage(jsonData string) (*Message, error) {
+	var data claimCheckData
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal claim check message: %w", err)
+	}
+
+	message := NewMessage()
+	if data.BodyBytes != nil {
+		message.SetBody(data.BodyBytes)
+	} else {
+		message.SetBody(data.Body)
+	}
+	message.SetHeaders(data.Headers)
+	for name, value := range data.HeaderBytes {
+		message.SetHeader(name, value)
+	}
+	return message, nil
+}
@@ -0,0 +1,36 @@
+package gocamel
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLClaimCheckRepository(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	repo := NewSQLClaimCheckRepository(db, SQLClaimCheckOptions{TableName: "test_claimcheck"})
+	require.NoError(t, repo.InitDB(ctx))
+	testClaimCheckRepository(t, repo)
+
+	// Les corps et en-têtes binaires sont restaurés tels quels
+	message := NewMessage()
+	message.SetBody([]byte{0x25, 0x50, 0x44, 0x46})
+	message.SetHeader(MailAttachmentPrefix+"_invoice.pdf", []byte("%PDF-1.7"))
+	message.SetHeader("Subject", "Invoice")
+	require.NoError(t, repo.Push(ctx, "exchange-1", message))
+
+	restored, err := repo.Pop(ctx, "exchange-1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x25, 0x50, 0x44, 0x46}, restored.GetBody())
+	assert.Equal(t, []byte("%PDF-1.7"), restored.GetHeaders()[MailAttachmentPrefix+"_invoice.pdf"])
+	assert.Equal(t, "Invoice", restored.GetHeaders()["Subject"])
+}
@@ -0,0 +1,109 @@
+package gocamel
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SQLIdempotentRepository is a SQL-based implementation of IdempotentRepository.
+// Keys are scoped by processor name, so several idempotent consumers can share the same table.
+type SQLIdempotentRepository struct {
+	db            *sql.DB
+	tableName     string
+	processorName string
+	// UseDollarParam uses dollar parameters (true for PostgreSQL $1, $2; false for MySQL/SQLite ?, ?)
+	UseDollarParam bool
+}
+
+// SQLIdempotentOptions contains the options for configuring SQLIdempotentRepository.
+type SQLIdempotentOptions struct {
+	TableName      string
+	ProcessorName  string
+	UseDollarParam bool
+}
+
+// NewSQLIdempotentRepository creates a new SQLIdempotentRepository instance.
+func NewSQLIdempotentRepository(db *sql.DB, opts SQLIdempotentOptions) *SQLIdempotentRepository {
+	tableName := opts.TableName
+	if tableName == "" {
+		tableName = "camel_messageprocessed"
+	}
+	processorName := opts.ProcessorName
+	if processorName == "" {
+		processorName = "default"
+	}
+	return &SQLIdempotentRepository{
+		db:             db,
+		tableName:      tableName,
+		processorName:  processorName,
+		UseDollarParam: opts.UseDollarParam,
+	}
+}
+
+// InitDB creates the table if it doesn't exist.
+func (r *SQLIdempotentRepository) InitDB(ctx context.Context) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			processor_name VARCHAR(255) NOT NULL,
+			message_id VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (processor_name, message_id)
+		)
+	`, r.tableName)
+	_, err := r.db.ExecContext(ctx, query)
+	return err
+}
+
+// param returns the placeholder for the nth parameter (1-based).
+func (r *SQLIdempotentRepository) param(n int) string {
+	if r.UseDollarParam {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Add adds the key to the repository. Returns false if the key was already present.
+func (r *SQLIdempotentRepository) Add(ctx context.Context, key string) (bool, error) {
+	query := fmt.Sprintf("INSERT INTO %s (processor_name, message_id) VALUES (%s, %s)",
+		r.tableName, r.param(1), r.param(2))
+	if _, err := r.db.ExecContext(ctx, query, r.processorName, key); err != nil {
+		// The primary key rejects duplicates; any other error is reported as is
+		if exists, containsErr := r.Contains(ctx, key); containsErr == nil && exists {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to add idempotent key: %w", err)
+	}
+	return true, nil
+}
+
+// Contains returns true if the key is present in the repository.
+func (r *SQLIdempotentRepository) Contains(ctx context.Context, key string) (bool, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE processor_name = %s AND message_id = %s",
+		r.tableName, r.param(1), r.param(2))
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, r.processorName, key).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to fetch idempotent key: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Remove removes the key from the repository.
+func (r *SQLIdempotentRepository) Remove(ctx context.Context, key string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE processor_name = %s AND message_id = %s",
+		r.tableName, r.param(1), r.param(2))
+	_, err := r.db.ExecContext(ctx, query, r.processorName, key)
+	return err
+}
+
+// Confirm is a no-op: keys are inserted as soon as they are added.
+func (r *SQLIdempotentRepository) Confirm(ctx context.Context, key string) error {
+	return nil
+}
+
+// Clear removes all the keys of this processor from the repository.
+func (r *SQLIdempotentRepository) Clear(ctx context.Context) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE processor_name = %s", r.tableName, r.param(1))
+	_, err := r.db.ExecContext(ctx, query, r.processorName)
+	return err
+}
@@ -0,0 +1,48 @@
+package gocamel
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLIdempotentRepository(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	orders := NewSQLIdempotentRepository(db, SQLIdempotentOptions{ProcessorName: "orders"})
+	require.NoError(t, orders.InitDB(ctx))
+	invoices := NewSQLIdempotentRepository(db, SQLIdempotentOptions{ProcessorName: "invoices"})
+
+	added, err := orders.Add(ctx, "42")
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = orders.Add(ctx, "42")
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	// Les clés sont isolées par processeur
+	contains, err := invoices.Contains(ctx, "42")
+	require.NoError(t, err)
+	assert.False(t, contains)
+	added, _ = invoices.Add(ctx, "42")
+	assert.True(t, added)
+
+	require.NoError(t, orders.Confirm(ctx, "42"))
+	require.NoError(t, orders.Remove(ctx, "42"))
+	contains, _ = orders.Contains(ctx, "42")
+	assert.False(t, contains)
+
+	orders.Add(ctx, "43")
+	require.NoError(t, orders.Clear(ctx))
+	contains, _ = orders.Contains(ctx, "43")
+	assert.False(t, contains)
+	contains, _ = invoices.Contains(ctx, "42")
+	assert.True(t, contains)
+}
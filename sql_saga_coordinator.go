@@ -0,0 +1,270 @@
+package gocamel
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SQLSagaCoordinator is a SagaCoordinator persisting the sagas and their steps in SQL tables,
+// so that they survive a restart: when the context starts, the timeouts of the running sagas
+// are scheduled again, the sagas whose timeout expired while the application was stopped
+// are compensated, and the sagas interrupted while being completed or compensated are finished.
+type SQLSagaCoordinator struct {
+	*sagaCoordinator
+	store *sqlSagaStore
+}
+
+// SQLSagaOptions contains the options for configuring SQLSagaCoordinator.
+type SQLSagaOptions struct {
+	TableName      string
+	StepTableName  string
+	UseDollarParam bool
+}
+
+// NewSQLSagaCoordinator creates a new SQLSagaCoordinator sending the completion and
+// compensation exchanges through the given CamelContext.
+func NewSQLSagaCoordinator(context *CamelContext, db *sql.DB, opts SQLSagaOptions) *SQLSagaCoordinator {
+	store := &sqlSagaStore{
+		db:             db,
+		tableName:      opts.TableName,
+		stepTableName:  opts.StepTableName,
+		useDollarParam: opts.UseDollarParam,
+	}
+	if store.tableName == "" {
+		store.tableName = "camel_saga"
+	}
+	if store.stepTableName == "" {
+		store.stepTableName = store.tableName + "_step"
+	}
+	return &SQLSagaCoordinator{
+		sagaCoordinator: newSagaCoordinator(context, store),
+		store:           store,
+	}
+}
+
+// InitDB creates the tables if they don't exist.
+func (c *SQLSagaCoordinator) InitDB(ctx context.Context) error {
+	return c.store.initDB(ctx)
+}
+
+type sqlSagaStore struct {
+	db             *sql.DB
+	tableName      string
+	stepTableName  string
+	useDollarParam bool
+}
+
+func (s *sqlSagaStore) initDB(ctx context.Context) error {
+	// The deadline is stored in Unix milliseconds, 0 meaning no timeout
+	queries := []string{
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				saga_id VARCHAR(64) PRIMARY KEY,
+				status VARCHAR(32) NOT NULL,
+				deadline BIGINT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)
+		`, s.tableName),
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				saga_id VARCHAR(64) NOT NULL,
+				position INTEGER NOT NULL,
+				step_data TEXT NOT NULL,
+				PRIMARY KEY (saga_id, position)
+			)
+		`, s.stepTableName),
+	}
+	for _, query := range queries {
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// param returns the placeholder for the nth parameter (1-based).
+func (s *sqlSagaStore) param(n int) string {
+	if s.useDollarParam {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func (s *sqlSagaStore) create(ctx context.Context, sagaID string, deadline time.Time) error {
+	var deadlineMillis int64
+	if !deadline.IsZero() {
+		deadlineMillis = deadline.UnixMilli()
+	}
+	query := fmt.Sprintf("INSERT INTO %s (saga_id, status, deadline) VALUES (%s, %s, %s)",
+		s.tableName, s.param(1), s.param(2), s.param(3))
+	_, err := s.db.ExecContext(ctx, query, sagaID, string(SagaRunning), deadlineMillis)
+	return err
+}
+
+func (s *sqlSagaStore) addStep(ctx context.Context, sagaID string, step SagaStep) error {
+	data, err := json.Marshal(step)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saga step: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The no-op UPDATE locks the saga row while it is RUNNING, like SELECT ... FOR UPDATE
+	// (which SQLite doesn't support): a concurrent transition waits for the step to be committed,
+	// so it is part of the steps read by the completion or compensation, or the step is refused.
+	query := fmt.Sprintf("UPDATE %s SET status = status WHERE saga_id = %s AND status = %s",
+		s.tableName, s.param(1), s.param(2))
+	result, err := tx.ExecContext(ctx, query, sagaID, string(SagaRunning))
+	if err != nil {
+		return fmt.Errorf("failed to lock saga: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		status, err := s.queryStatus(ctx, tx, sagaID)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: saga %s is %s", ErrSagaNotRunning, sagaID, status)
+	}
+
+	query = fmt.Sprintf(`
+		INSERT INTO %s (saga_id, position, step_data)
+		SELECT %s, COALESCE(MAX(position), 0) + 1, %s FROM %s WHERE saga_id = %s
+	`, s.stepTableName, s.param(1), s.param(2), s.stepTableName, s.param(3))
+	if _, err := tx.ExecContext(ctx, query, sagaID, string(data), sagaID); err != nil {
+		return fmt.Errorf("failed to add saga step: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *sqlSagaStore) steps(ctx context.Context, sagaID string) ([]SagaStep, error) {
+	query := fmt.Sprintf("SELECT step_data FROM %s WHERE saga_id = %s ORDER BY position", s.stepTableName, s.param(1))
+	rows, err := s.db.QueryContext(ctx, query, sagaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch saga steps: %w", err)
+	}
+	defer rows.Close()
+
+	var steps []SagaStep
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to fetch saga steps: %w", err)
+		}
+		var step SagaStep
+		if err := json.Unmarshal([]byte(data), &step); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal saga step: %w", err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+func (s *sqlSagaStore) status(ctx context.Context, sagaID string) (SagaStatus, error) {
+	return s.queryStatus(ctx, s.db, sagaID)
+}
+
+// queryStatus reads the status of a saga with a connection or a transaction.
+func (s *sqlSagaStore) queryStatus(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, sagaID string) (SagaStatus, error) {
+	query := fmt.Sprintf("SELECT status FROM %s WHERE saga_id = %s", s.tableName, s.param(1))
+	var status string
+	if err := q.QueryRowContext(ctx, query, sagaID).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %s", ErrSagaNotFound, sagaID)
+		}
+		return "", fmt.Errorf("failed to fetch saga status: %w", err)
+	}
+	return SagaStatus(status), nil
+}
+
+func (s *sqlSagaStore) transition(ctx context.Context, sagaID string, from, to SagaStatus) (bool, error) {
+	query := fmt.Sprintf("UPDATE %s SET status = %s WHERE saga_id = %s AND status = %s",
+		s.tableName, s.param(1), s.param(2), s.param(3))
+	result, err := s.db.ExecContext(ctx, query, string(to), sagaID, string(from))
+	if err != nil {
+		return false, fmt.Errorf("failed to update saga status: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		// Distinguishes an unknown saga from a saga in another status
+		if _, err := s.status(ctx, sagaID); err != nil {
+			return false, err
+		}
+	}
+	return affected > 0, nil
+}
+
+func (s *sqlSagaStore) expired(ctx context.Context, now time.Time) ([]string, error) {
+	query := fmt.Sprintf("SELECT saga_id FROM %s WHERE status = %s AND deadline > 0 AND deadline <= %s",
+		s.tableName, s.param(1), s.param(2))
+	rows, err := s.db.QueryContext(ctx, query, string(SagaRunning), now.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expired []string
+	for rows.Next() {
+		var sagaID string
+		if err := rows.Scan(&sagaID); err != nil {
+			return nil, err
+		}
+		expired = append(expired, sagaID)
+	}
+	return expired, rows.Err()
+}
+
+func (s *sqlSagaStore) deadlines(ctx context.Context) (map[string]time.Time, error) {
+	query := fmt.Sprintf("SELECT saga_id, deadline FROM %s WHERE status = %s AND deadline > 0",
+		s.tableName, s.param(1))
+	rows, err := s.db.QueryContext(ctx, query, string(SagaRunning))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deadlines := make(map[string]time.Time)
+	for rows.Next() {
+		var sagaID string
+		var deadline int64
+		if err := rows.Scan(&sagaID, &deadline); err != nil {
+			return nil, err
+		}
+		deadlines[sagaID] = time.UnixMilli(deadline)
+	}
+	return deadlines, rows.Err()
+}
+
+func (s *sqlSagaStore) finishing(ctx context.Context) (map[string]SagaStatus, error) {
+	query := fmt.Sprintf("SELECT saga_id, status FROM %s WHERE status IN (%s, %s)",
+		s.tableName, s.param(1), s.param(2))
+	rows, err := s.db.QueryContext(ctx, query, string(SagaCompleting), string(SagaCompensating))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	finishing := make(map[string]SagaStatus)
+	for rows.Next() {
+		var sagaID, status string
+		if err := rows.Scan(&sagaID, &status); err != nil {
+			return nil, err
+		}
+		finishing[sagaID] = SagaStatus(status)
+	}
+	return finishing, rows.Err()
+}
@@ -0,0 +1,100 @@
+package gocamel
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLSagaCoordinator(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	testSagaCoordinator(t, func(camel *CamelContext) SagaCoordinator {
+		coordinator := NewSQLSagaCoordinator(camel, db, SQLSagaOptions{TableName: "test_saga"})
+		require.NoError(t, coordinator.InitDB(context.Background()))
+		return coordinator
+	})
+}
+
+func TestSQLSagaCoordinator_Restart(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	// Première instance : les sagas sont créées puis l'application s'arrête avant leur expiration
+	first := NewSQLSagaCoordinator(NewCamelContext(), db, SQLSagaOptions{})
+	require.NoError(t, first.InitDB(ctx))
+	require.NoError(t, first.store.create(ctx, "order-42", time.Now().Add(-time.Second)))
+	require.NoError(t, first.AddStep(ctx, "order-42", SagaStep{
+		Compensation: "mock:cancelPayment",
+		Options:      map[string]any{"orderId": "42"},
+	}))
+	require.NoError(t, first.AddStep(ctx, "order-42", SagaStep{Compensation: "mock:cancelOrder"}))
+	require.NoError(t, first.store.create(ctx, "order-43", time.Now().Add(300*time.Millisecond)))
+	require.NoError(t, first.AddStep(ctx, "order-43", SagaStep{Compensation: "mock:cancelOrder"}))
+	// Sagas interrompues au milieu de leur complétion et de leur compensation
+	require.NoError(t, first.store.create(ctx, "order-44", time.Time{}))
+	require.NoError(t, first.AddStep(ctx, "order-44", SagaStep{Completion: "mock:confirmOrder"}))
+	_, err = first.store.transition(ctx, "order-44", SagaRunning, SagaCompleting)
+	require.NoError(t, err)
+	require.NoError(t, first.store.create(ctx, "order-45", time.Time{}))
+	require.NoError(t, first.AddStep(ctx, "order-45", SagaStep{Compensation: "mock:cancelOrder"}))
+	_, err = first.store.transition(ctx, "order-45", SagaRunning, SagaCompensating)
+	require.NoError(t, err)
+	assert.ErrorIs(t, first.AddStep(ctx, "order-45", SagaStep{Compensation: "mock:late"}), ErrSagaNotRunning)
+
+	// Seconde instance : au démarrage, la saga expirée est compensée à partir des étapes
+	// relues depuis la base et le délai de l'autre saga est réarmé
+	camel := NewCamelContext()
+	camel.AddComponent("mock", NewMockComponent())
+	second := NewSQLSagaCoordinator(camel, db, SQLSagaOptions{})
+	require.NoError(t, second.InitDB(ctx))
+
+	cancelOrder, _ := camel.GetMockEndpoint("mock:cancelOrder")
+	confirmOrder, _ := camel.GetMockEndpoint("mock:confirmOrder")
+	confirmOrder.ExpectedMessageCount(1)
+	cancelPayment, _ := camel.GetMockEndpoint("mock:cancelPayment")
+	cancelPayment.ExpectedHeaderReceived("orderId", "42")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	assert.Eventually(t, func() bool {
+		status, _ := second.Status(ctx, "order-42")
+		return status == SagaCompensated
+	}, time.Second, 10*time.Millisecond)
+	cancelPayment.AssertIsSatisfied(t, time.Second)
+
+	// Les sagas interrompues sont menées à leur terme au démarrage
+	confirmOrder.AssertIsSatisfied(t, time.Second)
+	assert.Eventually(t, func() bool {
+		completed, _ := second.Status(ctx, "order-44")
+		compensated, _ := second.Status(ctx, "order-45")
+		return completed == SagaCompleted && compensated == SagaCompensated
+	}, time.Second, 10*time.Millisecond)
+
+	status, err := second.Status(ctx, "order-43")
+	require.NoError(t, err)
+	assert.Equal(t, SagaRunning, status)
+
+	assert.Eventually(t, func() bool {
+		status, _ := second.Status(ctx, "order-43")
+		return status == SagaCompensated
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, cancelOrder.ReceivedCounter())
+
+	// Plus rien à compenser
+	count, err := second.CompensateExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -183,9 +183,11 @@ func (c *TelegramConsumer) Start(ctx context.Context) error {
 					exchange.SetHeader("TelegramUsername", update.Message.From.UserName)
 				}
 
-				if err := c.processor.Process(exchange); err != nil {
+				err := c.processor.Process(exchange)
+				if err != nil {
 					fmt.Printf("error during traitement du message Telegram: %v\n", err)
 				}
+				exchange.Done(err)
 			}
 		}
 	}()
@@ -0,0 +1,351 @@
+package gocamel
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ThrottlerRejectedError est retournée par un throttler en mode rejet lorsque la limite est atteinte
+type ThrottlerRejectedError struct {
+	Key         string
+	MaxRequests int
+	Period      time.Duration
+}
+
+func (e *ThrottlerRejectedError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("throttler rejected exchange: more than %d requests per %v for key %s", e.MaxRequests, e.Period, e.Key)
+	}
+	return fmt.Sprintf("throttler rejected exchange: more than %d requests per %v", e.MaxRequests, e.Period)
+}
+
+// Throttler implémente le Throttler EIP : au plus MaxRequests échanges traversent le bloc par
+// période. Au-delà, l'échange attend la période suivante (par défaut), est rejeté avec une
+// ThrottlerRejectedError, ou poursuit son traitement en arrière-plan (mode asynchrone).
+//
+// Avec une expression de corrélation, la limite s'applique séparément à chaque clé.
+type Throttler struct {
+	context *CamelContext
+
+	mu          sync.Mutex
+	maxRequests int
+	period      time.Duration
+	windows     map[string]*throttleWindow
+
+	// KeyExpression calcule la clé de corrélation ; nil applique une limite globale
+	KeyExpression func(*Exchange) (string, error)
+	// MaxRequestsHeader est l'en-tête permettant de modifier la limite à l'exécution
+	MaxRequestsHeader string
+	// RejectExecution rejette les échanges au-delà de la limite au lieu de les faire attendre
+	RejectExecution bool
+	// AsyncDelayed libère l'appelant : l'échange retardé poursuit le bloc en arrière-plan
+	AsyncDelayed bool
+	processors   []Processor
+	async        *asyncDelayed
+	stop         *stopSignal
+}
+
+// throttleWindow compte les échanges d'une clé pendant la période en cours
+type throttleWindow struct {
+	start time.Time
+	count int
+}
+
+// NewThrottler crée un throttler laissant passer maxRequests échanges par période
+func NewThrottler(context *CamelContext, maxRequests int, period time.Duration) *Throttler {
+	if maxRequests < 1 {
+		maxRequests = 1
+	}
+	if period <= 0 {
+		period = time.Second
+	}
+	return &Throttler{
+		context:     context,
+		maxRequests: maxRequests,
+		period:      period,
+		windows:     make(map[string]*throttleWindow),
+		processors:  make([]Processor, 0),
+		async:       &asyncDelayed{name: "Throttler"},
+		stop:        newStopSignal(context),
+	}
+}
+
+// AddProcessor ajoute un processeur exécuté une fois l'échange autorisé
+func (t *Throttler) AddProcessor(processor Processor) {
+	t.processors = append(t.processors, processor)
+}
+
+// MaxRequests retourne le nombre maximal d'échanges par période
+func (t *Throttler) MaxRequests() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.maxRequests
+}
+
+// Period retourne la durée de la période
+func (t *Throttler) Period() time.Duration {
+	return t.period
+}
+
+// SetMaxRequests modifie le nombre maximal d'échanges par période ; la nouvelle limite
+// s'applique immédiatement, y compris aux échanges en attente
+func (t *Throttler) SetMaxRequests(maxRequests int) error {
+	if maxRequests < 1 {
+		return fmt.Errorf("throttler maximum requests must be positive: %d", maxRequests)
+	}
+	t.mu.Lock()
+	t.maxRequests = maxRequests
+	t.mu.Unlock()
+	return nil
+}
+
+// Process implémente l'interface Processor
+func (t *Throttler) Process(exchange *Exchange) error {
+	if err := t.updateFromHeader(exchange); err != nil {
+		return err
+	}
+
+	key := ""
+	if t.KeyExpression != nil {
+		var err error
+		if key, err = t.KeyExpression(exchange); err != nil {
+			return fmt.Errorf("throttler correlation expression error: %w", err)
+		}
+	}
+
+	wait, err := t.tryAcquire(key)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		if t.AsyncDelayed {
+			t.async.processLater(t.context.GetContext(), exchange, func(delayed *Exchange) error {
+				return t.acquire(delayed.Context, key)
+			}, t.processBlock)
+			return ErrStopRouting
+		}
+		if err := t.acquire(exchangeContext(exchange), key); err != nil {
+			return err
+		}
+	}
+	return t.processBlock(exchange)
+}
+
+func (t *Throttler) processBlock(exchange *Exchange) error {
+	for _, p := range t.processors {
+		if err := p.Process(exchange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateFromHeader applique la limite portée par l'en-tête MaxRequestsHeader, s'il est présent
+func (t *Throttler) updateFromHeader(exchange *Exchange) error {
+	if t.MaxRequestsHeader == "" {
+		return nil
+	}
+	value, ok := exchange.GetIn().GetHeader(t.MaxRequestsHeader)
+	if !ok || value == nil {
+		return nil
+	}
+	maxRequests, err := strconv.Atoi(fmt.Sprintf("%v", value))
+	if err != nil {
+		return fmt.Errorf("invalid throttler maximum requests header %s: %v", t.MaxRequestsHeader, value)
+	}
+	return t.SetMaxRequests(maxRequests)
+}
+
+// tryAcquire réserve une place dans la période en cours. S'il n'en reste pas, il retourne
+// le temps à attendre avant la période suivante, ou une ThrottlerRejectedError en mode rejet.
+func (t *Throttler) tryAcquire(key string) (time.Duration, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	window := t.window(key, now)
+	if window.count < t.maxRequests {
+		window.count++
+		return 0, nil
+	}
+	if t.RejectExecution {
+		return 0, &ThrottlerRejectedError{Key: key, MaxRequests: t.maxRequests, Period: t.period}
+	}
+	return window.start.Add(t.period).Sub(now), nil
+}
+
+// acquire attend qu'une place se libère, que le contexte soit annulé ou que le CamelContext
+// soit arrêté
+func (t *Throttler) acquire(ctx context.Context, key string) error {
+	for {
+		wait, err := t.tryAcquire(key)
+		if err != nil || wait <= 0 {
+			return err
+		}
+		if err := t.stop.wait(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// window retourne la période en cours pour la clé ; appelé avec le verrou
+func (t *Throttler) window(key string, now time.Time) *throttleWindow {
+	window, exists := t.windows[key]
+	if !exists {
+		// les clés dont la période est écoulée sont purgées à l'apparition d'une nouvelle clé
+		for k, w := range t.windows {
+			if now.Sub(w.start) >= t.period {
+				delete(t.windows, k)
+			}
+		}
+		window = &throttleWindow{start: now}
+		t.windows[key] = window
+	} else if now.Sub(window.start) >= t.period {
+		window.start = now
+		window.count = 0
+	}
+	return window
+}
+
+// throttlers retourne les throttlers de premier niveau de la route, indexés par identifiant de nœud
+func (r *Route) throttlers() map[string]*Throttler {
+	return topLevelNodes[*Throttler](r)
+}
+
+// exchangeContext retourne le contexte de l'échange, ou un contexte vide s'il n'en a pas
+func exchangeContext(exchange *Exchange) context.Context {
+	if exchange.Context == nil {
+		return context.Background()
+	}
+	return exchange.Context
+}
+
+// Throttle commence un bloc Throttler EIP laissant passer au plus maxRequests échanges par période.
+// Sans appel à End(), la limite s'applique au reste de la route.
+func (b *RouteBuilder) Throttle(maxRequests int, period time.Duration) *ThrottleDefinition {
+	t := NewThrottler(b.context, maxRequests, period)
+	b.container.AddProcessor(t)
+
+	return &ThrottleDefinition{
+		RouteBuilder: &RouteBuilder{
+			context:   b.context,
+			route:     b.route,
+			container: t,
+		},
+		parent:    b,
+		throttler: t,
+	}
+}
+
+// ThrottleDefinition permet de configurer le throttler et les processeurs qu'il protège
+type ThrottleDefinition struct {
+	*RouteBuilder
+	parent    *RouteBuilder
+	throttler *Throttler
+}
+
+// CorrelationExpression applique la limite séparément à chaque valeur de l'expression Simple
+// (ex: "${header.apiKey}")
+func (d *ThrottleDefinition) CorrelationExpression(expression string) *ThrottleDefinition {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for throttle: %v", err))
+	}
+	expr := simpleValueExpression(template)
+	return d.CorrelationExpressionFunc(func(exchange *Exchange) (string, error) {
+		value, err := expr(exchange)
+		if err != nil || value == nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", value), nil
+	})
+}
+
+// CorrelationExpressionFunc applique la limite séparément à chaque clé calculée par la fonction
+func (d *ThrottleDefinition) CorrelationExpressionFunc(expression func(*Exchange) (string, error)) *ThrottleDefinition {
+	d.throttler.KeyExpression = expression
+	return d
+}
+
+// MaxRequestsHeader permet de modifier la limite à l'exécution : lorsqu'un échange porte cet
+// en-tête, sa valeur devient le nouveau nombre maximal d'échanges par période
+func (d *ThrottleDefinition) MaxRequestsHeader(name string) *ThrottleDefinition {
+	d.throttler.MaxRequestsHeader = name
+	return d
+}
+
+// RejectExecution rejette les échanges au-delà de la limite avec une ThrottlerRejectedError
+func (d *ThrottleDefinition) RejectExecution() *ThrottleDefinition {
+	d.throttler.RejectExecution = true
+	return d
+}
+
+// AsyncDelayed libère l'appelant lorsque la limite est atteinte : l'échange poursuit le bloc en
+// arrière-plan dès qu'il est autorisé, et l'appelant reçoit ErrStopRouting. L'échange retardé
+// exécute ensuite les étapes ajoutées après End() au même niveau que le bloc.
+func (d *ThrottleDefinition) AsyncDelayed() *ThrottleDefinition {
+	d.throttler.AsyncDelayed = true
+	d.throttler.async.capture(d.parent)
+	return d
+}
+
+// Throttler retourne le throttler configuré
+func (d *ThrottleDefinition) Throttler() *Throttler {
+	return d.throttler
+}
+
+// Process ajoute un processeur et reste dans le contexte du throttler
+func (d *ThrottleDefinition) Process(processor Processor) *ThrottleDefinition {
+	d.RouteBuilder.Process(processor)
+	return d
+}
+
+// ProcessFunc ajoute une fonction de traitement et reste dans le contexte du throttler
+func (d *ThrottleDefinition) ProcessFunc(f func(*Exchange) error) *ThrottleDefinition {
+	d.RouteBuilder.ProcessFunc(f)
+	return d
+}
+
+// To ajoute un ou plusieurs endpoints de destination et reste dans le contexte du throttler
+func (d *ThrottleDefinition) To(uris ...string) *ThrottleDefinition {
+	d.RouteBuilder.To(uris...)
+	return d
+}
+
+// ToD ajoute un ou plusieurs endpoints dynamiques et reste dans le contexte du throttler
+func (d *ThrottleDefinition) ToD(uriTemplates ...string) *ThrottleDefinition {
+	d.RouteBuilder.ToD(uriTemplates...)
+	return d
+}
+
+// SetBody définit le corps du message de sortie et reste dans le contexte du throttler
+func (d *ThrottleDefinition) SetBody(body interface{}) *ThrottleDefinition {
+	d.RouteBuilder.SetBody(body)
+	return d
+}
+
+// SetHeader définit un en-tête du message de sortie et reste dans le contexte du throttler
+func (d *ThrottleDefinition) SetHeader(key string, value interface{}) *ThrottleDefinition {
+	d.RouteBuilder.SetHeader(key, value)
+	return d
+}
+
+// SetProperty définit une propriété de l'échange et reste dans le contexte du throttler
+func (d *ThrottleDefinition) SetProperty(key string, value any) *ThrottleDefinition {
+	d.RouteBuilder.SetProperty(key, value)
+	return d
+}
+
+// Log ajoute un log et reste dans le contexte du throttler
+func (d *ThrottleDefinition) Log(message string) *ThrottleDefinition {
+	d.RouteBuilder.Log(message)
+	return d
+}
+
+// End termine le bloc Throttle et revient au builder parent
+func (d *ThrottleDefinition) End() *RouteBuilder {
+	return d.parent
+}
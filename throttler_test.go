@@ -0,0 +1,279 @@
+package gocamel
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle_Blocking(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Throttle(2, 200*time.Millisecond).
+		To("mock:throttled").
+		End().
+		To("mock:result").
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedMessageCount(5)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	started := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, template.SendBody("direct:start", i))
+	}
+
+	// 5 échanges à 2 par période : les deux derniers attendent la troisième période
+	assert.GreaterOrEqual(t, time.Since(started), 400*time.Millisecond)
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestThrottle_RejectExecution(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Throttle(1, time.Minute).
+		RejectExecution().
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("a")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "a"))
+	err := template.SendBody("direct:start", "b")
+
+	var rejected *ThrottlerRejectedError
+	require.ErrorAs(t, err, &rejected)
+	assert.Equal(t, 1, rejected.MaxRequests)
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestThrottle_CorrelationExpression(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Throttle(1, time.Minute).
+		CorrelationExpression("${header.apiKey}").
+		RejectExecution().
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("a1", "b1")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "a1", map[string]any{"apiKey": "a"}))
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "b1", map[string]any{"apiKey": "b"}))
+
+	err := template.SendBodyAndHeaders("direct:start", "a2", map[string]any{"apiKey": "a"})
+	var rejected *ThrottlerRejectedError
+	require.ErrorAs(t, err, &rejected)
+	assert.Equal(t, "a", rejected.Key)
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestThrottle_MaxRequestsHeader(t *testing.T) {
+	camel := newTestContext()
+	throttle := camel.CreateRouteBuilder().
+		From("direct:start").
+		Throttle(1, time.Minute).
+		MaxRequestsHeader("rateLimit").
+		RejectExecution().
+		To("mock:result")
+	throttle.End().Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedMessageCount(3)
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBodyAndHeaders("direct:start", "a", map[string]any{"rateLimit": "3"}))
+	require.NoError(t, template.SendBody("direct:start", "b"))
+	require.NoError(t, template.SendBody("direct:start", "c"))
+	assert.Error(t, template.SendBody("direct:start", "d"))
+
+	assert.Equal(t, 3, throttle.Throttler().MaxRequests())
+	assert.Error(t, template.SendBodyAndHeaders("direct:start", "e", map[string]any{"rateLimit": "zero"}))
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+type countingSynchronization struct {
+	completed atomic.Int32
+	failed    atomic.Int32
+}
+
+func (s *countingSynchronization) OnComplete(exchange *Exchange) { s.completed.Add(1) }
+func (s *countingSynchronization) OnFailure(exchange *Exchange)  { s.failed.Add(1) }
+
+func TestThrottle_AsyncDelayed(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Throttle(1, 200*time.Millisecond).
+		AsyncDelayed().
+		To("mock:result").
+		End().
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("a", "b")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "a"))
+
+	// Le second échange est différé : l'appelant n'attend pas et la synchronisation
+	// n'est déclenchée qu'à la fin du traitement en arrière-plan
+	sync := &countingSynchronization{}
+	exchange := NewExchange(context.Background())
+	exchange.GetIn().SetBody("b")
+	exchange.AddSynchronization(sync)
+
+	started := time.Now()
+	err := template.Send("direct:start", exchange)
+	assert.ErrorIs(t, err, ErrStopRouting)
+	assert.Less(t, time.Since(started), 100*time.Millisecond)
+	exchange.Done(err)
+	assert.Equal(t, int32(0), sync.completed.Load()+sync.failed.Load())
+
+	result.AssertIsSatisfied(t, time.Second)
+	assert.Eventually(t, func() bool { return sync.completed.Load() == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestThrottle_AsyncDelayedStepsAfterEnd(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Throttle(1, 200*time.Millisecond).
+		AsyncDelayed().
+		To("mock:result").
+		End().
+		To("mock:after").
+		Build()
+
+	after, _ := camel.GetMockEndpoint("mock:after")
+	after.ExpectedBodiesReceived("a", "b")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "a"))
+	// L'échange différé doit lui aussi atteindre les étapes situées après End()
+	assert.ErrorIs(t, template.SendBody("direct:start", "b"), ErrStopRouting)
+
+	after.AssertIsSatisfied(t, time.Second)
+}
+
+func TestThrottle_AsyncDelayedNestedEnd(t *testing.T) {
+	camel := newTestContext()
+	filter := camel.CreateRouteBuilder().
+		From("direct:start").
+		FilterFunc(func(*Exchange) bool { return true })
+	filter.Throttle(1, 200*time.Millisecond).
+		AsyncDelayed().
+		To("mock:result").
+		End().
+		To("mock:after")
+	filter.End().
+		To("mock:outer").
+		Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("a", "b")
+	after, _ := camel.GetMockEndpoint("mock:after")
+	after.ExpectedBodiesReceived("a", "b")
+	outer, _ := camel.GetMockEndpoint("mock:outer")
+	outer.ExpectedBodiesReceived("a")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "a"))
+	assert.ErrorIs(t, template.SendBody("direct:start", "b"), ErrStopRouting)
+
+	// End() revient au filtre : l'échange différé poursuit les étapes du filtre, mais pas
+	// celles qui suivent le filtre dans la route
+	result.AssertIsSatisfied(t, time.Second)
+	after.AssertIsSatisfied(t, time.Second)
+	time.Sleep(50 * time.Millisecond)
+	outer.AssertIsSatisfied(t, 0)
+}
+
+func TestThrottle_StopInterruptsWaits(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Throttle(1, time.Minute).
+		To("mock:result").
+		End().
+		Build()
+
+	require.NoError(t, camel.Start())
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "a"))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- template.SendBody("direct:start", "b")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// Le hook d'arrêt du throttler réveille l'échange en attente avant l'arrêt des routes
+	require.NoError(t, camel.Stop())
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("throttled exchange was not interrupted by Stop")
+	}
+}
+
+func TestThrottle_ContextCancellation(t *testing.T) {
+	camel := newTestContext()
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		Throttle(1, time.Minute).
+		To("mock:result").
+		End().
+		Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	require.NoError(t, template.SendBody("direct:start", "a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	exchange := NewExchange(ctx)
+	exchange.GetIn().SetBody("b")
+	err := template.Send("direct:start", exchange)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+}
@@ -189,6 +189,8 @@ func (c *TimerConsumer) run(ctx context.Context) {
 			// Log l'error (in une vraie implémentation)
 			// fmt.Printf("error during traitement de l'événement timer: %v\n", err)
 		}
+		// Le consommateur termine l'échange qu'il a créé (synchronisations de l'idempotent consumer...)
+		exchange.Done(err)
 
 		if c.endpoint.repeatCount > 0 && counter >= c.endpoint.repeatCount {
 			return
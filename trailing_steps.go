@@ -0,0 +1,44 @@
+package gocamel
+
+// trailingSteps enregistre les étapes qui suivent un bloc dans son conteneur parent, pour les
+// EIPs dont les échanges ne reviennent pas dans ce conteneur (traitement différé en
+// arrière-plan) : ils exécutent eux-mêmes ces étapes après le bloc.
+//
+// Seules les étapes du conteneur parent sont reprises : si le bloc est lui-même imbriqué
+// (Split, Filter...), les étapes qui suivent le bloc englobant ne sont pas exécutées.
+type trailingSteps struct {
+	processors []Processor
+	captured   bool
+}
+
+// trailingContainer transmet les processeurs ajoutés au conteneur parent après le bloc, tout
+// en les enregistrant comme étapes suivantes
+type trailingContainer struct {
+	ProcessorContainer
+	steps *trailingSteps
+}
+
+// AddProcessor implémente l'interface ProcessorContainer
+func (c *trailingContainer) AddProcessor(processor Processor) {
+	c.ProcessorContainer.AddProcessor(processor)
+	c.steps.processors = append(c.steps.processors, processor)
+}
+
+// capture enregistre les étapes ajoutées ensuite au builder parent du bloc
+func (s *trailingSteps) capture(parent *RouteBuilder) {
+	if s.captured {
+		return
+	}
+	s.captured = true
+	parent.container = &trailingContainer{ProcessorContainer: parent.container, steps: s}
+}
+
+// process exécute les étapes qui suivent le bloc
+func (s *trailingSteps) process(exchange *Exchange) error {
+	for _, p := range s.processors {
+		if err := p.Process(exchange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
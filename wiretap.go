@@ -0,0 +1,208 @@
+package gocamel
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+)
+
+// DefaultWireTapPoolSize est le nombre maximal d'envois Wire Tap simultanés par défaut
+const DefaultWireTapPoolSize = 10
+
+// WireTapStats contient les compteurs d'un Wire Tap
+type WireTapStats struct {
+	// Sent est le nombre de copies envoyées avec succès
+	Sent int64
+	// Failed est le nombre de copies dont l'envoi a échoué
+	Failed int64
+	// Dropped est le nombre de copies abandonnées car toutes les places du pool étaient occupées
+	Dropped int64
+}
+
+// WireTap envoie une copie de l'échange vers un endpoint de manière asynchrone (fire-and-forget).
+// Le nombre d'envois simultanés est borné : lorsque le pool est saturé, la copie est abandonnée
+// et comptabilisée, sans jamais bloquer ni affecter la route principale.
+type WireTap struct {
+	context   *CamelContext
+	uri       string
+	send      Processor
+	slots     chan struct{}
+	onPrepare Processor
+	newBody   *SimpleTemplate
+	headers   map[string]*SimpleTemplate
+
+	sent    atomic.Int64
+	failed  atomic.Int64
+	dropped atomic.Int64
+}
+
+// NewWireTap crée un Wire Tap vers l'URI donnée
+func NewWireTap(context *CamelContext, uri string) *WireTap {
+	return &WireTap{
+		context: context,
+		uri:     uri,
+		send:    createToProcessor(context, uri),
+		slots:   make(chan struct{}, DefaultWireTapPoolSize),
+		headers: make(map[string]*SimpleTemplate),
+	}
+}
+
+// SetPoolSize définit le nombre maximal d'envois simultanés
+func (w *WireTap) SetPoolSize(size int) {
+	if size < 1 {
+		size = 1
+	}
+	w.slots = make(chan struct{}, size)
+}
+
+// SetOnPrepare définit un processeur appliqué à la copie avant son envoi
+func (w *WireTap) SetOnPrepare(processor Processor) {
+	w.onPrepare = processor
+}
+
+// SetNewBody remplace le corps de la copie par le résultat d'une expression Simple
+func (w *WireTap) SetNewBody(expression string) error {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		return err
+	}
+	w.newBody = template
+	return nil
+}
+
+// SetNewHeader définit un en-tête de la copie à partir d'une expression Simple
+func (w *WireTap) SetNewHeader(name, expression string) error {
+	template, err := ParseSimpleTemplate(expression)
+	if err != nil {
+		return err
+	}
+	w.headers[name] = template
+	return nil
+}
+
+// Stats retourne les compteurs du Wire Tap
+func (w *WireTap) Stats() WireTapStats {
+	return WireTapStats{
+		Sent:    w.sent.Load(),
+		Failed:  w.failed.Load(),
+		Dropped: w.dropped.Load(),
+	}
+}
+
+// Process implémente l'interface Processor. L'échange d'origine n'est jamais modifié.
+func (w *WireTap) Process(exchange *Exchange) error {
+	tapped := exchange.Copy()
+	propagateOut(tapped)
+	tapped.Out = NewMessage()
+	// La copie survit à l'échange d'origine : elle ne dépend pas de son contexte (ex: requête HTTP)
+	tapped.Context = context.Background()
+	if w.context != nil {
+		tapped.Context = w.context.GetContext()
+	}
+
+	// Les expressions sont évaluées sur l'état courant de l'échange, avant de rendre la main
+	var body interface{}
+	if w.newBody != nil {
+		value, err := w.newBody.Evaluate(tapped)
+		if err != nil {
+			// Le Wire Tap n'affecte jamais la route principale
+			w.failed.Add(1)
+			log.Printf("wireTap %s: erreur lors de l'évaluation du corps de l'échange %s: %v", w.uri, exchange.ID, err)
+			return nil
+		}
+		body = value
+	}
+	headers := make(map[string]interface{}, len(w.headers))
+	for name, template := range w.headers {
+		value, err := template.Evaluate(tapped)
+		if err != nil {
+			w.failed.Add(1)
+			log.Printf("wireTap %s: erreur lors de l'évaluation de l'en-tête %s de l'échange %s: %v", w.uri, name, exchange.ID, err)
+			return nil
+		}
+		headers[name] = value
+	}
+	if w.newBody != nil {
+		tapped.GetIn().SetBody(body)
+	}
+	for name, value := range headers {
+		tapped.GetIn().SetHeader(name, value)
+	}
+
+	slots := w.slots
+	select {
+	case slots <- struct{}{}:
+	default:
+		w.dropped.Add(1)
+		log.Printf("wireTap %s: pool saturé, copie de l'échange %s abandonnée", w.uri, exchange.ID)
+		return nil
+	}
+
+	go func() {
+		defer func() { <-slots }()
+		if err := w.tap(tapped); err != nil {
+			w.failed.Add(1)
+			log.Printf("wireTap %s: erreur lors de l'envoi de l'échange %s: %v", w.uri, tapped.ID, err)
+			return
+		}
+		w.sent.Add(1)
+	}()
+	return nil
+}
+
+func (w *WireTap) tap(exchange *Exchange) error {
+	if w.onPrepare != nil {
+		if err := w.onPrepare.Process(exchange); err != nil {
+			return err
+		}
+	}
+	return w.send.Process(exchange)
+}
+
+// WireTap ajoute un Wire Tap vers l'URI donnée. Les options de la définition retournée
+// s'appliquent au Wire Tap ; les autres méthodes continuent la route.
+func (b *RouteBuilder) WireTap(uri string) *WireTapDefinition {
+	w := NewWireTap(b.context, uri)
+	b.container.AddProcessor(w)
+	return &WireTapDefinition{RouteBuilder: b, wireTap: w}
+}
+
+// WireTapDefinition permet de configurer un Wire Tap
+type WireTapDefinition struct {
+	*RouteBuilder
+	wireTap *WireTap
+}
+
+// PoolSize définit le nombre maximal d'envois simultanés (DefaultWireTapPoolSize par défaut)
+func (d *WireTapDefinition) PoolSize(size int) *WireTapDefinition {
+	d.wireTap.SetPoolSize(size)
+	return d
+}
+
+// OnPrepare définit un processeur appliqué à la copie avant son envoi
+func (d *WireTapDefinition) OnPrepare(processor Processor) *WireTapDefinition {
+	d.wireTap.SetOnPrepare(processor)
+	return d
+}
+
+// NewBody remplace le corps de la copie par le résultat d'une expression Simple
+func (d *WireTapDefinition) NewBody(expression string) *WireTapDefinition {
+	if err := d.wireTap.SetNewBody(expression); err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for wireTap body: %v", err))
+	}
+	return d
+}
+
+// NewHeader définit un en-tête de la copie à partir d'une expression Simple
+func (d *WireTapDefinition) NewHeader(name, expression string) *WireTapDefinition {
+	if err := d.wireTap.SetNewHeader(name, expression); err != nil {
+		panic(fmt.Sprintf("failed to parse simple expression for wireTap header: %v", err))
+	}
+	return d
+}
+
+// Stats retourne les compteurs du Wire Tap
+func (d *WireTapDefinition) Stats() WireTapStats {
+	return d.wireTap.Stats()
+}
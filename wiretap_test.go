@@ -0,0 +1,142 @@
+package gocamel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWireTap_DoesNotBlockMainRoute(t *testing.T) {
+	camel := newTestContext()
+
+	release := make(chan struct{})
+	tap, _ := camel.GetMockEndpoint("mock:tap")
+	tap.WhenAnyExchangeReceived(ProcessorFunc(func(e *Exchange) error {
+		<-release
+		e.GetIn().SetBody("changed by tap")
+		return nil
+	}))
+	tap.ExpectedBodiesReceived("hello")
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		WireTap("mock:tap").
+		To("mock:result").
+		Build()
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("hello")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	done := make(chan error, 1)
+	go func() { done <- camel.CreateProducerTemplate().SendBody("direct:start", "hello") }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("main route blocked by wire tap")
+	}
+	result.AssertIsSatisfied(t, time.Second)
+
+	close(release)
+	tap.AssertIsSatisfied(t, time.Second)
+}
+
+func TestWireTap_DropsWhenPoolSaturated(t *testing.T) {
+	camel := newTestContext()
+
+	release := make(chan struct{})
+	tap, _ := camel.GetMockEndpoint("mock:tap")
+	tap.WhenAnyExchangeReceived(ProcessorFunc(func(e *Exchange) error {
+		<-release
+		return nil
+	}))
+
+	definition := camel.CreateRouteBuilder().
+		From("direct:start").
+		WireTap("mock:tap").PoolSize(1)
+	definition.Build()
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	template := camel.CreateProducerTemplate()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, template.SendBody("direct:start", i))
+	}
+	assert.Equal(t, int64(2), definition.Stats().Dropped)
+
+	close(release)
+	assert.Eventually(t, func() bool { return definition.Stats().Sent == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(0), definition.Stats().Failed)
+}
+
+func TestWireTap_NewBodyHeadersAndOnPrepare(t *testing.T) {
+	camel := newTestContext()
+
+	camel.CreateRouteBuilder().
+		From("direct:start").
+		SetHeader("orderId", "42").
+		WireTap("mock:audit").
+		NewBody("audit of ${body}").
+		NewHeader("auditId", "${header.orderId}").
+		OnPrepare(ProcessorFunc(func(e *Exchange) error {
+			e.GetIn().SetHeader("prepared", true)
+			return nil
+		})).
+		To("mock:result").
+		Build()
+
+	audit, _ := camel.GetMockEndpoint("mock:audit")
+	audit.ExpectedBodiesReceived("audit of order")
+	audit.ExpectedHeaderReceived("auditId", "42")
+	audit.ExpectedHeaderReceived("prepared", true)
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("order")
+	result.MessageN(0).Predicate(func(e *Exchange) bool {
+		_, prepared := e.GetIn().GetHeader("prepared")
+		_, audited := e.GetIn().GetHeader("auditId")
+		return !prepared && !audited
+	})
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "order"))
+
+	audit.AssertIsSatisfied(t, time.Second)
+	result.AssertIsSatisfied(t, time.Second)
+}
+
+func TestWireTap_InvalidExpressionPanics(t *testing.T) {
+	camel := NewCamelContext()
+	assert.Panics(t, func() {
+		camel.CreateRouteBuilder().From("direct:start").WireTap("mock:tap").NewBody("${unclosed")
+	})
+}
+
+func TestWireTap_ExpressionErrorDoesNotFailMainRoute(t *testing.T) {
+	camel := newTestContext()
+
+	definition := camel.CreateRouteBuilder().
+		From("direct:start").
+		WireTap("mock:tap").
+		NewBody("${unknownExpression}")
+	definition.To("mock:result").Build()
+
+	result, _ := camel.GetMockEndpoint("mock:result")
+	result.ExpectedBodiesReceived("order")
+
+	require.NoError(t, camel.Start())
+	defer camel.Stop()
+
+	// L'échec de l'évaluation est compté mais n'affecte pas la route principale
+	require.NoError(t, camel.CreateProducerTemplate().SendBody("direct:start", "order"))
+	result.AssertIsSatisfied(t, time.Second)
+	tap, _ := camel.GetMockEndpoint("mock:tap")
+	assert.Equal(t, 0, tap.ReceivedCounter())
+	assert.Equal(t, int64(1), definition.Stats().Failed)
+}